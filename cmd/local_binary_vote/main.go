@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ecdsa"
 	"crypto/elliptic"
 	"encoding/json"
 	"fmt"
@@ -73,6 +74,16 @@ func main() {
 
 	fmt.Printf("Init a vote for %d voters\n\n", nVote)
 	v, _ := vote.NewBinaryVote(gkX, gkY, authAddr)
+	if err := v.Open(); err != nil {
+		panic(err)
+	}
+	// receipts signed by the authority
+	if err := v.SetReceiptKey(&ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: c, X: gkX, Y: gkY},
+		D:         k,
+	}); err != nil {
+		panic(err)
+	}
 
 	printline()
 	fmt.Println()
@@ -80,6 +91,7 @@ func main() {
 	fmt.Printf("Generate and cast encrypted ballots\n")
 	values := []bool{true, true, false, false, true}
 	nYes := 3
+	var ballots []*vote.BinaryBallot
 
 	for i := 0; i < 5; i++ {
 		// voter, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -123,7 +135,20 @@ func main() {
 
 		fmt.Printf("\nZKProof verification: PASS\n")
 
-		v.Cast(b, voterAddr)
+		rc, err := v.CastWithReceipt(b, voterAddr)
+		if err != nil {
+			panic(err)
+		}
+		ballots = append(ballots, b)
+
+		if data, err := json.Marshal(rc); err != nil {
+			panic(err)
+		} else {
+			file := fmt.Sprintf("./receipt_%d.json", i)
+			if err := ioutil.WriteFile(file, data, 0664); err != nil {
+				panic(err)
+			}
+		}
 
 		fmt.Println()
 	}
@@ -131,7 +156,18 @@ func main() {
 	printline()
 	fmt.Println()
 
+	if data, err := json.Marshal(ballots); err != nil {
+		panic(err)
+	} else {
+		if err := ioutil.WriteFile("./ballots.json", data, 0664); err != nil {
+			panic(err)
+		}
+	}
+
 	fmt.Printf("Tally voting result\n")
+	if err := v.Close(); err != nil {
+		panic(err)
+	}
 	if err := v.Tally(k); err != nil {
 		panic(err)
 	}
@@ -12,11 +12,16 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/ring"
 
 	"github.com/urfave/cli/v2"
+	"github.com/zzGHzz/zkVote/dkg"
+	"github.com/zzGHzz/zkVote/dlog"
 	"github.com/zzGHzz/zkVote/vote"
+	"github.com/zzGHzz/zkVote/zk"
 )
 
 var (
@@ -30,6 +35,131 @@ var (
 		Aliases:  []string{"o"},
 		Required: true,
 	}
+	boundFlag *cli.IntFlag = &cli.IntFlag{
+		Name:  "bound",
+		Usage: "upper bound of the tally result, the number of ballots by default",
+	}
+	tableFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "dlog-table",
+		Usage: "baby-step table file generated by gen-dlog-table",
+	}
+	sizeFlag *cli.IntFlag = &cli.IntFlag{
+		Name:  "size",
+		Value: 1 << 16,
+		Usage: "number of baby steps",
+	}
+	thresholdFlag *cli.IntFlag = &cli.IntFlag{
+		Name:     "threshold",
+		Aliases:  []string{"t"},
+		Required: true,
+		Usage:    "number of shares needed to decrypt",
+	}
+	sharesFlag *cli.IntFlag = &cli.IntFlag{
+		Name:     "shares",
+		Aliases:  []string{"n"},
+		Required: true,
+		Usage:    "number of shares",
+	}
+	addrFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "address",
+		Value: "0x00",
+		Usage: "address of the account the trustee will use to interact with the voting contract",
+	}
+	indexFlag *cli.IntFlag = &cli.IntFlag{
+		Name:     "index",
+		Required: true,
+		Usage:    "index of the trustee starting from 1",
+	}
+	stateFlag *cli.StringFlag = &cli.StringFlag{
+		Name:     "state",
+		Required: true,
+		Usage:    "secret state file generated by dkg-init",
+	}
+	commitFlag *cli.StringSliceFlag = &cli.StringSliceFlag{
+		Name:  "commit",
+		Usage: "commitment file broadcast by a trustee",
+	}
+	shareFlag *cli.StringSliceFlag = &cli.StringSliceFlag{
+		Name:  "share",
+		Usage: "share file received from a trustee",
+	}
+	complaintFlag *cli.StringSliceFlag = &cli.StringSliceFlag{
+		Name:  "complaint",
+		Usage: "complaint file broadcast by a trustee",
+	}
+	responseFlag *cli.StringSliceFlag = &cli.StringSliceFlag{
+		Name:  "response",
+		Usage: "response file broadcast by a trustee",
+	}
+	registryFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "registry",
+		Usage: "root of the voter registry, ballots must prove eligibility if set",
+	}
+	ringFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "ring",
+		Usage: "ring of anonymous voter keys, ballots must carry ring signatures if set",
+	}
+	minVotersFlag *cli.IntFlag = &cli.IntFlag{
+		Name:  "min-voters",
+		Usage: "minimum number of ballots for the quorum",
+	}
+	maxVotersFlag *cli.IntFlag = &cli.IntFlag{
+		Name:  "max-voters",
+		Usage: "maximum number of ballots, more fail the tally",
+	}
+	passYesFlag *cli.IntFlag = &cli.IntFlag{
+		Name:  "pass-threshold",
+		Usage: "minimum number of yes votes to pass",
+	}
+	passRatioFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "pass-ratio",
+		Usage: "minimum ratio of yes votes to pass, e.g., 2/3",
+	}
+	electionFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "election",
+		Usage: "election manifest generated by init-election",
+	}
+	idFlag *cli.StringFlag = &cli.StringFlag{
+		Name:     "id",
+		Usage:    "election id",
+		Required: true,
+	}
+	questionFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "question",
+		Usage: "question voted on",
+	}
+	optionFlag *cli.StringSliceFlag = &cli.StringSliceFlag{
+		Name:  "option",
+		Usage: "meaning of ballot value 0, 1, ... (default: no, yes)",
+	}
+	sheetQuestionFlag *cli.StringSliceFlag = &cli.StringSliceFlag{
+		Name:  "sheet-question",
+		Usage: "question answered on ballot sheets, repeated for each",
+	}
+	startFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "start",
+		Usage: "start of the voting period (RFC 3339)",
+	}
+	endFlag *cli.StringFlag = &cli.StringFlag{
+		Name:  "end",
+		Usage: "end of the voting period (RFC 3339)",
+	}
+	spoilFlag *cli.BoolFlag = &cli.BoolFlag{
+		Name:  "spoil",
+		Usage: "reveal a and v of the ballots for audit instead of casting them",
+	}
+	typedFlag *cli.BoolFlag = &cli.BoolFlag{
+		Name:  "typed",
+		Usage: "tag each ballot with its kind so that ballots of different kinds can be mixed",
+	}
+	workersFlag *cli.IntFlag = &cli.IntFlag{
+		Name:  "workers",
+		Usage: "number of goroutines verifying and aggregating ballots, one per CPU if 0",
+	}
+	excludeFlag *cli.BoolFlag = &cli.BoolFlag{
+		Name:  "exclude-invalid",
+		Usage: "exclude invalid ballots with the reasons and commit to them in the tally result",
+	}
 	// fileFlag *cli.StringFlag = &cli.StringFlag{
 	// 	Name:    "file",
 	// 	Aliases: []string{"f"},
@@ -52,41 +182,344 @@ func main() {
 				},
 				Action: genPrivKey,
 			},
+			{
+				Name:  "init-election",
+				Usage: "Create election manifest",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					idFlag,
+					questionFlag,
+					optionFlag,
+					sheetQuestionFlag,
+					registryFlag,
+					startFlag,
+					endFlag,
+				},
+				Action: initElection,
+			},
 			{
 				Name:  "gen-bin-ballot",
 				Usage: "Generate binary ballot(s)",
 				Flags: []cli.Flag{
 					inFlag,
 					outFlag,
+					electionFlag,
+					ringFlag,
+					spoilFlag,
+					typedFlag,
 				},
 				Action: genBinaryBallots,
 			},
+			{
+				Name:   "audit",
+				Usage:  "Audit spoiled ballot(s)",
+				Flags:  []cli.Flag{inFlag},
+				Action: audit,
+			},
 			{
 				Name:  "ver-bin-ballot",
 				Usage: "Verify yes/no ballots",
 				Flags: []cli.Flag{
 					inFlag,
 					outFlag,
+					registryFlag,
+					ringFlag,
+					electionFlag,
+					workersFlag,
 				},
+				Before: checkWorkers,
 				Action: verifyBinaryBallots,
 			},
+			{
+				Name:  "ver-ballots",
+				Usage: "Verify ballots of any kind tagged with their kinds",
+				Flags: []cli.Flag{
+					inFlag,
+				},
+				Action: verifyTypedBallots,
+			},
+			{
+				Name:  "build-registry",
+				Usage: "Build voter registry from a CSV or JSON voter list",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+				},
+				Action: buildRegistry,
+			},
+			{
+				Name:  "build-ring",
+				Usage: "Build ring of anonymous voter keys from key files",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+				},
+				Action: buildRing,
+			},
 			{
 				Name:  "tally",
 				Usage: "Tally voting result",
 				Flags: []cli.Flag{
 					inFlag,
 					outFlag,
+					boundFlag,
+					tableFlag,
+					registryFlag,
+					ringFlag,
+					minVotersFlag,
+					maxVotersFlag,
+					passYesFlag,
+					passRatioFlag,
+					electionFlag,
+					workersFlag,
+					excludeFlag,
 				},
+				Before: checkWorkers,
 				Action: tally,
 			},
+			{
+				Name:  "aggregate",
+				Usage: "Verify and aggregate a shard of yes/no ballots",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					registryFlag,
+					ringFlag,
+					electionFlag,
+					workersFlag,
+				},
+				Before: checkWorkers,
+				Action: aggregate,
+			},
+			{
+				Name:  "mix",
+				Usage: "Shuffle and re-encrypt yes/no ballots or the output of a mix",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					registryFlag,
+					ringFlag,
+					electionFlag,
+					workersFlag,
+				},
+				Before: checkWorkers,
+				Action: mix,
+			},
+			{
+				Name:  "verify-mix",
+				Usage: "Verify a chain of mixes of yes/no ballots",
+				Flags: []cli.Flag{
+					inFlag,
+					registryFlag,
+					ringFlag,
+					electionFlag,
+					workersFlag,
+				},
+				Before: checkWorkers,
+				Action: verifyMix,
+			},
+			{
+				Name:  "decrypt-mix",
+				Usage: "Decrypt the output of a mix one by one with proofs",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					electionFlag,
+				},
+				Action: decryptMix,
+			},
+			{
+				Name:  "verify-decrypt-mix",
+				Usage: "Verify the decryption of the output of a mix",
+				Flags: []cli.Flag{
+					inFlag,
+					electionFlag,
+				},
+				Action: verifyDecryptMix,
+			},
+			{
+				Name:  "merge",
+				Usage: "Merge aggregates of disjoint shards of ballots",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+				},
+				Action: merge,
+			},
 			{
 				Name:  "ver-tally",
 				Usage: "Verify tally result",
 				Flags: []cli.Flag{
 					inFlag,
+					registryFlag,
+					ringFlag,
+					minVotersFlag,
+					maxVotersFlag,
+					passYesFlag,
+					passRatioFlag,
+					electionFlag,
+					workersFlag,
 				},
+				Before: checkWorkers,
 				Action: verifyTallyResult,
 			},
+			{
+				Name:  "verify-receipt",
+				Usage: "Verify that a receipted ballot is counted in a tally result",
+				Flags: []cli.Flag{
+					inFlag,
+					registryFlag,
+					ringFlag,
+					minVotersFlag,
+					maxVotersFlag,
+					passYesFlag,
+					passRatioFlag,
+					electionFlag,
+					workersFlag,
+				},
+				Before: checkWorkers,
+				Action: verifyReceipt,
+			},
+			{
+				Name:  "gen-sheet",
+				Usage: "Generate ballot sheet(s) answering the questions of an election",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					electionFlag,
+					typedFlag,
+				},
+				Action: genSheets,
+			},
+			{
+				Name:  "tally-sheet",
+				Usage: "Tally each question of ballot sheets",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					boundFlag,
+					tableFlag,
+					registryFlag,
+					minVotersFlag,
+					maxVotersFlag,
+					passYesFlag,
+					passRatioFlag,
+					electionFlag,
+				},
+				Action: tallySheets,
+			},
+			{
+				Name:  "ver-sheet-tally",
+				Usage: "Verify tally result of ballot sheets",
+				Flags: []cli.Flag{
+					inFlag,
+					registryFlag,
+					minVotersFlag,
+					maxVotersFlag,
+					passYesFlag,
+					passRatioFlag,
+					electionFlag,
+				},
+				Action: verifySheetTallyResult,
+			},
+			{
+				Name:  "gen-dlog-table",
+				Usage: "Generate baby-step table for recovering tally results",
+				Flags: []cli.Flag{
+					outFlag,
+					sizeFlag,
+				},
+				Action: genDLogTable,
+			},
+			{
+				Name:  "split-key",
+				Usage: "Split authority private key into shares held by trustees",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					thresholdFlag,
+					sharesFlag,
+				},
+				Action: splitKey,
+			},
+			{
+				Name:  "partial-decrypt",
+				Usage: "Compute partial decryption of the tally with a key share",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					addrFlag,
+					registryFlag,
+					ringFlag,
+					electionFlag,
+				},
+				Action: partialDecrypt,
+			},
+			{
+				Name:  "combine",
+				Usage: "Tally voting result from partial decryptions",
+				Flags: []cli.Flag{
+					inFlag,
+					outFlag,
+					boundFlag,
+					tableFlag,
+					registryFlag,
+					ringFlag,
+					minVotersFlag,
+					maxVotersFlag,
+					passYesFlag,
+					passRatioFlag,
+					electionFlag,
+				},
+				Action: combine,
+			},
+			{
+				Name:  "dkg-init",
+				Usage: "Start distributed key generation as a trustee",
+				Flags: []cli.Flag{
+					outFlag,
+					indexFlag,
+					thresholdFlag,
+					sharesFlag,
+					addrFlag,
+				},
+				Action: dkgInit,
+			},
+			{
+				Name:  "dkg-verify",
+				Usage: "Verify received shares and complain against dealers",
+				Flags: []cli.Flag{
+					outFlag,
+					stateFlag,
+					commitFlag,
+					shareFlag,
+				},
+				Action: dkgVerify,
+			},
+			{
+				Name:  "dkg-respond",
+				Usage: "Reveal shares disputed by complaints",
+				Flags: []cli.Flag{
+					outFlag,
+					stateFlag,
+					complaintFlag,
+				},
+				Action: dkgRespond,
+			},
+			{
+				Name:  "dkg-finalize",
+				Usage: "Compute key share and joint public key",
+				Flags: []cli.Flag{
+					outFlag,
+					stateFlag,
+					commitFlag,
+					shareFlag,
+					complaintFlag,
+					responseFlag,
+				},
+				Action: dkgFinalize,
+			},
 		},
 	}
 
@@ -126,6 +559,49 @@ func genPrivKey(ctx *cli.Context) error {
 	return nil
 }
 
+func initElection(ctx *cli.Context) error {
+	gkX, gkY, err := readPublicKey(ctx.StringSlice(inFlag.Name)[0])
+	if err != nil {
+		return err
+	}
+
+	var options []string
+	if ctx.IsSet(optionFlag.Name) {
+		options = ctx.StringSlice(optionFlag.Name)
+	}
+	m, err := vote.NewManifest(ctx.String(idFlag.Name), ctx.String(questionFlag.Name), options, gkX, gkY)
+	if err != nil {
+		return err
+	}
+	if ctx.IsSet(sheetQuestionFlag.Name) {
+		m.Questions = ctx.StringSlice(sheetQuestionFlag.Name)
+	}
+
+	if m.Registry, err = registryRoot(ctx, nil); err != nil {
+		return err
+	}
+	if s := ctx.String(startFlag.Name); s != "" {
+		if m.Start, err = time.Parse(time.RFC3339, s); err != nil {
+			return err
+		}
+	}
+	if s := ctx.String(endFlag.Name); s != "" {
+		if m.End, err = time.Parse(time.RFC3339, s); err != nil {
+			return err
+		}
+	}
+	if err = m.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
 func genBinaryBallots(ctx *cli.Context) error {
 	var (
 		a, gkX, gkY, addr *big.Int
@@ -133,6 +609,7 @@ func genBinaryBallots(ctx *cli.Context) error {
 
 		data    []byte
 		ballots []*vote.BinaryBallot
+		spoiled []*vote.SpoiledBallot
 	)
 
 	data, err = ioutil.ReadFile(ctx.StringSlice(inFlag.Name)[0])
@@ -158,31 +635,102 @@ func genBinaryBallots(ctx *cli.Context) error {
 		return err
 	}
 
+	m, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if m != nil && (m.GKX.Cmp(gkX) != 0 || m.GKY.Cmp(gkY) != 0) {
+		return errors.New("Authority key doesn't match election")
+	}
+
+	// Attach eligibility proofs if a registry is given
+	var reg *vote.Registry
+	if inFiles := ctx.StringSlice(inFlag.Name); len(inFiles) > 1 {
+		reg = new(vote.Registry)
+		if err := readJSONFile(inFiles[1], reg); err != nil {
+			return err
+		}
+	}
+
+	// Sign anonymously if a ring is given
+	r, err := readRing(ctx)
+	if err != nil {
+		return err
+	}
+	if r != nil && reg != nil {
+		return errors.New("Registry and ring both given")
+	}
+
 	for _, d := range input.Data {
 		// Convert string to big.Int
 		if a, err = common.HexStrToBigInt(d.A); err != nil {
 			return err
 		}
-		if addr, err = common.HexStrToBigInt(d.Address); err != nil {
-			return err
-		}
 
 		// Generate binary ballot
-		b, err := vote.NewBinaryBallot(d.V != 0, a, gkX, gkY, addr)
-		if err != nil {
-			return err
+		var b *vote.BinaryBallot
+		if r != nil {
+			x, err := common.HexStrToBigInt(d.Key)
+			if err != nil {
+				return err
+			}
+			if m != nil {
+				b, err = vote.NewAnonymousBinaryBallotInElection(m, d.V != 0, a, x, r)
+			} else {
+				b, err = vote.NewAnonymousBinaryBallot(d.V != 0, a, gkX, gkY, x, r)
+			}
+			if err != nil {
+				return err
+			}
+		} else {
+			if addr, err = common.HexStrToBigInt(d.Address); err != nil {
+				return err
+			}
+			if m != nil {
+				b, err = vote.NewBinaryBallotInElection(m, d.V != 0, a, addr)
+			} else {
+				b, err = vote.NewBinaryBallot(d.V != 0, a, gkX, gkY, addr)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if reg != nil {
+			e, err := reg.Eligibility(addr)
+			if err != nil {
+				return err
+			}
+			b.SetEligibility(e)
 		}
 
 		ballots = append(ballots, b)
+		if ctx.Bool(spoilFlag.Name) {
+			s, err := vote.NewSpoiledBallot(b, a, d.V != 0)
+			if err != nil {
+				return err
+			}
+			spoiled = append(spoiled, s)
+		}
 	}
 
 	file := ctx.String(outFlag.Name)
 	// if file == "" {
 	// 	file = "bin-ballot.json"
 	// }
-	if len(ballots) == 1 {
+	switch {
+	case len(spoiled) == 1:
+		data, err = json.Marshal(spoiled[0])
+	case len(spoiled) > 1:
+		data, err = json.Marshal(spoiled)
+	case ctx.Bool(typedFlag.Name):
+		typed := make([]*vote.TypedBallot, len(ballots))
+		for i, b := range ballots {
+			typed[i] = &vote.TypedBallot{Ballot: b}
+		}
+		data, err = json.Marshal(typed)
+	case len(ballots) == 1:
 		data, err = json.Marshal(ballots[0])
-	} else {
+	default:
 		data, err = json.Marshal(ballots)
 	}
 	if err != nil {
@@ -211,6 +759,47 @@ func genBinaryBallots(ctx *cli.Context) error {
 	return nil
 }
 
+func audit(ctx *cli.Context) error {
+	data, err := ioutil.ReadFile(ctx.StringSlice(inFlag.Name)[0])
+	if err != nil {
+		return err
+	}
+
+	var spoiled []*vote.SpoiledBallot
+	if err := json.Unmarshal(data, &spoiled); err != nil {
+		s := new(vote.SpoiledBallot)
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+		spoiled = []*vote.SpoiledBallot{s}
+	}
+
+	pass := true
+	for i, s := range spoiled {
+		h, err := s.Ballot().Hash()
+		if err != nil {
+			return err
+		}
+		v := 0
+		if s.Value() {
+			v = 1
+		}
+		fmt.Printf("Ballot [%d]: hash = %x, v = %d\n", i, h, v)
+
+		if err := s.Audit(); err != nil {
+			fmt.Printf("Audit: FAIL (%v)\n", err)
+			pass = false
+			continue
+		}
+		fmt.Println("Audit: PASS")
+	}
+	if !pass {
+		return errors.New("Audit failed")
+	}
+
+	return nil
+}
+
 func verifyBinaryBallots(ctx *cli.Context) error {
 	data, err := ioutil.ReadFile(ctx.StringSlice(inFlag.Name)[0])
 	if err != nil {
@@ -227,16 +816,23 @@ func verifyBinaryBallots(ctx *cli.Context) error {
 		return err
 	}
 
-	var invalids []string
-	var valids []*vote.BinaryBallot
-	for _, ballot := range ballots {
-		if err := ballot.VerifyBallot(); err != nil {
-			obj := ballot.BuildJSONBinaryBallot()
-			invalids = append(invalids, obj.Proof.Data)
-		} else {
-			valids = append(valids, ballot)
-		}
+	m, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	root, err := registryRoot(ctx, m)
+	if err != nil {
+		return err
+	}
+	var gkX, gkY *big.Int
+	if m != nil {
+		gkX, gkY = m.GKX, m.GKY
+	}
+	r, err := readRing(ctx)
+	if err != nil {
+		return err
 	}
+	valids, invalids := splitBinaryBallots(ballots, gkX, gkY, m, root, r, ctx.Int(workersFlag.Name))
 
 	data, err = json.Marshal(invalids)
 	if err != nil {
@@ -259,83 +855,1000 @@ func verifyBinaryBallots(ctx *cli.Context) error {
 	return nil
 }
 
-func tally(ctx *cli.Context) error {
-	outDir := ctx.String(outFlag.Name)
-	if _, err := os.Stat(outDir); os.IsNotExist(err) {
-		return errors.New("out_dir does not exist")
-	}
-
+func verifyTypedBallots(ctx *cli.Context) error {
 	inFiles := ctx.StringSlice(inFlag.Name)
-	if len(inFiles) < 2 {
-		return errors.New("Not enough input files")
-	}
 
-	data1, err := ioutil.ReadFile(inFiles[0])
-	if err != nil {
-		return err
-	}
-	data2, err := ioutil.ReadFile(inFiles[1])
-	if err != nil {
+	var ballots []*vote.TypedBallot
+	if err := readJSONFile(inFiles[0], &ballots); err != nil {
 		return err
 	}
 
-	var (
+	// check the authority key if given
+	var gkX, gkY *big.Int
+	if len(inFiles) > 1 {
+		var err error
+		if gkX, gkY, err = readPublicKey(inFiles[1]); err != nil {
+			return err
+		}
+	}
+
+	pass := true
+	for i, b := range ballots {
+		if b == nil || b.Ballot == nil {
+			fmt.Printf("Ballot [%d]: missing\n", i)
+			fmt.Println("Verify: FAIL")
+			pass = false
+			continue
+		}
+		err := b.VerifyBallot()
+		if err != nil {
+			fmt.Printf("Ballot [%d]: kind = %s\n", i, b.Kind())
+		} else {
+			fmt.Printf("Ballot [%d]: kind = %s, voter = %s\n", i, b.Kind(), common.BigIntToHexStr(b.Voter()))
+		}
+		if err == nil && gkX != nil {
+			if X, Y := b.PublicKey(); X.Cmp(gkX) != 0 || Y.Cmp(gkY) != 0 {
+				err = errors.New("Ballot not encrypted with g^k")
+			}
+		}
+		if err != nil {
+			fmt.Printf("Verify: FAIL (%v)\n", err)
+			pass = false
+			continue
+		}
+		fmt.Println("Verify: PASS")
+	}
+	if !pass {
+		return errors.New("Verification failed")
+	}
+
+	return nil
+}
+
+func buildRegistry(ctx *cli.Context) error {
+	voters, weights, err := readVoterList(ctx.StringSlice(inFlag.Name)[0])
+	if err != nil {
+		return err
+	}
+
+	reg, err := vote.NewRegistry(voters, weights)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func buildRing(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) == 0 {
+		return errors.New("Not enough input files")
+	}
+
+	xs := make([]*big.Int, len(inFiles))
+	ys := make([]*big.Int, len(inFiles))
+	for i, file := range inFiles {
+		var err error
+		if xs[i], ys[i], err = readPublicKey(file); err != nil {
+			return err
+		}
+	}
+
+	r, err := ring.NewRing(xs, ys)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func aggregate(ctx *cli.Context) error {
+	outDir := ctx.String(outFlag.Name)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		return errors.New("out_dir does not exist")
+	}
+
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) < 2 {
+		return errors.New("Not enough input files")
+	}
+
+	var ballots []*vote.BinaryBallot
+	if err := readJSONFile(inFiles[0], &ballots); err != nil {
+		return err
+	}
+	gkX, gkY, err := readPublicKey(inFiles[1])
+	if err != nil {
+		return err
+	}
+
+	p, m, root, err := electionParams(ctx, gkX, gkY, new(big.Int))
+	if err != nil {
+		return err
+	}
+
+	valids, invalids := splitBinaryBallots(ballots, gkX, gkY, m, root, p.Ring(), ctx.Int(workersFlag.Name))
+	agg, err := vote.NewAggregate(p, valids, ctx.Int(workersFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(agg)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, "bin-aggregate.json"), data, 0700); err != nil {
+		return err
+	}
+
+	// write addresses of the invalid ballots
+	if data, err = json.Marshal(invalids); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outDir, "invalid-bin-addr.json"), data, 0700)
+}
+
+func mix(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) != 2 {
+		return errors.New("Need ballots or a mix, and a public key")
+	}
+
+	gkX, gkY, err := readPublicKey(inFiles[1])
+	if err != nil {
+		return err
+	}
+	p, m, root, err := electionParams(ctx, gkX, gkY, new(big.Int))
+	if err != nil {
+		return err
+	}
+
+	// ballots, or the mix whose output is shuffled again
+	data, err := ioutil.ReadFile(inFiles[0])
+	if err != nil {
+		return err
+	}
+	var (
+		ballots []*vote.BinaryBallot
+		in      []*zk.Ciphertext
+	)
+	if err := json.Unmarshal(data, &ballots); err == nil {
+		valids, _ := splitBinaryBallots(ballots, gkX, gkY, m, root, p.Ring(), ctx.Int(workersFlag.Name))
+		if in, err = vote.MixInput(p, valids); err != nil {
+			return err
+		}
+	} else {
+		prev := new(vote.Mix)
+		if err := json.Unmarshal(data, prev); err != nil {
+			return err
+		}
+		if err := prev.Verify(p); err != nil {
+			return err
+		}
+		in = prev.Output()
+	}
+
+	res, err := vote.NewMix(p, in)
+	if err != nil {
+		return err
+	}
+	if data, err = json.Marshal(res); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func verifyMix(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) < 3 {
+		return errors.New("Need ballots, mixes and a public key")
+	}
+
+	var ballots []*vote.BinaryBallot
+	if err := readJSONFile(inFiles[0], &ballots); err != nil {
+		return err
+	}
+	var mixes []*vote.Mix
+	for _, file := range inFiles[1 : len(inFiles)-1] {
+		m := new(vote.Mix)
+		if err := readJSONFile(file, m); err != nil {
+			return err
+		}
+		mixes = append(mixes, m)
+	}
+	gkX, gkY, err := readPublicKey(inFiles[len(inFiles)-1])
+	if err != nil {
+		return err
+	}
+	p, m, root, err := electionParams(ctx, gkX, gkY, new(big.Int))
+	if err != nil {
+		return err
+	}
+
+	// the first mix shuffles only the valid ballots
+	valids, _ := splitBinaryBallots(ballots, gkX, gkY, m, root, p.Ring(), ctx.Int(workersFlag.Name))
+	if err := vote.VerifyMixes(p, valids, mixes); err != nil {
+		fmt.Println("Verify mix: FAIL")
+		return err
+	}
+
+	fmt.Println("Verify mix: PASS")
+
+	return nil
+}
+
+func decryptMix(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) != 2 {
+		return errors.New("Need a mix and authority data")
+	}
+
+	m := new(vote.Mix)
+	if err := readJSONFile(inFiles[0], m); err != nil {
+		return err
+	}
+	var authData AuthDataForTally
+	if err := readJSONFile(inFiles[1], &authData); err != nil {
+		return err
+	}
+
+	var (
+		gkX, gkY, k, addr *big.Int
+		err               error
+	)
+	if gkX, err = common.HexStrToBigInt(authData.GKX); err != nil {
+		return err
+	}
+	if gkY, err = common.HexStrToBigInt(authData.GKY); err != nil {
+		return err
+	}
+	if k, err = common.HexStrToBigInt(authData.K); err != nil {
+		return err
+	}
+	if addr, err = common.HexStrToBigInt(authData.Address); err != nil {
+		return err
+	}
+	p, _, _, err := electionParams(ctx, gkX, gkY, addr)
+	if err != nil {
+		return err
+	}
+
+	d, err := vote.DecryptMix(p, k, m)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func verifyDecryptMix(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) != 3 {
+		return errors.New("Need a mix, its decryption and a public key")
+	}
+
+	m := new(vote.Mix)
+	if err := readJSONFile(inFiles[0], m); err != nil {
+		return err
+	}
+	d := new(vote.MixDecryption)
+	if err := readJSONFile(inFiles[1], d); err != nil {
+		return err
+	}
+	gkX, gkY, err := readPublicKey(inFiles[2])
+	if err != nil {
+		return err
+	}
+	p, _, _, err := electionParams(ctx, gkX, gkY, new(big.Int))
+	if err != nil {
+		return err
+	}
+
+	if err := d.Verify(p, m); err != nil {
+		fmt.Println("Verify mix decryption: FAIL")
+		return err
+	}
+
+	yes := 0
+	for _, v := range d.Values() {
+		if v {
+			yes++
+		}
+	}
+	fmt.Printf("No. YES = %d, No. NO = %d\n", yes, len(d.Values())-yes)
+	fmt.Println("Verify mix decryption: PASS")
+
+	return nil
+}
+
+func merge(ctx *cli.Context) error {
+	var parts []*vote.Aggregate
+	for _, file := range ctx.StringSlice(inFlag.Name) {
+		agg := new(vote.Aggregate)
+		if err := readJSONFile(file, agg); err != nil {
+			return err
+		}
+		parts = append(parts, agg)
+	}
+
+	merged, err := vote.MergeAggregates(parts)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func tally(ctx *cli.Context) error {
+	outDir := ctx.String(outFlag.Name)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		return errors.New("out_dir does not exist")
+	}
+
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) < 2 {
+		return errors.New("Not enough input files")
+	}
+
+	data1, err := ioutil.ReadFile(inFiles[0])
+	if err != nil {
+		return err
+	}
+	data2, err := ioutil.ReadFile(inFiles[1])
+	if err != nil {
+		return err
+	}
+
+	var (
 		ballots  []*vote.BinaryBallot
+		agg      *vote.Aggregate
+		authData AuthDataForTally
+	)
+
+	// authority data and either ballots or an aggregate of ballots, in any
+	// order
+	if err := json.Unmarshal(data1, &authData); err != nil || authData.K == "" {
+		data1, data2 = data2, data1
+		if err := json.Unmarshal(data1, &authData); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data2, &ballots); err != nil {
+		agg = new(vote.Aggregate)
+		if err := json.Unmarshal(data2, agg); err != nil {
+			return err
+		}
+	}
+
+	var (
+		gkX, gkY, k, addr *big.Int
+		tal               *vote.BinaryTally
+		invalids          []string
+		excluded          []*vote.ExcludedBallot
+		res               *vote.BinaryTallyRes
+		data              []byte
+	)
+
+	if gkX, err = common.HexStrToBigInt(authData.GKX); err != nil {
+		return err
+	}
+	if gkY, err = common.HexStrToBigInt(authData.GKY); err != nil {
+		return err
+	}
+	if k, err = common.HexStrToBigInt(authData.K); err != nil {
+		return err
+	}
+	if addr, err = common.HexStrToBigInt(authData.Address); err != nil {
+		return err
+	}
+
+	switch {
+	case agg != nil:
+		if ctx.Bool(excludeFlag.Name) {
+			return errors.New("Can't exclude ballots of an aggregate")
+		}
+		if tal, err = newBinaryTallyFromAggregate(ctx, gkX, gkY, addr, agg); err != nil {
+			return err
+		}
+		invalids = []string{}
+	case ctx.Bool(excludeFlag.Name):
+		if tal, excluded, err = newBinaryTallyExcluding(ctx, gkX, gkY, addr, ballots); err != nil {
+			return err
+		}
+		invalids = []string{}
+		for _, e := range excluded {
+			if b := ballots[e.Index]; b != nil {
+				invalids = append(invalids, common.BigIntToHexStr(b.Voter()))
+			}
+		}
+	default:
+		if tal, invalids, err = newBinaryTally(ctx, gkX, gkY, addr, ballots); err != nil {
+			return err
+		}
+	}
+
+	if ctx.IsSet(boundFlag.Name) {
+		if err = tal.SetBound(ctx.Int(boundFlag.Name)); err != nil {
+			return err
+		}
+	}
+
+	if file := ctx.String(tableFlag.Name); file != "" {
+		if err = loadDLogTable(file); err != nil {
+			return err
+		}
+	}
+
+	if res, err = tal.Tally(k); err != nil {
+		return err
+	}
+
+	// write tally result
+	if data, err = json.Marshal(res); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, "bin-tally-res.json"), data, 0700); err != nil {
+		return err
+	}
+
+	// write addresses of the invalid ballots
+	if data, err = json.Marshal(invalids); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, "invalid-bin-addr.json"), data, 0700); err != nil {
+		return err
+	}
+
+	// write the excluded ballots with the reasons
+	if excluded != nil {
+		if data, err = json.Marshal(excluded); err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(filepath.Join(outDir, "excluded-bin-ballots.json"), data, 0700); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verifyTallyResult(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) != 1 && len(inFiles) != 3 {
+		return errors.New("Need a tally result, or ballots, a tally result and a public key")
+	}
+
+	// a lone tally result, or ballots or their aggregate, the tally result
+	// and the authority public key
+	resFile := inFiles[0]
+	if len(inFiles) == 3 {
+		resFile = inFiles[1]
+	}
+
+	res := new(vote.BinaryTallyRes)
+	if err := readJSONFile(resFile, res); err != nil {
+		return err
+	}
+
+	if err := verifyTally(ctx, res); err != nil {
+		fmt.Println("Verify tally result: FAIL")
+		return err
+	}
+
+	fmt.Println("Verify tally result: PASS")
+
+	return nil
+}
+
+func verifyTally(ctx *cli.Context, res *vote.BinaryTallyRes) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) == 1 {
+		m, err := readElection(ctx)
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			if err := res.CheckElection(m); err != nil {
+				return err
+			}
+		}
+		return res.Verify()
+	}
+
+	data, err := ioutil.ReadFile(inFiles[0])
+	if err != nil {
+		return err
+	}
+	var (
+		ballots []*vote.BinaryBallot
+		agg     *vote.Aggregate
+	)
+	if err := json.Unmarshal(data, &ballots); err != nil {
+		agg = new(vote.Aggregate)
+		if err := json.Unmarshal(data, agg); err != nil {
+			return err
+		}
+	}
+
+	gkX, gkY, err := readPublicKey(inFiles[2])
+	if err != nil {
+		return err
+	}
+	p, m, root, err := electionParams(ctx, gkX, gkY, new(big.Int))
+	if err != nil {
+		return err
+	}
+	if err := setTallyRules(ctx, p); err != nil {
+		return err
+	}
+
+	if agg != nil {
+		return res.VerifyAggregate(p, agg)
+	}
+
+	// the tally counts only the valid ballots, either excluded by the tally
+	// itself or filtered out before
+	if res.ExcludesInvalid() {
+		return res.VerifyBallots(p, ballots, ctx.Int(workersFlag.Name))
+	}
+	valids, _ := splitBinaryBallots(ballots, gkX, gkY, m, root, p.Ring(), ctx.Int(workersFlag.Name))
+	return res.VerifyBallots(p, valids, ctx.Int(workersFlag.Name))
+}
+
+func genSheets(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+
+	var input DataForGenSheets
+	if err := readJSONFile(inFiles[0], &input); err != nil {
+		return err
+	}
+
+	m, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return errors.New("Missing election")
+	}
+
+	// Attach eligibility proofs if a registry is given
+	var reg *vote.Registry
+	if len(inFiles) > 1 {
+		reg = new(vote.Registry)
+		if err := readJSONFile(inFiles[1], reg); err != nil {
+			return err
+		}
+	}
+
+	var sheets []*vote.BallotSheet
+	for _, d := range input.Data {
+		addr, err := common.HexStrToBigInt(d.Address)
+		if err != nil {
+			return err
+		}
+		values := make([]bool, len(d.V))
+		for i, v := range d.V {
+			values[i] = v != 0
+		}
+
+		s, err := vote.NewBallotSheet(m, values, addr)
+		if err != nil {
+			return err
+		}
+		if reg != nil {
+			e, err := reg.Eligibility(addr)
+			if err != nil {
+				return err
+			}
+			s.SetEligibility(e)
+		}
+		sheets = append(sheets, s)
+	}
+
+	var data []byte
+	if ctx.Bool(typedFlag.Name) {
+		typed := make([]*vote.TypedBallot, len(sheets))
+		for i, s := range sheets {
+			typed[i] = &vote.TypedBallot{Ballot: s}
+		}
+		data, err = json.Marshal(typed)
+	} else {
+		data, err = json.Marshal(sheets)
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func tallySheets(ctx *cli.Context) error {
+	outDir := ctx.String(outFlag.Name)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		return errors.New("out_dir does not exist")
+	}
+
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) != 2 {
+		return errors.New("Need ballot sheets and authority data")
+	}
+
+	var (
+		sheets   []*vote.BallotSheet
 		authData AuthDataForTally
 	)
+	if err := readJSONFile(inFiles[0], &sheets); err != nil {
+		return err
+	}
+	if err := readJSONFile(inFiles[1], &authData); err != nil {
+		return err
+	}
+
+	var (
+		gkX, gkY, k, addr *big.Int
+		err               error
+	)
+	if gkX, err = common.HexStrToBigInt(authData.GKX); err != nil {
+		return err
+	}
+	if gkY, err = common.HexStrToBigInt(authData.GKY); err != nil {
+		return err
+	}
+	if k, err = common.HexStrToBigInt(authData.K); err != nil {
+		return err
+	}
+	if addr, err = common.HexStrToBigInt(authData.Address); err != nil {
+		return err
+	}
+
+	p, m, root, err := sheetParams(ctx, gkX, gkY, addr)
+	if err != nil {
+		return err
+	}
+	valids, invalids := splitSheets(sheets, m, root)
+	tal, err := vote.NewSheetTally(p, len(m.Questions), valids)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet(boundFlag.Name) {
+		if err = tal.SetBound(ctx.Int(boundFlag.Name)); err != nil {
+			return err
+		}
+	}
+
+	if file := ctx.String(tableFlag.Name); file != "" {
+		if err = loadDLogTable(file); err != nil {
+			return err
+		}
+	}
+
+	res, err := tal.Tally(k)
+	if err != nil {
+		return err
+	}
+
+	// write tally result
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, "sheet-tally-res.json"), data, 0700); err != nil {
+		return err
+	}
+
+	// write addresses of the invalid sheets
+	if data, err = json.Marshal(invalids); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outDir, "invalid-sheet-addr.json"), data, 0700)
+}
+
+func verifySheetTallyResult(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) != 1 && len(inFiles) != 3 {
+		return errors.New("Need a tally result, or ballot sheets, a tally result and a public key")
+	}
+
+	resFile := inFiles[0]
+	if len(inFiles) == 3 {
+		resFile = inFiles[1]
+	}
+
+	res := new(vote.SheetTallyRes)
+	if err := readJSONFile(resFile, res); err != nil {
+		return err
+	}
+
+	if err := verifySheetTally(ctx, res); err != nil {
+		fmt.Println("Verify tally result: FAIL")
+		return err
+	}
+
+	fmt.Println("Verify tally result: PASS")
+
+	return nil
+}
+
+func verifySheetTally(ctx *cli.Context, res *vote.SheetTallyRes) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) == 1 {
+		m, err := readElection(ctx)
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			if err := res.CheckElection(m); err != nil {
+				return err
+			}
+		}
+		return res.Verify()
+	}
+
+	var sheets []*vote.BallotSheet
+	if err := readJSONFile(inFiles[0], &sheets); err != nil {
+		return err
+	}
+
+	gkX, gkY, err := readPublicKey(inFiles[2])
+	if err != nil {
+		return err
+	}
+	p, m, root, err := sheetParams(ctx, gkX, gkY, new(big.Int))
+	if err != nil {
+		return err
+	}
+	if err := res.CheckElection(m); err != nil {
+		return err
+	}
+
+	// the tally counts only the valid sheets
+	valids, _ := splitSheets(sheets, m, root)
+	return res.VerifyBallots(p, valids)
+}
+
+func verifyReceipt(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) != 4 {
+		return errors.New("Need a receipt, a tally result, ballots and a public key")
+	}
+
+	rc := new(vote.Receipt)
+	if err := readJSONFile(inFiles[0], rc); err != nil {
+		return err
+	}
+	res := new(vote.BinaryTallyRes)
+	if err := readJSONFile(inFiles[1], res); err != nil {
+		return err
+	}
+	var ballots []*vote.BinaryBallot
+	if err := readJSONFile(inFiles[2], &ballots); err != nil {
+		return err
+	}
+	gkX, gkY, err := readPublicKey(inFiles[3])
+	if err != nil {
+		return err
+	}
+
+	if err := checkReceipt(ctx, rc, res, ballots, gkX, gkY); err != nil {
+		fmt.Println("Verify receipt: FAIL")
+		return err
+	}
+
+	fmt.Println("Verify receipt: PASS")
+
+	return nil
+}
+
+// checkReceipt checks the receipt signed with (gkX, gkY), that res is the
+// tally of ballots and that the receipted ballot is on the board of the
+// valid ballots committed in res
+func checkReceipt(ctx *cli.Context, rc *vote.Receipt, res *vote.BinaryTallyRes, ballots []*vote.BinaryBallot, gkX, gkY *big.Int) error {
+	if err := rc.Verify(gkX, gkY); err != nil {
+		return err
+	}
+
+	p, m, registry, err := electionParams(ctx, gkX, gkY, new(big.Int))
+	if err != nil {
+		return err
+	}
+	if err := setTallyRules(ctx, p); err != nil {
+		return err
+	}
+
+	// the tally counts only the valid ballots; the commitment of a result
+	// combined from partial decryptions is only bound by tallying them again
+	valids, _ := splitBinaryBallots(ballots, gkX, gkY, m, registry, p.Ring(), ctx.Int(workersFlag.Name))
+	if res.ExcludesInvalid() {
+		err = res.VerifyBallots(p, ballots, ctx.Int(workersFlag.Name))
+	} else {
+		err = res.VerifyBallots(p, valids, ctx.Int(workersFlag.Name))
+	}
+	if err != nil {
+		return err
+	}
+
+	root, err := res.BallotsRoot()
+	if err != nil {
+		return err
+	}
+	bd, err := vote.NewBinaryBoard(valids)
+	if err != nil {
+		return err
+	}
+	return rc.VerifyInclusion(root, bd)
+}
+
+func splitKey(ctx *cli.Context) error {
+	outDir := ctx.String(outFlag.Name)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		return errors.New("out_dir does not exist")
+	}
+
+	data, err := ioutil.ReadFile(ctx.StringSlice(inFlag.Name)[0])
+	if err != nil {
+		return err
+	}
+
+	var key Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return err
+	}
+	k, err := common.HexStrToBigInt(key.K)
+	if err != nil {
+		return err
+	}
+
+	pub, shares, err := vote.SplitKey(k, ctx.Int(thresholdFlag.Name), ctx.Int(sharesFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(pub); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, "threshold-key.json"), data, 0700); err != nil {
+		return err
+	}
 
-	if err := json.Unmarshal(data1, &authData); err != nil {
-		if err := json.Unmarshal(data2, &authData); err != nil {
+	for _, s := range shares {
+		if data, err = json.Marshal(s); err != nil {
 			return err
 		}
-		if err := json.Unmarshal(data1, &ballots); err != nil {
+		fname := fmt.Sprintf("key-share-%d.json", s.Index())
+		if err = ioutil.WriteFile(filepath.Join(outDir, fname), data, 0700); err != nil {
 			return err
 		}
-	} else if err := json.Unmarshal(data2, &ballots); err != nil {
-		return err
 	}
 
-	var invalids []string
-	var valids []*vote.BinaryBallot
-	for _, ballot := range ballots {
-		if err := ballot.VerifyBallot(); err != nil {
-			obj := ballot.BuildJSONBinaryBallot()
-			invalids = append(invalids, obj.Proof.Data)
-		} else {
-			valids = append(valids, ballot)
-		}
+	return nil
+}
+
+func partialDecrypt(ctx *cli.Context) error {
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) < 2 {
+		return errors.New("Not enough input files")
 	}
 
 	var (
-		gkX, gkY, k, addr *big.Int
-		tal               *vote.BinaryTally
-		res               *vote.BinaryTallyRes
-		data              []byte
+		ballots []*vote.BinaryBallot
+		share   vote.KeyShare
 	)
 
-	if gkX, err = common.HexStrToBigInt(authData.GKX); err != nil {
+	if err := readJSONFile(inFiles[0], &ballots); err != nil {
 		return err
 	}
-	if gkY, err = common.HexStrToBigInt(authData.GKY); err != nil {
+	if err := readJSONFile(inFiles[1], &share); err != nil {
 		return err
 	}
-	if k, err = common.HexStrToBigInt(authData.K); err != nil {
+	if err := share.Verify(); err != nil {
 		return err
 	}
-	if addr, err = common.HexStrToBigInt(authData.Address); err != nil {
+
+	addr, err := common.HexStrToBigInt(ctx.String(addrFlag.Name))
+	if err != nil {
 		return err
 	}
 
-	if tal, err = vote.NewBinaryTally(gkX, gkY, addr, valids); err != nil {
+	gkX, gkY := share.ThresholdKey().PublicKey()
+	tal, _, err := newBinaryTally(ctx, gkX, gkY, addr, ballots)
+	if err != nil {
 		return err
 	}
 
-	if res, err = tal.Tally(k); err != nil {
+	HX, HY := tal.H()
+	m, err := readElection(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := share.PartialDecryptInElection(m, HX, HY, addr)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func combine(ctx *cli.Context) error {
+	outDir := ctx.String(outFlag.Name)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		return errors.New("out_dir does not exist")
+	}
+
+	inFiles := ctx.StringSlice(inFlag.Name)
+	if len(inFiles) < 3 {
+		return errors.New("Not enough input files")
+	}
+
+	var (
+		ballots  []*vote.BinaryBallot
+		pub      vote.ThresholdKey
+		partials []*vote.PartialDecryption
+	)
+
+	if err := readJSONFile(inFiles[0], &ballots); err != nil {
+		return err
+	}
+	if err := readJSONFile(inFiles[1], &pub); err != nil {
+		return err
+	}
+	for _, file := range inFiles[2:] {
+		d := new(vote.PartialDecryption)
+		if err := readJSONFile(file, d); err != nil {
+			return err
+		}
+		partials = append(partials, d)
+	}
+
+	gkX, gkY := pub.PublicKey()
+	tal, invalids, err := newBinaryTally(ctx, gkX, gkY, big.NewInt(0), ballots)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet(boundFlag.Name) {
+		if err = tal.SetBound(ctx.Int(boundFlag.Name)); err != nil {
+			return err
+		}
+	}
+
+	if file := ctx.String(tableFlag.Name); file != "" {
+		if err = loadDLogTable(file); err != nil {
+			return err
+		}
+	}
+
+	res, err := tal.Combine(&pub, partials)
+	if err != nil {
 		return err
 	}
 
 	// write tally result
-	if data, err = json.Marshal(res); err != nil {
+	data, err := json.Marshal(res)
+	if err != nil {
 		return err
 	}
 	if err = ioutil.WriteFile(filepath.Join(outDir, "bin-tally-res.json"), data, 0700); err != nil {
@@ -353,27 +1866,181 @@ func tally(ctx *cli.Context) error {
 	return nil
 }
 
-func verifyTallyResult(ctx *cli.Context) error {
-	data, err := ioutil.ReadFile(ctx.StringSlice(inFlag.Name)[0])
+func dkgInit(ctx *cli.Context) error {
+	outDir := ctx.String(outFlag.Name)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		return errors.New("out_dir does not exist")
+	}
+
+	addr, err := common.HexStrToBigInt(ctx.String(addrFlag.Name))
 	if err != nil {
 		return err
 	}
 
-	var res *vote.BinaryTallyRes
+	p, err := dkg.NewParticipant(ctx.Int(indexFlag.Name), ctx.Int(thresholdFlag.Name), ctx.Int(sharesFlag.Name))
+	if err != nil {
+		return err
+	}
+	c, err := p.Commit(addr)
+	if err != nil {
+		return err
+	}
 
-	if err := json.Unmarshal(data, &res); err != nil {
+	// secret state kept by the trustee
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, fmt.Sprintf("dkg-state-%d.json", p.Index())), data, 0600); err != nil {
 		return err
 	}
 
-	if err := res.Verify(); err != nil {
-		fmt.Println("Verify tally result: FAIL")
+	// broadcast
+	if data, err = json.Marshal(c); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, fmt.Sprintf("dkg-commit-%d.json", p.Index())), data, 0700); err != nil {
+		return err
 	}
 
-	fmt.Println("Verify tally result: PASS")
+	// sent privately to each trustee
+	for _, s := range p.Shares() {
+		if data, err = json.Marshal(s); err != nil {
+			return err
+		}
+		fname := fmt.Sprintf("dkg-share-%d-%d.json", s.Dealer(), s.Recipient())
+		if err = ioutil.WriteFile(filepath.Join(outDir, fname), data, 0600); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+func dkgVerify(ctx *cli.Context) error {
+	p, commits, err := readDKGRound(ctx)
+	if err != nil {
+		return err
+	}
+
+	var shares []*dkg.Share
+	for _, file := range ctx.StringSlice(shareFlag.Name) {
+		s := new(dkg.Share)
+		if err := readJSONFile(file, s); err != nil {
+			return err
+		}
+		shares = append(shares, s)
+	}
+
+	complaints := p.Check(commits, shares)
+	if complaints == nil {
+		complaints = []*dkg.Complaint{}
+	}
+
+	data, err := json.Marshal(complaints)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func dkgRespond(ctx *cli.Context) error {
+	var p dkg.Participant
+	if err := readJSONFile(ctx.String(stateFlag.Name), &p); err != nil {
+		return err
+	}
+
+	complaints, err := readDKGComplaints(ctx)
+	if err != nil {
+		return err
+	}
+
+	responses := p.Respond(complaints)
+	if responses == nil {
+		responses = []*dkg.Share{}
+	}
+
+	data, err := json.Marshal(responses)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.String(outFlag.Name), data, 0700)
+}
+
+func dkgFinalize(ctx *cli.Context) error {
+	outDir := ctx.String(outFlag.Name)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		return errors.New("out_dir does not exist")
+	}
+
+	p, commits, err := readDKGRound(ctx)
+	if err != nil {
+		return err
+	}
+
+	var shares, responses []*dkg.Share
+	for _, file := range ctx.StringSlice(shareFlag.Name) {
+		s := new(dkg.Share)
+		if err := readJSONFile(file, s); err != nil {
+			return err
+		}
+		shares = append(shares, s)
+	}
+	for _, file := range ctx.StringSlice(responseFlag.Name) {
+		var rs []*dkg.Share
+		if err := readJSONFile(file, &rs); err != nil {
+			return err
+		}
+		responses = append(responses, rs...)
+	}
+
+	complaints, err := readDKGComplaints(ctx)
+	if err != nil {
+		return err
+	}
+
+	share, pub, err := p.Finalize(commits, shares, complaints, responses)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(pub)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(outDir, "threshold-key.json"), data, 0700); err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(share); err != nil {
+		return err
+	}
+	fname := fmt.Sprintf("key-share-%d.json", share.Index())
+	return ioutil.WriteFile(filepath.Join(outDir, fname), data, 0600)
+}
+
+func genDLogTable(ctx *cli.Context) error {
+	size := ctx.Int(sizeFlag.Name)
+	if size <= 0 {
+		return errors.New("size must be larger than zero")
+	}
+
+	b, err := dlog.NewBSGS(elliptic.P256(), uint64(size))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(ctx.String(outFlag.Name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return b.Save(f)
+}
+
 // func genRandValidBallots(ctx *cli.Context) error {
 // 	outDir := ctx.String(outFlag.Name)
 // 	if _, err := os.Stat(outDir); os.IsNotExist(err) {
@@ -5,6 +5,7 @@ type VoterData struct {
 	A       string `json:"a"`
 	Address string `json:"address"`
 	V       uint   `json:"v"`
+	Key     string `json:"key,omitempty"` // private key in the ring of anonymous voters
 }
 
 // DataForGenBinaryBallots contains data to create binary ballots
@@ -14,6 +15,17 @@ type DataForGenBinaryBallots struct {
 	Data []*VoterData `json:"data"`
 }
 
+// SheetData contains data from voter for a ballot sheet
+type SheetData struct {
+	Address string `json:"address"`
+	V       []uint `json:"v"` // answers to the questions in order
+}
+
+// DataForGenSheets contains data to create ballot sheets
+type DataForGenSheets struct {
+	Data []*SheetData `json:"data"`
+}
+
 // Key contains a private key and its corresponding public key
 type Key struct {
 	K string `json:"k"`
@@ -28,3 +40,9 @@ type AuthDataForTally struct {
 	GKX     string `json:"gkx"`
 	GKY     string `json:"gky"`
 }
+
+// RegistryEntry contains a voter in the voter list used to build a registry
+type RegistryEntry struct {
+	Address string  `json:"address"`
+	Weight  *uint64 `json:"weight,omitempty"` // one if omitted
+}
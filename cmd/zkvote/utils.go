@@ -4,12 +4,25 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	rnd "math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 
+	"github.com/urfave/cli/v2"
 	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/dkg"
+	"github.com/zzGHzz/zkVote/dlog"
+	"github.com/zzGHzz/zkVote/merkle"
+	"github.com/zzGHzz/zkVote/ring"
 
 	"github.com/zzGHzz/zkVote/vote"
 )
@@ -90,3 +103,446 @@ func invalidBinaryBallots(n int) []*vote.BinaryBallot {
 
 	return ballots
 }
+
+// loadDLogTable loads a baby-step table and uses it for tallying
+func loadDLogTable(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b, err := dlog.LoadBSGS(curve, f)
+	if err != nil {
+		return err
+	}
+	vote.SetDLogSolver(dlog.NewSolverWithTable(curve, b))
+
+	return nil
+}
+
+// checkWorkers checks the number of goroutines verifying and aggregating
+// ballots given by --workers
+func checkWorkers(ctx *cli.Context) error {
+	if ctx.Int(workersFlag.Name) < 0 {
+		return errors.New("Invalid number of workers")
+	}
+	return nil
+}
+
+// splitBinaryBallots separates valid ballots from invalid ones which are
+// identified by voting account addresses. If gk is given, ballots encrypted
+// with other keys are invalid. If m is given, ballots of other elections are
+// invalid. If root is given, ballots without a valid eligibility proof and
+// repeated ballots of a voter are invalid. If r is given, ballots without a
+// valid ring signature and repeated ballots of a key image are invalid.
+// Ballots are checked on the given number of goroutines, one per CPU if zero.
+func splitBinaryBallots(ballots []*vote.BinaryBallot, gkX, gkY *big.Int, m *vote.Manifest, root *[32]byte, r *ring.Ring, workers int) ([]*vote.BinaryBallot, []string) {
+	// the checks of each ballot are done in parallel, repeated voters in
+	// order
+	valid := make([]bool, len(ballots))
+	common.ParallelFor(len(ballots), workers, func(i int) {
+		valid[i] = checkBinaryBallot(ballots[i], gkX, gkY, m, root, r) == nil
+	})
+
+	var invalids []string
+	var valids []*vote.BinaryBallot
+	voters := make(map[string]bool)
+	for i, ballot := range ballots {
+		if ballot == nil {
+			// missing, without address
+			continue
+		}
+		data := common.BigIntToHexStr(ballot.Voter())
+		if !valid[i] || ((root != nil || r != nil) && voters[data]) {
+			invalids = append(invalids, data)
+			continue
+		}
+		if root != nil || r != nil {
+			voters[data] = true
+		}
+		valids = append(valids, ballot)
+	}
+	return valids, invalids
+}
+
+// checkBinaryBallot checks a ballot as splitBinaryBallots does, except for
+// repeated voters
+func checkBinaryBallot(ballot *vote.BinaryBallot, gkX, gkY *big.Int, m *vote.Manifest, root *[32]byte, r *ring.Ring) error {
+	if ballot == nil {
+		return errors.New("Missing ballot")
+	}
+	if err := ballot.VerifyBallot(); err != nil {
+		return err
+	}
+	if gkX != nil {
+		if err := ballot.CheckKey(gkX, gkY); err != nil {
+			return err
+		}
+	}
+	if err := ballot.CheckElection(m); err != nil {
+		return err
+	}
+	if root != nil {
+		if err := ballot.VerifyEligibility(*root); err != nil {
+			return err
+		}
+	}
+	if r != nil {
+		if err := ballot.VerifySignature(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitSheets separates valid ballot sheets of the election described by m
+// from invalid ones, as splitBinaryBallots does
+func splitSheets(sheets []*vote.BallotSheet, m *vote.Manifest, root *[32]byte) ([]*vote.BallotSheet, []string) {
+	invalids := []string{}
+	var valids []*vote.BallotSheet
+	voters := make(map[string]bool)
+	for _, s := range sheets {
+		if s == nil {
+			// missing, without address
+			continue
+		}
+		if err := s.VerifyBallot(); err != nil {
+			if s.Size() > 0 && s.Ballot(0) != nil {
+				invalids = append(invalids, s.Ballot(0).BuildJSONBinaryBallot().Proof.Data)
+			}
+			continue
+		}
+		data := s.Ballot(0).BuildJSONBinaryBallot().Proof.Data
+		if err := s.CheckKey(m.GKX, m.GKY); err != nil {
+			invalids = append(invalids, data)
+			continue
+		}
+		if err := s.CheckElection(m); err != nil {
+			invalids = append(invalids, data)
+			continue
+		}
+		if root != nil {
+			if err := s.VerifyEligibility(*root); err != nil || voters[data] {
+				invalids = append(invalids, data)
+				continue
+			}
+			voters[data] = true
+		}
+		valids = append(valids, s)
+	}
+	return valids, invalids
+}
+
+// readElection reads the election manifest given by --election, nil if not
+// set
+func readElection(ctx *cli.Context) (*vote.Manifest, error) {
+	file := ctx.String(electionFlag.Name)
+	if file == "" {
+		return nil, nil
+	}
+	m := new(vote.Manifest)
+	if err := readJSONFile(file, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// registryRoot reads the registry root given by --registry, or that of the
+// election manifest m, nil if neither is set
+func registryRoot(ctx *cli.Context, m *vote.Manifest) (*[32]byte, error) {
+	s := ctx.String(registryFlag.Name)
+	if s == "" {
+		if m != nil {
+			return m.Registry, nil
+		}
+		return nil, nil
+	}
+	root, err := merkle.HexStrToHash(s)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && (m.Registry == nil || *m.Registry != root) {
+		return nil, errors.New("Registry doesn't match election")
+	}
+	return &root, nil
+}
+
+// setTallyRules sets the turnout limits and pass rule given by flags
+func setTallyRules(ctx *cli.Context, p *vote.ElectionParams) error {
+	if err := p.SetVoterLimits(ctx.Int(minVotersFlag.Name), ctx.Int(maxVotersFlag.Name)); err != nil {
+		return err
+	}
+
+	var num, den int
+	if s := ctx.String(passRatioFlag.Name); s != "" {
+		ratio := strings.Split(s, "/")
+		if len(ratio) != 2 {
+			return errors.New("Invalid pass ratio")
+		}
+		var err error
+		if num, err = strconv.Atoi(ratio[0]); err != nil {
+			return err
+		}
+		if den, err = strconv.Atoi(ratio[1]); err != nil {
+			return err
+		}
+	}
+
+	return p.SetPassRule(ctx.Int(passYesFlag.Name), num, den)
+}
+
+// readRing reads the ring of anonymous voter keys given by --ring, nil if
+// not set
+func readRing(ctx *cli.Context) (*ring.Ring, error) {
+	file := ctx.String(ringFlag.Name)
+	if file == "" {
+		return nil, nil
+	}
+	r := new(ring.Ring)
+	if err := readJSONFile(file, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// electionParams builds the election parameters from the manifest given by
+// --election, if any, the registry given by --registry and the ring given by
+// --ring
+func electionParams(ctx *cli.Context, gkX, gkY, addr *big.Int) (*vote.ElectionParams, *vote.Manifest, *[32]byte, error) {
+	m, err := readElection(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	root, err := registryRoot(ctx, m)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var p *vote.ElectionParams
+	if m != nil {
+		if m.GKX.Cmp(gkX) != 0 || m.GKY.Cmp(gkY) != 0 {
+			return nil, nil, nil, errors.New("Authority key doesn't match election")
+		}
+		p, err = m.Params(addr)
+	} else {
+		p, err = vote.NewElectionParams(gkX, gkY, addr)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if root != nil {
+		if err := p.SetRegistry(*root); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	r, err := readRing(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if r != nil {
+		if err := p.SetRing(r); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return p, m, root, nil
+}
+
+// sheetParams builds the election parameters of ballot sheets, which need
+// the manifest given by --election
+func sheetParams(ctx *cli.Context, gkX, gkY, addr *big.Int) (*vote.ElectionParams, *vote.Manifest, *[32]byte, error) {
+	p, m, root, err := electionParams(ctx, gkX, gkY, addr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if m == nil || len(m.Questions) == 0 {
+		return nil, nil, nil, errors.New("Missing election with questions")
+	}
+	if err := setTallyRules(ctx, p); err != nil {
+		return nil, nil, nil, err
+	}
+	return p, m, root, nil
+}
+
+// newBinaryTally verifies ballots and creates a tally restricted to the
+// election, registry, ring and rules given by flags
+func newBinaryTally(ctx *cli.Context, gkX, gkY, addr *big.Int, ballots []*vote.BinaryBallot) (*vote.BinaryTally, []string, error) {
+	p, m, root, err := electionParams(ctx, gkX, gkY, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := setTallyRules(ctx, p); err != nil {
+		return nil, nil, err
+	}
+
+	valids, invalids := splitBinaryBallots(ballots, gkX, gkY, m, root, p.Ring(), ctx.Int(workersFlag.Name))
+	tal, err := vote.NewBinaryTallyWithParams(p, valids, ctx.Int(workersFlag.Name))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tal, invalids, nil
+}
+
+// newBinaryTallyExcluding creates a tally that excludes invalid ballots,
+// which are returned with the reasons
+func newBinaryTallyExcluding(ctx *cli.Context, gkX, gkY, addr *big.Int, ballots []*vote.BinaryBallot) (*vote.BinaryTally, []*vote.ExcludedBallot, error) {
+	p, _, _, err := electionParams(ctx, gkX, gkY, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := setTallyRules(ctx, p); err != nil {
+		return nil, nil, err
+	}
+
+	return vote.NewBinaryTallyExcluding(p, ballots, ctx.Int(workersFlag.Name))
+}
+
+// newBinaryTallyFromAggregate creates a tally from an aggregate of ballots
+func newBinaryTallyFromAggregate(ctx *cli.Context, gkX, gkY, addr *big.Int, agg *vote.Aggregate) (*vote.BinaryTally, error) {
+	p, _, _, err := electionParams(ctx, gkX, gkY, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := setTallyRules(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return vote.NewBinaryTallyFromAggregate(p, agg)
+}
+
+// readPublicKey reads the authority public key from a key file, authority
+// data for tally or threshold-key.json
+func readPublicKey(file string) (*big.Int, *big.Int, error) {
+	var (
+		key      Key
+		auth     AuthDataForTally
+		gkX, gkY *big.Int
+		err      error
+	)
+	if err = readJSONFile(file, &key); err != nil {
+		return nil, nil, err
+	}
+	if err = readJSONFile(file, &auth); err != nil {
+		return nil, nil, err
+	}
+	if key.X == "" {
+		key.X, key.Y = auth.GKX, auth.GKY
+	}
+	if key.X != "" {
+		if gkX, err = common.HexStrToBigInt(key.X); err != nil {
+			return nil, nil, err
+		}
+		if gkY, err = common.HexStrToBigInt(key.Y); err != nil {
+			return nil, nil, err
+		}
+		return gkX, gkY, nil
+	}
+
+	var pub vote.ThresholdKey
+	if err = readJSONFile(file, &pub); err != nil {
+		return nil, nil, err
+	}
+	gkX, gkY = pub.PublicKey()
+	return gkX, gkY, nil
+}
+
+func readJSONFile(file string, v interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// readDKGRound reads the trustee state and the broadcast commitments
+func readDKGRound(ctx *cli.Context) (*dkg.Participant, []*dkg.Commitment, error) {
+	p := new(dkg.Participant)
+	if err := readJSONFile(ctx.String(stateFlag.Name), p); err != nil {
+		return nil, nil, err
+	}
+
+	var commits []*dkg.Commitment
+	for _, file := range ctx.StringSlice(commitFlag.Name) {
+		c := new(dkg.Commitment)
+		if err := readJSONFile(file, c); err != nil {
+			return nil, nil, err
+		}
+		commits = append(commits, c)
+	}
+
+	return p, commits, nil
+}
+
+// readDKGComplaints reads all the broadcast complaints
+func readDKGComplaints(ctx *cli.Context) ([]*dkg.Complaint, error) {
+	var complaints []*dkg.Complaint
+	for _, file := range ctx.StringSlice(complaintFlag.Name) {
+		var cs []*dkg.Complaint
+		if err := readJSONFile(file, &cs); err != nil {
+			return nil, err
+		}
+		complaints = append(complaints, cs...)
+	}
+	return complaints, nil
+}
+
+// readVoterList reads voters and their weights from a JSON file containing
+// an array of {address, weight} or a CSV file with lines "address[,weight]".
+// Weight is one if omitted.
+func readVoterList(file string) ([]*big.Int, []uint64, error) {
+	var entries []*RegistryEntry
+
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		if err := readJSONFile(file, &entries); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, rec := range records {
+			if len(rec) == 0 || rec[0] == "" {
+				continue
+			}
+			e := &RegistryEntry{Address: rec[0]}
+			if len(rec) > 1 && rec[1] != "" {
+				w, err := strconv.ParseUint(rec[1], 10, 64)
+				if err != nil {
+					return nil, nil, err
+				}
+				e.Weight = &w
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, nil, errors.New("Empty voter list")
+	}
+
+	voters := make([]*big.Int, len(entries))
+	weights := make([]uint64, len(entries))
+	for i, e := range entries {
+		var err error
+		if voters[i], err = common.HexStrToBigInt(e.Address); err != nil {
+			return nil, nil, err
+		}
+		weights[i] = 1
+		if e.Weight != nil {
+			weights[i] = *e.Weight
+		}
+	}
+
+	return voters, weights, nil
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/hex"
 	"errors"
 	"math/big"
 	"regexp"
@@ -29,3 +30,16 @@ func HexStrToBigInt(s string) (*big.Int, error) {
 
 	return i, nil
 }
+
+// BytesToHexStr converts bytes to hex string
+func BytesToHexStr(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// HexStrToBytes converts hex string to bytes
+func HexStrToBytes(s string) ([]byte, error) {
+	if len(s) < 2 || (s[:2] != "0x" && s[:2] != "0X") {
+		return nil, errors.New("Invalid hex string")
+	}
+	return hex.DecodeString(s[2:])
+}
@@ -0,0 +1,349 @@
+// Package dkg generates a t-of-n authority key jointly among n trustees
+// (Pedersen's protocol with Feldman's verifiable secret sharing) so that no
+// party ever learns the authority key k.
+//
+// Every trustee i deals a random polynomial f_i of degree t-1:
+//
+//  1. it broadcasts a Commitment to the coefficients of f_i together with a
+//     proof of knowledge of f_i(0), and privately sends the Share f_i(j) to
+//     trustee j;
+//  2. trustee j checks the received shares against the commitments and
+//     broadcasts a Complaint against every dealer whose share is missing
+//     or invalid;
+//  3. dealers answer complaints by revealing the disputed shares;
+//  4. dealers with an invalid commitment or an unanswered complaint are
+//     disqualified. Trustee j holds k_j = sum_{i\in QUAL} f_i(j) and the
+//     joint public key is g^k = prod_{i\in QUAL} g^{f_i(0)}.
+//
+// The output is a vote.KeyShare per trustee and a vote.ThresholdKey whose
+// public key is used as g^k for ballots and decrypted by vote.BinaryTally's
+// Combine.
+package dkg
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/vote"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+var curve = elliptic.P256()
+
+// Participant - trustee taking part in the key generation
+type Participant struct {
+	index  int        // i\in[1, n]
+	n      int        // number of trustees
+	coeffs []*big.Int // coefficients of f_i, kept secret
+}
+
+// NewParticipant news trustee i of n with a random polynomial of degree t-1
+func NewParticipant(index, t, n int) (*Participant, error) {
+	if t <= 0 || t > n {
+		return nil, errors.New("Invalid threshold")
+	}
+	if index <= 0 || index > n {
+		return nil, errors.New("Invalid index")
+	}
+
+	coeffs := make([]*big.Int, t)
+	for j := range coeffs {
+		a, err := randScalar()
+		if err != nil {
+			return nil, err
+		}
+		coeffs[j] = a
+	}
+
+	return &Participant{index, n, coeffs}, nil
+}
+
+// Index returns the index of the trustee
+func (p *Participant) Index() int {
+	return p.index
+}
+
+// Threshold returns the number of shares needed to decrypt
+func (p *Participant) Threshold() int {
+	return len(p.coeffs)
+}
+
+// Parties returns the number of trustees
+func (p *Participant) Parties() int {
+	return p.n
+}
+
+// Commit computes the commitments to f_i and proves the knowledge of f_i(0)
+//
+// data contains the data (e.g., account address) that identifies the trustee.
+func (p *Participant) Commit(data *big.Int) (*Commitment, error) {
+	t := len(p.coeffs)
+	cX, cY := make([]*big.Int, t), make([]*big.Int, t)
+	for j, a := range p.coeffs {
+		cX[j], cY[j] = curve.ScalarBaseMult(a.Bytes())
+	}
+
+	pub, err := vote.NewThresholdKey(t, cX, cY)
+	if err != nil {
+		return nil, err
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	prover, err := zk.NewECFSProver(p.coeffs[0], curve.Params().Gx, curve.Params().Gy)
+	if err != nil {
+		return nil, err
+	}
+	pok, err := prover.Prove(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Commitment{p.index, pub, pok}, nil
+}
+
+// Shares computes f_i(j) for j = 1, ..., n. Share j must be sent to trustee
+// j over a private channel.
+func (p *Participant) Shares() []*Share {
+	shares := make([]*Share, p.n)
+	for j := 1; j <= p.n; j++ {
+		shares[j-1] = &Share{p.index, j, p.eval(j)}
+	}
+	return shares
+}
+
+// eval computes f_i(x) mod N
+func (p *Participant) eval(x int) *big.Int {
+	N := curve.Params().N
+	X := big.NewInt(int64(x))
+
+	r := big.NewInt(0)
+	for j := len(p.coeffs) - 1; j >= 0; j-- {
+		r = r.Mul(r, X)
+		r = r.Add(r, p.coeffs[j])
+		r = r.Mod(r, N)
+	}
+
+	return r
+}
+
+// Check verifies the shares received from the dealers and returns
+// complaints against dealers whose share is missing or invalid
+func (p *Participant) Check(commits []*Commitment, shares []*Share) []*Complaint {
+	var complaints []*Complaint
+
+	valids := validCommitments(len(p.coeffs), p.n, commits)
+	for i := 1; i <= p.n; i++ {
+		if c, ok := valids[i]; ok && findShare(c, p.index, shares) == nil {
+			complaints = append(complaints, &Complaint{i, p.index})
+		}
+	}
+
+	return complaints
+}
+
+// Respond reveals the shares disputed by complaints against the trustee
+func (p *Participant) Respond(complaints []*Complaint) []*Share {
+	var (
+		responses []*Share
+		seen      = make(map[int]bool)
+	)
+
+	for _, c := range complaints {
+		if c.dealer != p.index || c.accuser <= 0 || c.accuser > p.n || seen[c.accuser] {
+			continue
+		}
+		seen[c.accuser] = true
+		responses = append(responses, &Share{p.index, c.accuser, p.eval(c.accuser)})
+	}
+
+	return responses
+}
+
+// Finalize computes the key share of the trustee and the joint public key
+// from the shares dealt by the qualified dealers. Disputed shares are taken
+// from the responses.
+func (p *Participant) Finalize(commits []*Commitment, shares []*Share, complaints []*Complaint, responses []*Share) (*vote.KeyShare, *vote.ThresholdKey, error) {
+	t := len(p.coeffs)
+
+	qual := Qualified(t, p.n, commits, complaints, responses)
+	pub, err := JointKey(t, p.n, commits, qual)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valids := validCommitments(t, p.n, commits)
+
+	k := big.NewInt(0)
+	for _, i := range qual {
+		c := valids[i]
+		s := findShare(c, p.index, shares)
+		if s == nil {
+			s = findShare(c, p.index, responses)
+		}
+		if s == nil {
+			return nil, nil, fmt.Errorf("Missing share from dealer %d", i)
+		}
+		k = k.Add(k, s.s)
+	}
+	k = k.Mod(k, curve.Params().N)
+
+	share, err := vote.NewKeyShare(p.index, k, pub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return share, pub, nil
+}
+
+// Qualified returns the indices of the dealers that are not disqualified,
+// i.e., dealers with a single valid commitment whose complaints are all
+// answered by valid shares
+func Qualified(t, n int, commits []*Commitment, complaints []*Complaint, responses []*Share) []int {
+	valids := validCommitments(t, n, commits)
+
+	var qual []int
+	for i := 1; i <= n; i++ {
+		c, ok := valids[i]
+		if !ok {
+			continue
+		}
+
+		answered := true
+		for _, cp := range complaints {
+			if cp.dealer == i && findShare(c, cp.accuser, responses) == nil {
+				answered = false
+				break
+			}
+		}
+
+		if answered {
+			qual = append(qual, i)
+		}
+	}
+
+	return qual
+}
+
+// JointKey computes the commitments to the joint polynomial
+// f = sum_{i\in QUAL} f_i, whose public key g^{f(0)} is the authority
+// public key
+func JointKey(t, n int, commits []*Commitment, qual []int) (*vote.ThresholdKey, error) {
+	if len(qual) == 0 {
+		return nil, errors.New("No qualified dealer")
+	}
+
+	valids := validCommitments(t, n, commits)
+
+	cX, cY := make([]*big.Int, t), make([]*big.Int, t)
+	for j := range cX {
+		cX[j], cY[j] = new(big.Int), new(big.Int)
+	}
+
+	for _, i := range qual {
+		c, ok := valids[i]
+		if !ok {
+			return nil, fmt.Errorf("Invalid commitment from dealer %d", i)
+		}
+		for j := range cX {
+			X, Y := c.coefficient(j)
+			cX[j], cY[j] = curve.Add(cX[j], cY[j], X, Y)
+		}
+	}
+
+	return vote.NewThresholdKey(t, cX, cY)
+}
+
+// validCommitments returns the valid commitments by dealer. Dealers that
+// published more than one commitment are excluded.
+func validCommitments(t, n int, commits []*Commitment) map[int]*Commitment {
+	valids := make(map[int]*Commitment)
+	dup := make(map[int]bool)
+
+	for _, c := range commits {
+		if c == nil || c.dealer <= 0 || c.dealer > n {
+			continue
+		}
+		if c.pub.Threshold() != t || c.Verify() != nil {
+			continue
+		}
+		if _, ok := valids[c.dealer]; ok {
+			dup[c.dealer] = true
+			continue
+		}
+		valids[c.dealer] = c
+	}
+
+	for i := range dup {
+		delete(valids, i)
+	}
+
+	return valids
+}
+
+// findShare returns a valid share dealt to the recipient by the dealer of c
+func findShare(c *Commitment, recipient int, shares []*Share) *Share {
+	for _, s := range shares {
+		if s != nil && s.dealer == c.dealer && s.recipient == recipient && s.Verify(c) == nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// randScalar returns a random number in [1, N-1]
+func randScalar() (*big.Int, error) {
+	r, err := rand.Int(rand.Reader, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return r.Add(r, big.NewInt(1)), nil
+}
+
+// BuildJSONParticipant builds json object
+func (p *Participant) BuildJSONParticipant() *JSONParticipant {
+	obj := &JSONParticipant{Index: p.index, Parties: p.n}
+	for _, a := range p.coeffs {
+		obj.Coeffs = append(obj.Coeffs, common.BigIntToHexStr(a))
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (p *Participant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONParticipant())
+}
+
+// FromJSONParticipant reconstructs from json object
+func (p *Participant) FromJSONParticipant(obj *JSONParticipant) error {
+	t := len(obj.Coeffs)
+	if t <= 0 || t > obj.Parties || obj.Index <= 0 || obj.Index > obj.Parties {
+		return errors.New("Invalid participant")
+	}
+
+	p.index = obj.Index
+	p.n = obj.Parties
+	p.coeffs = make([]*big.Int, t)
+	for j, a := range obj.Coeffs {
+		var err error
+		if p.coeffs[j], err = common.HexStrToBigInt(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *Participant) UnmarshalJSON(data []byte) error {
+	var obj JSONParticipant
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONParticipant(&obj)
+}
@@ -0,0 +1,147 @@
+package dkg
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zzGHzz/zkVote/vote"
+)
+
+func TestDKG(t *testing.T) {
+	const T, N = 2, 4
+
+	var (
+		parts   []*Participant
+		commits []*Commitment
+		shares  = make(map[int][]*Share) // recipient => shares
+	)
+
+	// round 1
+	for i := 1; i <= N; i++ {
+		p, err := NewParticipant(i, T, N)
+		assert.Nil(t, err)
+		parts = append(parts, p)
+
+		c, err := p.Commit(big.NewInt(int64(i)))
+		assert.Nil(t, err)
+		assert.Nil(t, c.Verify())
+		commits = append(commits, c)
+
+		for _, s := range p.Shares() {
+			assert.Nil(t, s.Verify(c))
+			shares[s.recipient] = append(shares[s.recipient], s)
+		}
+	}
+
+	// dealer 1 sends a bad share to trustee 2 but answers the complaint
+	shares[2][0].s = new(big.Int).Add(shares[2][0].s, big.NewInt(1))
+	// dealer 3 sends no share to trustee 4 and never answers
+	shares[4] = append(shares[4][:2], shares[4][3])
+
+	// round 2
+	var complaints []*Complaint
+	for _, p := range parts {
+		complaints = append(complaints, p.Check(commits, shares[p.index])...)
+	}
+	assert.Equal(t, []*Complaint{{1, 2}, {3, 4}}, complaints)
+
+	// round 3
+	responses := parts[0].Respond(complaints)
+	assert.Equal(t, 1, len(responses))
+
+	// round 4
+	qual := Qualified(T, N, commits, complaints, responses)
+	assert.Equal(t, []int{1, 2, 4}, qual)
+
+	var keyShares []*vote.KeyShare
+	var pub *vote.ThresholdKey
+	for _, p := range parts {
+		s, pk, err := p.Finalize(commits, shares[p.index], complaints, responses)
+		assert.Nil(t, err)
+		assert.Nil(t, s.Verify())
+		keyShares = append(keyShares, s)
+		pub = pk
+	}
+
+	// k = f_1(0) + f_2(0) + f_4(0) is never computed by any trustee
+	k := new(big.Int)
+	for _, i := range qual {
+		k = k.Add(k, parts[i-1].coeffs[0])
+	}
+	k = k.Mod(k, curve.Params().N)
+	gkX, gkY := pub.PublicKey()
+	X, Y := curve.ScalarBaseMult(k.Bytes())
+	assert.Equal(t, X, gkX)
+	assert.Equal(t, Y, gkY)
+
+	// vote with the joint key and decrypt with two key shares
+	V := 0
+	var ballots []*vote.BinaryBallot
+	for i := 0; i < 5; i++ {
+		a, _ := randScalar()
+		b, err := vote.NewBinaryBallot(i%2 == 0, a, gkX, gkY, big.NewInt(int64(i+100)))
+		assert.Nil(t, err)
+		ballots = append(ballots, b)
+		if i%2 == 0 {
+			V = V + 1
+		}
+	}
+	tal, err := vote.NewBinaryTally(gkX, gkY, big.NewInt(0), ballots)
+	assert.Nil(t, err)
+
+	HX, HY := tal.H()
+	var partials []*vote.PartialDecryption
+	for _, s := range keyShares[2:] {
+		d, err := s.PartialDecrypt(HX, HY, big.NewInt(int64(s.Index())))
+		assert.Nil(t, err)
+		partials = append(partials, d)
+	}
+	res, err := tal.Combine(pub, partials)
+	assert.Nil(t, err)
+	assert.Equal(t, V, res.V)
+	params, err := vote.NewElectionParams(gkX, gkY, big.NewInt(0))
+	assert.Nil(t, err)
+	assert.Nil(t, res.VerifyBallots(params, ballots, 0))
+}
+
+func TestDKGJSON(t *testing.T) {
+	p, err := NewParticipant(2, 2, 3)
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(p)
+	assert.Nil(t, err)
+	var p1 Participant
+	assert.Nil(t, json.Unmarshal(data, &p1))
+	assert.Equal(t, p.coeffs, p1.coeffs)
+
+	c, err := p.Commit(big.NewInt(2))
+	assert.Nil(t, err)
+	data, err = json.Marshal(c)
+	assert.Nil(t, err)
+	var c1 Commitment
+	assert.Nil(t, json.Unmarshal(data, &c1))
+	assert.Nil(t, c1.Verify())
+
+	s := p.Shares()[0]
+	data, err = json.Marshal(s)
+	assert.Nil(t, err)
+	var s1 Share
+	assert.Nil(t, json.Unmarshal(data, &s1))
+	assert.Nil(t, s1.Verify(&c1))
+
+	data, err = json.Marshal(NewComplaint(2, 1))
+	assert.Nil(t, err)
+	var cp Complaint
+	assert.Nil(t, json.Unmarshal(data, &cp))
+	assert.Equal(t, Complaint{2, 1}, cp)
+
+	// proof of knowledge copied from another dealer
+	q, err := NewParticipant(2, 2, 3)
+	assert.Nil(t, err)
+	c3, err := q.Commit(big.NewInt(2))
+	assert.Nil(t, err)
+	c3.pok = c.pok
+	assert.NotNil(t, c3.Verify())
+}
@@ -0,0 +1,204 @@
+package dkg
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/vote"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// Commitment - commitments C_ij = g^{a_ij} to the coefficients of f_i
+// broadcast by dealer i, with a proof of knowledge of a_i0 = f_i(0)
+type Commitment struct {
+	dealer int
+	pub    *vote.ThresholdKey
+	pok    *zk.ECFSProof // proves the knowledge of log_g C_i0
+}
+
+// Share - share f_i(j) dealt by dealer i to trustee j
+type Share struct {
+	dealer    int
+	recipient int
+	s         *big.Int
+}
+
+// Complaint - complaint of trustee j against dealer i
+type Complaint struct {
+	dealer  int
+	accuser int
+}
+
+// NewComplaint news a complaint
+func NewComplaint(dealer, accuser int) *Complaint {
+	return &Complaint{dealer, accuser}
+}
+
+// Dealer returns the index of the dealer
+func (c *Commitment) Dealer() int {
+	return c.dealer
+}
+
+// ThresholdKey returns the commitments of the dealer
+func (c *Commitment) ThresholdKey() *vote.ThresholdKey {
+	return c.pub
+}
+
+func (c *Commitment) coefficient(j int) (*big.Int, *big.Int) {
+	return c.pub.Commitment(j)
+}
+
+// Verify verifies the proof of knowledge of f_i(0)
+func (c *Commitment) Verify() error {
+	if c.pub == nil || c.pok == nil {
+		return errors.New("Invalid commitment")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	hX, hY := c.pok.Base()
+	yX, yY := c.pok.Value()
+	cX, cY := c.coefficient(0)
+	if hX.Cmp(curve.Params().Gx) != 0 || hY.Cmp(curve.Params().Gy) != 0 ||
+		yX.Cmp(cX) != 0 || yY.Cmp(cY) != 0 {
+		return errors.New("Inconsistent commitment")
+	}
+
+	res, err := c.pok.Verify()
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Failed to verify commitment")
+	}
+
+	return nil
+}
+
+// Dealer returns the index of the dealer
+func (s *Share) Dealer() int {
+	return s.dealer
+}
+
+// Recipient returns the index of the recipient
+func (s *Share) Recipient() int {
+	return s.recipient
+}
+
+// Verify checks g^{f_i(j)} = prod_k C_ik^{j^k}
+func (s *Share) Verify(c *Commitment) error {
+	if s.s == nil || s.dealer != c.dealer || s.recipient <= 0 {
+		return errors.New("Invalid share")
+	}
+
+	X, Y := curve.ScalarBaseMult(s.s.Bytes())
+	sX, sY := c.pub.ShareKey(s.recipient)
+	if X.Cmp(sX) != 0 || Y.Cmp(sY) != 0 {
+		return errors.New("Share doesn't match commitments")
+	}
+
+	return nil
+}
+
+// Dealer returns the index of the accused dealer
+func (c *Complaint) Dealer() int {
+	return c.dealer
+}
+
+// Accuser returns the index of the trustee that complains
+func (c *Complaint) Accuser() int {
+	return c.accuser
+}
+
+// BuildJSONCommitment builds json object
+func (c *Commitment) BuildJSONCommitment() *JSONCommitment {
+	return &JSONCommitment{
+		Dealer: c.dealer,
+		PubKey: c.pub.BuildJSONThresholdKey(),
+		PoK:    c.pok.BuildJSONJSONECFSProof(),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (c *Commitment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.BuildJSONCommitment())
+}
+
+// FromJSONCommitment reconstructs from json object
+func (c *Commitment) FromJSONCommitment(obj *JSONCommitment) error {
+	if obj.PubKey == nil || obj.PoK == nil {
+		return errors.New("Invalid commitment")
+	}
+
+	c.dealer = obj.Dealer
+
+	c.pub = new(vote.ThresholdKey)
+	if err := c.pub.FromJSONThresholdKey(obj.PubKey); err != nil {
+		return err
+	}
+
+	c.pok = new(zk.ECFSProof)
+	return c.pok.FromJSONECFSProof(obj.PoK)
+}
+
+// UnmarshalJSON implements json unmarshal
+func (c *Commitment) UnmarshalJSON(data []byte) error {
+	var obj JSONCommitment
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return c.FromJSONCommitment(&obj)
+}
+
+// BuildJSONShare builds json object
+func (s *Share) BuildJSONShare() *JSONShare {
+	return &JSONShare{
+		Dealer:    s.dealer,
+		Recipient: s.recipient,
+		S:         common.BigIntToHexStr(s.s),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (s *Share) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.BuildJSONShare())
+}
+
+// FromJSONShare reconstructs from json object
+func (s *Share) FromJSONShare(obj *JSONShare) error {
+	var err error
+
+	s.dealer = obj.Dealer
+	s.recipient = obj.Recipient
+	if s.s, err = common.HexStrToBigInt(obj.S); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (s *Share) UnmarshalJSON(data []byte) error {
+	var obj JSONShare
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return s.FromJSONShare(&obj)
+}
+
+// MarshalJSON implements json marshal
+func (c *Complaint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&JSONComplaint{Dealer: c.dealer, Accuser: c.accuser})
+}
+
+// UnmarshalJSON implements json unmarshal
+func (c *Complaint) UnmarshalJSON(data []byte) error {
+	var obj JSONComplaint
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	c.dealer, c.accuser = obj.Dealer, obj.Accuser
+	return nil
+}
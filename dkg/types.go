@@ -0,0 +1,33 @@
+package dkg
+
+import (
+	"github.com/zzGHzz/zkVote/vote"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// JSONParticipant defines json object
+type JSONParticipant struct {
+	Index   int      `json:"index"`
+	Parties int      `json:"n"`
+	Coeffs  []string `json:"coeffs"`
+}
+
+// JSONCommitment defines json object
+type JSONCommitment struct {
+	Dealer int                    `json:"dealer"`
+	PubKey *vote.JSONThresholdKey `json:"tkey"`
+	PoK    *zk.JSONECFSProof      `json:"pok"`
+}
+
+// JSONShare defines json object
+type JSONShare struct {
+	Dealer    int    `json:"dealer"`
+	Recipient int    `json:"recipient"`
+	S         string `json:"s"`
+}
+
+// JSONComplaint defines json object
+type JSONComplaint struct {
+	Dealer  int `json:"dealer"`
+	Accuser int `json:"accuser"`
+}
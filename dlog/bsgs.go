@@ -0,0 +1,172 @@
+package dlog
+
+import (
+	"bufio"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// bsgsMagic identifies a persisted baby-step table
+const bsgsMagic = "ZKVBSGS1"
+
+// BSGS - baby-step giant-step solver
+//
+// The table maps the x-coordinate of g^j to j for j\in[0, m). Solving
+// v\in[0, max] takes at most max/m + 1 giant steps.
+type BSGS struct {
+	curve elliptic.Curve
+	m     uint64
+	table map[uint64]uint64 // x-coordinate prefix of g^j => j
+}
+
+// NewBSGS builds a table of m baby steps
+func NewBSGS(c elliptic.Curve, m uint64) (*BSGS, error) {
+	if m == 0 {
+		return nil, errors.New("Invalid table size")
+	}
+
+	b := &BSGS{c, m, make(map[uint64]uint64, m)}
+
+	// g^0 is the point at infinity and handled separately
+	X, Y := new(big.Int), new(big.Int)
+	for j := uint64(1); j < m; j++ {
+		X, Y = c.Add(X, Y, c.Params().Gx, c.Params().Gy)
+		b.insert(key(X), j)
+	}
+
+	return b, nil
+}
+
+func (b *BSGS) insert(k, j uint64) {
+	// keep the first entry in the rare case of a collision
+	if _, ok := b.table[k]; !ok {
+		b.table[k] = j
+	}
+}
+
+// Size returns the number of baby steps
+func (b *BSGS) Size() uint64 {
+	return b.m
+}
+
+// Solve finds v\in[0, max] such that g^v = (X, Y)
+func (b *BSGS) Solve(X, Y *big.Int, max uint64) (uint64, error) {
+	c := b.curve
+
+	if isInfinity(X, Y) {
+		return 0, nil
+	}
+	if !c.IsOnCurve(X, Y) {
+		return 0, ErrNotOnCurve
+	}
+
+	// -g^m
+	gmX, gmY := c.ScalarBaseMult(new(big.Int).SetUint64(b.m).Bytes())
+	gmY = new(big.Int).Sub(c.Params().P, gmY)
+
+	// gamma = (X, Y) / g^{im}
+	gX, gY := new(big.Int).Set(X), new(big.Int).Set(Y)
+	for i := uint64(0); i <= max/b.m; i++ {
+		if isInfinity(gX, gY) {
+			if v := i * b.m; v <= max {
+				return v, nil
+			}
+			break
+		}
+
+		if j, ok := b.table[key(gX)]; ok {
+			// rule out prefix collisions and g^{-j}
+			jX, jY := c.ScalarBaseMult(new(big.Int).SetUint64(j).Bytes())
+			if jX.Cmp(gX) == 0 && jY.Cmp(gY) == 0 {
+				if v := i*b.m + j; v <= max {
+					return v, nil
+				}
+				break
+			}
+		}
+
+		gX, gY = c.Add(gX, gY, gmX, gmY)
+	}
+
+	return 0, ErrNotFound
+}
+
+// Save writes the table to w so that it can be reloaded by LoadBSGS
+func (b *BSGS) Save(w io.Writer) error {
+	keys := make([]uint64, b.m)
+	for k, j := range b.table {
+		keys[j] = k
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(bsgsMagic); err != nil {
+		return err
+	}
+
+	name := b.curve.Params().Name
+	if err := binary.Write(bw, binary.BigEndian, uint16(len(name))); err != nil {
+		return err
+	}
+	if _, err := bw.WriteString(name); err != nil {
+		return err
+	}
+
+	if err := binary.Write(bw, binary.BigEndian, b.m); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.BigEndian, keys); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// LoadBSGS reads a table written by Save
+func LoadBSGS(c elliptic.Curve, r io.Reader) (*BSGS, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(bsgsMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != bsgsMagic {
+		return nil, errors.New("Invalid table file")
+	}
+
+	var n uint16
+	if err := binary.Read(br, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	name := make([]byte, n)
+	if _, err := io.ReadFull(br, name); err != nil {
+		return nil, err
+	}
+	if string(name) != c.Params().Name {
+		return nil, errors.New("Table was built for a different curve")
+	}
+
+	var m uint64
+	if err := binary.Read(br, binary.BigEndian, &m); err != nil {
+		return nil, err
+	}
+	if m == 0 {
+		return nil, errors.New("Invalid table size")
+	}
+
+	b := &BSGS{c, m, make(map[uint64]uint64, m)}
+
+	var k uint64
+	for j := uint64(0); j < m; j++ {
+		if err := binary.Read(br, binary.BigEndian, &k); err != nil {
+			return nil, err
+		}
+		if j > 0 {
+			b.insert(k, j)
+		}
+	}
+
+	return b, nil
+}
@@ -0,0 +1,114 @@
+// Package dlog recovers bounded discrete logarithms, i.e., v\in[0, max]
+// given g^v, as needed to read tally results encrypted in the exponent.
+package dlog
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+// dlog related errors
+var (
+	ErrNotFound      = errors.New("Discrete log not found")
+	ErrNotOnCurve    = errors.New("Not on curve")
+	ErrBoundTooLarge = errors.New("Bound too large")
+)
+
+// DefaultMaxTable is the default size limit of baby-step tables built by
+// AutoSolver. Larger bounds are handled by the kangaroo solver.
+const DefaultMaxTable = 1 << 20
+
+var mask64 = new(big.Int).SetUint64(^uint64(0))
+
+// Solver interface
+type Solver interface {
+	// Solve finds v\in[0, max] such that g^v = (X, Y)
+	Solve(X, Y *big.Int, max uint64) (uint64, error)
+}
+
+// AutoSolver - picks a solver according to the bound
+//
+// Bounds up to maxTable^2 are solved by baby-step giant-step with a table of
+// about sqrt(max) entries, built on demand and reused by later calls. Larger
+// bounds are solved by Pollard's kangaroo.
+type AutoSolver struct {
+	curve    elliptic.Curve
+	maxTable uint64
+
+	mu   sync.Mutex
+	bsgs *BSGS
+}
+
+// NewSolver news an AutoSolver with tables of at most DefaultMaxTable entries
+func NewSolver(c elliptic.Curve) *AutoSolver {
+	return &AutoSolver{curve: c, maxTable: DefaultMaxTable}
+}
+
+// NewSolverWithTable news an AutoSolver that starts with a prebuilt (e.g.,
+// loaded) baby-step table
+func NewSolverWithTable(c elliptic.Curve, b *BSGS) *AutoSolver {
+	s := NewSolver(c)
+	if b != nil {
+		s.bsgs = b
+		if b.m > s.maxTable {
+			s.maxTable = b.m
+		}
+	}
+	return s
+}
+
+// SetMaxTable sets the size limit of baby-step tables
+func (s *AutoSolver) SetMaxTable(m uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxTable = m
+}
+
+// Table returns the current baby-step table, which may be nil
+func (s *AutoSolver) Table() *BSGS {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bsgs
+}
+
+// Solve finds v\in[0, max] such that g^v = (X, Y)
+func (s *AutoSolver) Solve(X, Y *big.Int, max uint64) (uint64, error) {
+	if max >= 1<<62 {
+		return 0, ErrBoundTooLarge
+	}
+
+	// m > sqrt(max)
+	m := new(big.Int).Sqrt(new(big.Int).SetUint64(max)).Uint64() + 1
+
+	s.mu.Lock()
+	b := s.bsgs
+	if b == nil || b.m < m {
+		b = nil
+		if m <= s.maxTable {
+			var err error
+			if b, err = NewBSGS(s.curve, m); err != nil {
+				s.mu.Unlock()
+				return 0, err
+			}
+			s.bsgs = b
+		}
+	}
+	s.mu.Unlock()
+
+	if b != nil {
+		return b.Solve(X, Y, max)
+	}
+
+	return NewKangaroo(s.curve).Solve(X, Y, max)
+}
+
+// key returns the lowest 64 bits of the x-coordinate
+func key(X *big.Int) uint64 {
+	return new(big.Int).And(X, mask64).Uint64()
+}
+
+func isInfinity(X, Y *big.Int) bool {
+	return X.Sign() == 0 && Y.Sign() == 0
+}
@@ -0,0 +1,74 @@
+package dlog
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var curve = elliptic.P256()
+
+func gv(v uint64) (*big.Int, *big.Int) {
+	return curve.ScalarBaseMult(new(big.Int).SetUint64(v).Bytes())
+}
+
+func TestBSGS(t *testing.T) {
+	b, err := NewBSGS(curve, 32)
+	assert.Nil(t, err)
+
+	for _, v := range []uint64{0, 1, 31, 32, 33, 500, 1000} {
+		X, Y := gv(v)
+		r, err := b.Solve(X, Y, 1000)
+		assert.Nil(t, err)
+		assert.Equal(t, v, r)
+	}
+
+	X, Y := gv(1001)
+	_, err = b.Solve(X, Y, 1000)
+	assert.Equal(t, ErrNotFound, err)
+
+	// persisted table
+	var buf bytes.Buffer
+	assert.Nil(t, b.Save(&buf))
+	loaded, err := LoadBSGS(curve, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, b.table, loaded.table)
+
+	X, Y = gv(777)
+	r, err := loaded.Solve(X, Y, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(777), r)
+}
+
+func TestKangaroo(t *testing.T) {
+	s := NewKangaroo(curve)
+	max := uint64(1) << 24
+
+	for _, v := range []uint64{0, 1, 12345, 1<<23 + 77, max} {
+		X, Y := gv(v)
+		r, err := s.Solve(X, Y, max)
+		assert.Nil(t, err)
+		assert.Equal(t, v, r)
+	}
+}
+
+func TestAutoSolver(t *testing.T) {
+	s := NewSolver(curve)
+	s.SetMaxTable(64)
+
+	// solved by baby-step giant-step
+	X, Y := gv(4000)
+	r, err := s.Solve(X, Y, 4000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4000), r)
+	assert.NotNil(t, s.Table())
+
+	// solved by kangaroo
+	X, Y = gv(654321)
+	r, err = s.Solve(X, Y, 1<<20)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(654321), r)
+}
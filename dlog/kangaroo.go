@@ -0,0 +1,98 @@
+package dlog
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"math/bits"
+)
+
+// kangarooAttempts limits the number of walks with different jump functions
+const kangarooAttempts = 8
+
+// Kangaroo - Pollard's kangaroo (lambda) solver
+//
+// A tame kangaroo starts at g^max and a wild one at the target. Both jump
+// by g^{2^i} where i is derived from the x-coordinate of the current point,
+// so once the wild kangaroo lands on a point visited by the tame one it
+// follows the same path into the trap set by the tame kangaroo. It takes
+// O(sqrt(max)) point additions and needs no table.
+type Kangaroo struct {
+	curve elliptic.Curve
+}
+
+// NewKangaroo news a kangaroo solver
+func NewKangaroo(c elliptic.Curve) *Kangaroo {
+	return &Kangaroo{c}
+}
+
+// Solve finds v\in[0, max] such that g^v = (X, Y)
+func (s *Kangaroo) Solve(X, Y *big.Int, max uint64) (uint64, error) {
+	c := s.curve
+
+	if isInfinity(X, Y) {
+		return 0, nil
+	}
+	if !c.IsOnCurve(X, Y) {
+		return 0, ErrNotOnCurve
+	}
+
+	// jumps 2^0, ..., 2^{K-1} with mean (2^K - 1)/K close to sqrt(max)/2
+	mean := new(big.Int).Sqrt(new(big.Int).SetUint64(max)).Uint64()/2 + 1
+	K := 1
+	for K < 63 && (uint64(1)<<uint(K)-1)/uint64(K) < mean {
+		K = K + 1
+	}
+
+	jumpX := make([]*big.Int, K)
+	jumpY := make([]*big.Int, K)
+	for i := 0; i < K; i++ {
+		jumpX[i], jumpY[i] = c.ScalarBaseMult(new(big.Int).Lsh(big.NewInt(1), uint(i)).Bytes())
+	}
+
+	gMaxX, gMaxY := c.ScalarBaseMult(new(big.Int).SetUint64(max).Bytes())
+
+	for salt := uint64(0); salt < kangarooAttempts; salt++ {
+		jump := func(x *big.Int) int {
+			var w uint64
+			if words := x.Bits(); len(words) > 0 {
+				w = uint64(words[0])
+			}
+			// spread the salt over all bits before reducing
+			w = w ^ (salt * 0x9e3779b97f4a7c15)
+			w = bits.RotateLeft64(w, int(salt))
+			return int(w % uint64(K))
+		}
+
+		// tame kangaroo travels at least 2*max from g^max
+		tX, tY := new(big.Int).Set(gMaxX), new(big.Int).Set(gMaxY)
+		var dT uint64
+		for dT < 2*max+1 {
+			if isInfinity(tX, tY) {
+				break
+			}
+			i := jump(tX)
+			tX, tY = c.Add(tX, tY, jumpX[i], jumpY[i])
+			dT = dT + uint64(1)<<uint(i)
+		}
+
+		// wild kangaroo jumps until it falls into the trap or passes it
+		wX, wY := new(big.Int).Set(X), new(big.Int).Set(Y)
+		var dW uint64
+		for dW <= max+dT {
+			if wX.Cmp(tX) == 0 && wY.Cmp(tY) == 0 {
+				if v := max + dT - dW; v <= max {
+					return v, nil
+				}
+				break
+			}
+			if isInfinity(wX, wY) {
+				break
+			}
+			i := jump(wX)
+			wX, wY = c.Add(wX, wY, jumpX[i], jumpY[i])
+			dW = dW + uint64(1)<<uint(i)
+		}
+	}
+
+	return 0, ErrNotFound
+}
@@ -0,0 +1,234 @@
+// Package merkle implements a binary Merkle tree over sha256 with inclusion
+// proofs.
+//
+// Leaves and inner nodes are hashed with different prefixes so that an
+// inner node can't be passed off as a leaf. When a level has an odd number
+// of nodes, the last one is promoted to the next level unchanged.
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+// hash prefixes
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// Tree - Merkle tree
+type Tree struct {
+	levels [][][32]byte // levels[0] are the leaves, the last level is the root
+}
+
+// Proof - inclusion proof from a leaf to the root
+type Proof struct {
+	path []node
+}
+
+type node struct {
+	hash [32]byte
+	left bool // whether the sibling is on the left
+}
+
+// HashLeaf hashes leaf data
+func HashLeaf(data []byte) [32]byte {
+	return sha256.Sum256(append([]byte{leafPrefix}, data...))
+}
+
+func hashNode(l, r [32]byte) [32]byte {
+	b := make([]byte, 0, 65)
+	b = append(b, nodePrefix)
+	b = append(b, l[:]...)
+	b = append(b, r[:]...)
+	return sha256.Sum256(b)
+}
+
+// NewTree builds a tree from hashed leaves
+func NewTree(leaves [][32]byte) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, errors.New("No leaves")
+	}
+
+	level := append([][32]byte(nil), leaves...)
+	t := &Tree{[][][32]byte{level}}
+
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i+1 < len(level); i = i + 2 {
+			next = append(next, hashNode(level[i], level[i+1]))
+		}
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+
+	return t, nil
+}
+
+// Append adds a hashed leaf to the tree, updating only the nodes on the
+// path from the new leaf to the root. Leaves keep their indexes, so the
+// tree can serve as an append-only log.
+func (t *Tree) Append(leaf [32]byte) {
+	t.levels[0] = append(t.levels[0], leaf)
+
+	for j := 0; len(t.levels[j]) > 1; j++ {
+		level := t.levels[j]
+		i := len(level) - 1
+
+		// the parent of the last node, or the node itself if promoted
+		h := level[i]
+		if i%2 == 1 {
+			h = hashNode(level[i-1], level[i])
+		}
+
+		if j+1 == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		if i/2 < len(t.levels[j+1]) {
+			t.levels[j+1][i/2] = h
+		} else {
+			t.levels[j+1] = append(t.levels[j+1], h)
+		}
+	}
+}
+
+// Root returns the root hash
+func (t *Tree) Root() [32]byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Leaf returns the i-th hashed leaf
+func (t *Tree) Leaf(i int) [32]byte {
+	return t.levels[0][i]
+}
+
+// Size returns the number of leaves
+func (t *Tree) Size() int {
+	return len(t.levels[0])
+}
+
+// Prove generates the inclusion proof of the i-th leaf
+func (t *Tree) Prove(i int) (*Proof, error) {
+	if i < 0 || i >= t.Size() {
+		return nil, errors.New("Index out of range")
+	}
+
+	p := new(Proof)
+	for _, level := range t.levels[:len(t.levels)-1] {
+		if i%2 == 1 {
+			p.path = append(p.path, node{level[i-1], true})
+		} else if i+1 < len(level) {
+			p.path = append(p.path, node{level[i+1], false})
+		}
+		// otherwise the node is promoted
+		i = i / 2
+	}
+
+	return p, nil
+}
+
+// Verify checks that leaf is included in the tree with the given root
+func (p *Proof) Verify(root, leaf [32]byte) bool {
+	h := leaf
+	for _, n := range p.path {
+		if n.left {
+			h = hashNode(n.hash, h)
+		} else {
+			h = hashNode(h, n.hash)
+		}
+	}
+	return h == root
+}
+
+// VerifyAt checks that leaf is the i-th of the n leaves of the tree with the
+// given root
+func (p *Proof) VerifyAt(root, leaf [32]byte, i, n int) bool {
+	if i < 0 || i >= n {
+		return false
+	}
+
+	// the path of the i-th leaf has one node per level it isn't promoted at
+	k := 0
+	for ; n > 1; n = (n + 1) / 2 {
+		if i%2 == 1 || i+1 < n {
+			if k == len(p.path) || p.path[k].left != (i%2 == 1) {
+				return false
+			}
+			k = k + 1
+		}
+		i = i / 2
+	}
+	return k == len(p.path) && p.Verify(root, leaf)
+}
+
+// JSONNode defines json object
+type JSONNode struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// BuildJSONProof builds json object
+func (p *Proof) BuildJSONProof() []*JSONNode {
+	obj := make([]*JSONNode, len(p.path))
+	for i, n := range p.path {
+		obj[i] = &JSONNode{HashToHexStr(n.hash), n.left}
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONProof())
+}
+
+// FromJSONProof reconstructs from json object
+func (p *Proof) FromJSONProof(obj []*JSONNode) error {
+	p.path = make([]node, len(obj))
+	for i, n := range obj {
+		if n == nil {
+			return errors.New("Invalid proof")
+		}
+		h, err := HexStrToHash(n.Hash)
+		if err != nil {
+			return err
+		}
+		p.path[i] = node{h, n.Left}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	var obj []*JSONNode
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONProof(obj)
+}
+
+// HashToHexStr converts hash to hex string
+func HashToHexStr(h [32]byte) string {
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+// HexStrToHash converts hex string to hash
+func HexStrToHash(s string) ([32]byte, error) {
+	var h [32]byte
+
+	if len(s) != 66 || (s[:2] != "0x" && s[:2] != "0X") {
+		return h, errors.New("Invalid hash")
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return h, err
+	}
+	copy(h[:], b)
+
+	return h, nil
+}
@@ -0,0 +1,78 @@
+package merkle
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTree(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		var leaves [][32]byte
+		for i := 0; i < n; i++ {
+			leaves = append(leaves, HashLeaf([]byte{byte(i)}))
+		}
+
+		tree, err := NewTree(leaves)
+		assert.Nil(t, err)
+		root := tree.Root()
+
+		for i := 0; i < n; i++ {
+			p, err := tree.Prove(i)
+			assert.Nil(t, err)
+			assert.True(t, p.Verify(root, leaves[i]))
+			assert.False(t, p.Verify(root, HashLeaf([]byte{byte(n)})))
+
+			data, err := json.Marshal(p)
+			assert.Nil(t, err)
+			var p1 Proof
+			assert.Nil(t, json.Unmarshal(data, &p1))
+			assert.True(t, p1.Verify(root, leaves[i]))
+
+			if i^1 < n {
+				assert.False(t, p.Verify(root, leaves[i^1]))
+			}
+		}
+
+		_, err = tree.Prove(n)
+		assert.NotNil(t, err)
+	}
+
+	_, err := NewTree(nil)
+	assert.NotNil(t, err)
+}
+
+func TestAppend(t *testing.T) {
+	leaves := [][32]byte{HashLeaf([]byte{0})}
+	tree, err := NewTree(leaves)
+	assert.Nil(t, err)
+
+	for n := 2; n <= 33; n++ {
+		leaf := HashLeaf([]byte{byte(n - 1)})
+		tree.Append(leaf)
+		leaves = append(leaves, leaf)
+
+		// same tree as built from all leaves
+		full, err := NewTree(leaves)
+		assert.Nil(t, err)
+		assert.Equal(t, full.levels, tree.levels)
+
+		for i := 0; i < n; i++ {
+			p, err := tree.Prove(i)
+			assert.Nil(t, err)
+			assert.True(t, p.VerifyAt(tree.Root(), leaves[i], i, n))
+			assert.False(t, p.VerifyAt(tree.Root(), leaves[i], i^1, n))
+		}
+	}
+}
+
+func TestHexHash(t *testing.T) {
+	h := HashLeaf([]byte("zkvote"))
+	h1, err := HexStrToHash(HashToHexStr(h))
+	assert.Nil(t, err)
+	assert.Equal(t, h, h1)
+
+	_, err = HexStrToHash("0x1234")
+	assert.NotNil(t, err)
+}
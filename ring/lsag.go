@@ -0,0 +1,394 @@
+// Package ring implements linkable spontaneous anonymous group (LSAG)
+// signatures.
+//
+// A signature proves that it is made by the owner of one of the public keys
+// in a ring without revealing which one. Each signature carries the key
+// image x * Hp(ctx, P) of the signer's key P = g^x, which is the same for
+// all signatures of the signer in the same context, so that a signer can't
+// sign twice unnoticed.
+package ring
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/zzGHzz/zkVote/common"
+)
+
+var curve elliptic.Curve = elliptic.P256()
+
+// SetEllipticCurve sets elliptic curve. Setting the curve already in use
+// is a no-op so that signatures can be verified concurrently.
+func SetEllipticCurve(c elliptic.Curve) {
+	if c == curve {
+		return
+	}
+	curve = c
+}
+
+// Ring - set of public keys sorted by their encoding
+type Ring struct {
+	xs, ys []*big.Int
+}
+
+// NewRing news a ring of public keys
+func NewRing(xs, ys []*big.Int) (*Ring, error) {
+	if len(xs) == 0 || len(xs) != len(ys) {
+		return nil, errors.New("Invalid ring size")
+	}
+
+	keys := make([][]byte, len(xs))
+	for i := range xs {
+		if !curve.IsOnCurve(xs[i], ys[i]) {
+			return nil, errors.New("Invalid public key")
+		}
+		keys[i] = elliptic.Marshal(curve, xs[i], ys[i])
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	r := &Ring{make([]*big.Int, len(keys)), make([]*big.Int, len(keys))}
+	for i, k := range keys {
+		if i > 0 && bytes.Equal(keys[i-1], k) {
+			return nil, errors.New("Duplicate public key")
+		}
+		r.xs[i], r.ys[i] = elliptic.Unmarshal(curve, k)
+	}
+
+	return r, nil
+}
+
+// Size returns the number of keys
+func (r *Ring) Size() int {
+	return len(r.xs)
+}
+
+// Index returns the index of public key (X, Y), -1 if not in the ring
+func (r *Ring) Index(X, Y *big.Int) int {
+	for i := range r.xs {
+		if r.xs[i].Cmp(X) == 0 && r.ys[i].Cmp(Y) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Hash returns the hash of the keys
+func (r *Ring) Hash() [32]byte {
+	var buf bytes.Buffer
+	for i := range r.xs {
+		buf.Write(elliptic.Marshal(curve, r.xs[i], r.ys[i]))
+	}
+	return sha256.Sum256(buf.Bytes())
+}
+
+// Signature - LSAG signature
+type Signature struct {
+	imgX, imgY *big.Int   // key image
+	c          *big.Int   // first challenge
+	s          []*big.Int // responses, one per key in the ring
+
+	ctx []byte // context the key image is bound to
+}
+
+// KeyImage returns the key image of private key x in context ctx
+func KeyImage(x *big.Int, ctx []byte) (*big.Int, *big.Int) {
+	PX, PY := curve.ScalarBaseMult(x.Bytes())
+	HX, HY := hashToPoint(ctx, PX, PY)
+	return curve.ScalarMult(HX, HY, x.Bytes())
+}
+
+// Sign signs msg with private key x whose public key is in ring r. The key
+// image is bound to ctx.
+func Sign(r *Ring, x *big.Int, ctx, msg []byte) (*Signature, error) {
+	N := curve.Params().N
+	if x.Sign() <= 0 || x.Cmp(N) >= 0 {
+		return nil, errors.New("Invalid private key")
+	}
+
+	PX, PY := curve.ScalarBaseMult(x.Bytes())
+	pi := r.Index(PX, PY)
+	if pi < 0 {
+		return nil, errors.New("Key not in ring")
+	}
+
+	n := r.Size()
+	sig := &Signature{s: make([]*big.Int, n), ctx: append([]byte(nil), ctx...)}
+	sig.imgX, sig.imgY = KeyImage(x, ctx)
+	ringHash := r.Hash()
+
+	// L = g^alpha, R = Hp(P)^alpha at the signer
+	alpha, err := randScalar()
+	if err != nil {
+		return nil, err
+	}
+	HX, HY := hashToPoint(ctx, PX, PY)
+	LX, LY := curve.ScalarBaseMult(alpha.Bytes())
+	RX, RY := curve.ScalarMult(HX, HY, alpha.Bytes())
+
+	cs := make([]*big.Int, n)
+	cs[(pi+1)%n] = challenge(ringHash, ctx, msg, LX, LY, RX, RY)
+	for k := 1; k < n; k++ {
+		i := (pi + k) % n
+		if sig.s[i], err = randScalar(); err != nil {
+			return nil, err
+		}
+		LX, LY, RX, RY = sig.commit(r, i, cs[i])
+		cs[(i+1)%n] = challenge(ringHash, ctx, msg, LX, LY, RX, RY)
+	}
+
+	// s = alpha - c * x
+	s := new(big.Int).Mul(cs[pi], x)
+	s = s.Sub(alpha, s)
+	sig.s[pi] = s.Mod(s, N)
+	sig.c = cs[0]
+
+	return sig, nil
+}
+
+// commit returns L = g^s_i * P_i^c and R = Hp(P_i)^s_i * I^c
+func (sig *Signature) commit(r *Ring, i int, c *big.Int) (LX, LY, RX, RY *big.Int) {
+	X1, Y1 := curve.ScalarBaseMult(sig.s[i].Bytes())
+	X2, Y2 := curve.ScalarMult(r.xs[i], r.ys[i], c.Bytes())
+	LX, LY = curve.Add(X1, Y1, X2, Y2)
+
+	HX, HY := hashToPoint(sig.ctx, r.xs[i], r.ys[i])
+	X1, Y1 = curve.ScalarMult(HX, HY, sig.s[i].Bytes())
+	X2, Y2 = curve.ScalarMult(sig.imgX, sig.imgY, c.Bytes())
+	RX, RY = curve.Add(X1, Y1, X2, Y2)
+
+	return
+}
+
+// Verify verifies the signature of msg by a member of ring r
+func (sig *Signature) Verify(r *Ring, msg []byte) error {
+	n := r.Size()
+	if len(sig.s) != n {
+		return errors.New("Signature doesn't match ring")
+	}
+	if !curve.IsOnCurve(sig.imgX, sig.imgY) {
+		return errors.New("Invalid key image")
+	}
+	N := curve.Params().N
+	for _, s := range append([]*big.Int{sig.c}, sig.s...) {
+		if s.Sign() < 0 || s.Cmp(N) >= 0 {
+			return errors.New("Invalid signature")
+		}
+	}
+
+	ringHash := r.Hash()
+	c := sig.c
+	for i := 0; i < n; i++ {
+		LX, LY, RX, RY := sig.commit(r, i, c)
+		c = challenge(ringHash, sig.ctx, msg, LX, LY, RX, RY)
+	}
+	if c.Cmp(sig.c) != 0 {
+		return errors.New("Invalid signature")
+	}
+	return nil
+}
+
+// KeyImage returns the key image of the signer
+func (sig *Signature) KeyImage() (*big.Int, *big.Int) {
+	return new(big.Int).Set(sig.imgX), new(big.Int).Set(sig.imgY)
+}
+
+// Context returns the context the key image is bound to, nil if none
+func (sig *Signature) Context() []byte {
+	return append([]byte(nil), sig.ctx...)
+}
+
+// c = H(ring, len(ctx), ctx, len(msg), msg, L, R)
+func challenge(ringHash [32]byte, ctx, msg []byte, LX, LY, RX, RY *big.Int) *big.Int {
+	h := sha256.Sum256(common.ConcatBytes(
+		ringHash[:],
+		frame(ctx),
+		frame(msg),
+		elliptic.Marshal(curve, LX, LY),
+		elliptic.Marshal(curve, RX, RY),
+	))
+	c := new(big.Int).SetBytes(h[:])
+	return c.Mod(c, curve.Params().N)
+}
+
+// frame prefixes b with its length so that consecutive variable-length
+// inputs of a hash can't be shifted into one another
+func frame(b []byte) []byte {
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(b)))
+	return common.ConcatBytes(l[:], b)
+}
+
+// hashToPoint maps ctx and public key P to a point whose discrete log is
+// unknown by try-and-increment
+func hashToPoint(ctx []byte, PX, PY *big.Int) (*big.Int, *big.Int) {
+	P := curve.Params().P
+	three := big.NewInt(3)
+	seed := sha256.Sum256(common.ConcatBytes(ctx, elliptic.Marshal(curve, PX, PY)))
+
+	for ctr := uint32(0); ; ctr++ {
+		var buf [4]byte
+		binary.BigEndian.PutUint32(buf[:], ctr)
+		d := sha256.Sum256(append(append([]byte("zkVote key image"), seed[:]...), buf[:]...))
+
+		// y^2 = x^3 - 3x + b
+		x := new(big.Int).SetBytes(d[:])
+		x = x.Mod(x, P)
+		rhs := new(big.Int).Exp(x, three, P)
+		rhs = rhs.Sub(rhs, new(big.Int).Mul(three, x))
+		rhs = rhs.Add(rhs, curve.Params().B)
+		rhs = rhs.Mod(rhs, P)
+		if y := new(big.Int).ModSqrt(rhs, P); y != nil && curve.IsOnCurve(x, y) {
+			return x, y
+		}
+	}
+}
+
+// randScalar returns a random number in [1, N-1]
+func randScalar() (*big.Int, error) {
+	r, err := rand.Int(rand.Reader, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return r.Add(r, big.NewInt(1)), nil
+}
+
+// JSONPoint defines json object of an EC point
+type JSONPoint struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+// JSONRing defines json object
+type JSONRing struct {
+	Keys []*JSONPoint `json:"keys"`
+}
+
+// JSONSignature defines json object
+type JSONSignature struct {
+	IX  string   `json:"ix"`
+	IY  string   `json:"iy"`
+	C   string   `json:"c"`
+	S   []string `json:"s"`
+	Ctx string   `json:"ctx,omitempty"`
+}
+
+// BuildJSONRing builds json object
+func (r *Ring) BuildJSONRing() *JSONRing {
+	obj := &JSONRing{Keys: make([]*JSONPoint, len(r.xs))}
+	for i := range r.xs {
+		obj.Keys[i] = &JSONPoint{common.BigIntToHexStr(r.xs[i]), common.BigIntToHexStr(r.ys[i])}
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (r *Ring) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.BuildJSONRing())
+}
+
+// FromJSONRing reconstructs from json object
+func (r *Ring) FromJSONRing(obj *JSONRing) error {
+	xs := make([]*big.Int, len(obj.Keys))
+	ys := make([]*big.Int, len(obj.Keys))
+	for i, k := range obj.Keys {
+		if k == nil {
+			return errors.New("Missing public key")
+		}
+		var err error
+		if xs[i], err = common.HexStrToBigInt(k.X); err != nil {
+			return err
+		}
+		if ys[i], err = common.HexStrToBigInt(k.Y); err != nil {
+			return err
+		}
+	}
+
+	r1, err := NewRing(xs, ys)
+	if err != nil {
+		return err
+	}
+	*r = *r1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (r *Ring) UnmarshalJSON(data []byte) error {
+	var obj JSONRing
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return r.FromJSONRing(&obj)
+}
+
+// BuildJSONSignature builds json object
+func (sig *Signature) BuildJSONSignature() *JSONSignature {
+	obj := &JSONSignature{
+		IX: common.BigIntToHexStr(sig.imgX),
+		IY: common.BigIntToHexStr(sig.imgY),
+		C:  common.BigIntToHexStr(sig.c),
+		S:  make([]string, len(sig.s)),
+	}
+	for i, s := range sig.s {
+		obj.S[i] = common.BigIntToHexStr(s)
+	}
+	if len(sig.ctx) > 0 {
+		obj.Ctx = common.BytesToHexStr(sig.ctx)
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (sig *Signature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sig.BuildJSONSignature())
+}
+
+// FromJSONSignature reconstructs from json object
+func (sig *Signature) FromJSONSignature(obj *JSONSignature) error {
+	var err error
+
+	sig1 := &Signature{s: make([]*big.Int, len(obj.S))}
+	fields := []struct {
+		dst **big.Int
+		src string
+	}{
+		{&sig1.imgX, obj.IX}, {&sig1.imgY, obj.IY}, {&sig1.c, obj.C},
+	}
+	for _, f := range fields {
+		if *f.dst, err = common.HexStrToBigInt(f.src); err != nil {
+			return err
+		}
+	}
+	for i, s := range obj.S {
+		if sig1.s[i], err = common.HexStrToBigInt(s); err != nil {
+			return err
+		}
+	}
+	if obj.Ctx != "" {
+		if sig1.ctx, err = common.HexStrToBytes(obj.Ctx); err != nil {
+			return err
+		}
+	}
+
+	*sig = *sig1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (sig *Signature) UnmarshalJSON(data []byte) error {
+	var obj JSONSignature
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return sig.FromJSONSignature(&obj)
+}
@@ -0,0 +1,101 @@
+package ring
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func genRing(n int, t *testing.T) (*Ring, []*ecdsa.PrivateKey) {
+	var (
+		keys   []*ecdsa.PrivateKey
+		xs, ys []*big.Int
+	)
+	for i := 0; i < n; i++ {
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, k)
+		xs, ys = append(xs, k.PublicKey.X), append(ys, k.PublicKey.Y)
+	}
+	r, err := NewRing(xs, ys)
+	assert.Nil(t, err)
+	return r, keys
+}
+
+func TestLSAG(t *testing.T) {
+	r, keys := genRing(5, t)
+	ctx := []byte("election")
+	msg := []byte("ballot")
+
+	sig, err := Sign(r, keys[2].D, ctx, msg)
+	assert.Nil(t, err)
+	assert.Nil(t, sig.Verify(r, msg))
+	assert.NotNil(t, sig.Verify(r, []byte("another ballot")))
+
+	data, err := json.Marshal(sig)
+	assert.Nil(t, err)
+	sig1 := new(Signature)
+	assert.Nil(t, json.Unmarshal(data, sig1))
+	assert.Equal(t, sig, sig1)
+	assert.Nil(t, sig1.Verify(r, msg))
+
+	data, err = json.Marshal(r)
+	assert.Nil(t, err)
+	r1 := new(Ring)
+	assert.Nil(t, json.Unmarshal(data, r1))
+	assert.Equal(t, r.Hash(), r1.Hash())
+	assert.Nil(t, sig.Verify(r1, msg))
+
+	// linkable in the same context only
+	sig2, err := Sign(r, keys[2].D, ctx, []byte("another ballot"))
+	assert.Nil(t, err)
+	X1, Y1 := sig.KeyImage()
+	X2, Y2 := sig2.KeyImage()
+	assert.True(t, X1.Cmp(X2) == 0 && Y1.Cmp(Y2) == 0)
+	sig3, err := Sign(r, keys[2].D, []byte("another election"), msg)
+	assert.Nil(t, err)
+	X3, _ := sig3.KeyImage()
+	assert.NotEqual(t, 0, X1.Cmp(X3))
+	sig4, err := Sign(r, keys[3].D, ctx, msg)
+	assert.Nil(t, err)
+	X4, _ := sig4.KeyImage()
+	assert.NotEqual(t, 0, X1.Cmp(X4))
+
+	// another ring
+	r2, keys2 := genRing(5, t)
+	assert.NotNil(t, sig.Verify(r2, msg))
+	_, err = Sign(r, keys2[0].D, ctx, msg)
+	assert.NotNil(t, err)
+
+	// forged key image
+	sig1.imgX, sig1.imgY = sig4.KeyImage()
+	assert.NotNil(t, sig1.Verify(r, msg))
+
+	// single key ring
+	r3, keys3 := genRing(1, t)
+	sig, err = Sign(r3, keys3[0].D, nil, msg)
+	assert.Nil(t, err)
+	assert.Nil(t, sig.Verify(r3, msg))
+
+	// duplicate key
+	_, err = NewRing([]*big.Int{keys[0].X, keys[0].X}, []*big.Int{keys[0].Y, keys[0].Y})
+	assert.NotNil(t, err)
+}
+
+func TestChallengeFraming(t *testing.T) {
+	r, keys := genRing(2, t)
+	h := r.Hash()
+	X, Y := keys[0].X, keys[0].Y
+
+	// bytes moved from the context to the message change the challenge
+	c1 := challenge(h, []byte("election"), []byte("ballot"), X, Y, X, Y)
+	c2 := challenge(h, []byte("electionb"), []byte("allot"), X, Y, X, Y)
+	c3 := challenge(h, nil, []byte("electionballot"), X, Y, X, Y)
+	assert.NotEqual(t, c1, c2)
+	assert.NotEqual(t, c1, c3)
+	assert.NotEqual(t, c2, c3)
+}
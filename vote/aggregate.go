@@ -0,0 +1,346 @@
+package vote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
+)
+
+// Aggregate - partial aggregate of a shard of binary ballots
+//
+// Shards of a ballot set can be verified and aggregated on different
+// machines and the aggregates merged into one from which the tally is
+// computed. An aggregate commits to the ballots it includes by the root of
+// their bulletin board, see Board, and keeps the voter ids so that
+// overlapping shards are detected when merging. As in
+// NewBinaryTallyWithParams, each voter may appear once if the election
+// identifies voters by a registry or a ring.
+type Aggregate struct {
+	gkX, gkY *big.Int
+	election []byte    // hash of the election manifest, nil if none
+	registry *[32]byte // root of the voter registry, nil if none
+	repeats  bool      // whether voters may appear more than once
+
+	n      int    // number of ballots
+	weight uint64 // total weight of ballots
+
+	HX, HY *big.Int // H = prod_i h_i^w_i
+	YX, YY *big.Int // Y = prod_i y_i^w_i
+
+	leaves []ballotLeaf // voters and ballots included, sorted by voter id
+}
+
+// voterID returns the id of the voter who cast b, i.e., the hash of the
+// data bound to the ballot proof
+func voterID(b *BinaryBallot) [32]byte {
+	return sha256.Sum256(b.proof.Data().Bytes())
+}
+
+// NewAggregate verifies ballots and aggregates them on workers goroutines,
+// one per CPU if workers <= 0
+func NewAggregate(p *ElectionParams, ballots []*BinaryBallot, workers int) (*Aggregate, error) {
+	a := &Aggregate{
+		gkX:      new(big.Int).Set(p.gkX),
+		gkY:      new(big.Int).Set(p.gkY),
+		election: p.context(),
+		registry: p.registry,
+		repeats:  !p.uniqueVoters(),
+	}
+
+	checked := checkBinaryBallots(p, ballots, workers)
+	for _, c := range checked {
+		if c.err != nil {
+			return nil, c.err
+		}
+		a.weight = a.weight + c.w
+		a.leaves = append(a.leaves, c.leaf)
+	}
+	a.HX, a.HY, a.YX, a.YY = sumWeighted(ballots, checked, workers)
+	a.n = len(ballots)
+
+	sortLeaves(a.leaves)
+	if !a.repeats && hasDuplicateVoter(a.leaves) {
+		return nil, errors.New("Duplicate voter")
+	}
+
+	return a, nil
+}
+
+// sameRoot checks if two optional registry roots are equal
+func sameRoot(a, b *[32]byte) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// hasDuplicateVoter checks sorted leaves for a voter appearing twice
+func hasDuplicateVoter(leaves []ballotLeaf) bool {
+	for i := 1; i < len(leaves); i++ {
+		if leaves[i].voter == leaves[i-1].voter {
+			return true
+		}
+	}
+	return false
+}
+
+// Size returns the number of ballots
+func (a *Aggregate) Size() int {
+	return a.n
+}
+
+// Weight returns the total weight of ballots
+func (a *Aggregate) Weight() uint64 {
+	return a.weight
+}
+
+// Root returns the root of the bulletin board of the ballots included,
+// zero if the aggregate is empty
+func (a *Aggregate) Root() [32]byte {
+	return ballotsRoot(a.leaves)
+}
+
+// Verify checks that the aggregate is computed from ballots, verified on
+// workers goroutines as in NewAggregate
+func (a *Aggregate) Verify(p *ElectionParams, ballots []*BinaryBallot, workers int) error {
+	a1, err := NewAggregate(p, ballots, workers)
+	if err != nil {
+		return err
+	}
+	if !a.equal(a1) {
+		return errors.New("Aggregate doesn't match ballots")
+	}
+	return nil
+}
+
+func (a *Aggregate) equal(b *Aggregate) bool {
+	return a.gkX.Cmp(b.gkX) == 0 && a.gkY.Cmp(b.gkY) == 0 &&
+		bytes.Equal(a.election, b.election) && sameRoot(a.registry, b.registry) &&
+		a.repeats == b.repeats &&
+		a.n == b.n && a.weight == b.weight &&
+		a.HX.Cmp(b.HX) == 0 && a.HY.Cmp(b.HY) == 0 &&
+		a.YX.Cmp(b.YX) == 0 && a.YY.Cmp(b.YY) == 0 &&
+		a.Root() == b.Root()
+}
+
+// MergeAggregates merges partial aggregates of the same election. It fails
+// if a voter is included in more than one of them, unless voters may appear
+// more than once.
+func MergeAggregates(parts []*Aggregate) (*Aggregate, error) {
+	if len(parts) == 0 {
+		return nil, errors.New("No aggregates")
+	}
+
+	m := &Aggregate{
+		gkX:      new(big.Int).Set(parts[0].gkX),
+		gkY:      new(big.Int).Set(parts[0].gkY),
+		election: append([]byte(nil), parts[0].election...),
+		HX:       new(big.Int),
+		HY:       new(big.Int),
+		YX:       new(big.Int),
+		YY:       new(big.Int),
+	}
+	if len(m.election) == 0 {
+		m.election = nil
+	}
+	m.registry = parts[0].registry
+	m.repeats = parts[0].repeats
+
+	for _, a := range parts {
+		if a.gkX.Cmp(m.gkX) != 0 || a.gkY.Cmp(m.gkY) != 0 {
+			return nil, errors.New("Aggregates of different g^k")
+		}
+		if !bytes.Equal(a.election, m.election) {
+			return nil, errors.New("Aggregates of different elections")
+		}
+		if !sameRoot(a.registry, m.registry) {
+			return nil, errors.New("Aggregates of different registries")
+		}
+		if a.repeats != m.repeats {
+			return nil, errors.New("Aggregates of different elections")
+		}
+
+		m.HX, m.HY = curve.Add(m.HX, m.HY, a.HX, a.HY)
+		m.YX, m.YY = curve.Add(m.YX, m.YY, a.YX, a.YY)
+		m.n = m.n + a.n
+		m.weight = m.weight + a.weight
+		m.leaves = append(m.leaves, a.leaves...)
+	}
+
+	sortLeaves(m.leaves)
+	if !m.repeats && hasDuplicateVoter(m.leaves) {
+		return nil, errors.New("Overlapping aggregates")
+	}
+
+	return m, nil
+}
+
+// VerifyMerge checks that merged is the merge of parts
+func VerifyMerge(merged *Aggregate, parts []*Aggregate) error {
+	m, err := MergeAggregates(parts)
+	if err != nil {
+		return err
+	}
+	if !merged.equal(m) {
+		return errors.New("Merged aggregate doesn't match parts")
+	}
+	return nil
+}
+
+// NewBinaryTallyFromAggregate creates a tally from an aggregate, e.g.,
+// merged from the aggregates of all shards of the ballots
+func NewBinaryTallyFromAggregate(p *ElectionParams, a *Aggregate) (*BinaryTally, error) {
+	if a.gkX.Cmp(p.gkX) != 0 || a.gkY.Cmp(p.gkY) != 0 {
+		return nil, errors.New("Aggregate of different g^k")
+	}
+	if err := checkContext(p.context(), a.election); err != nil {
+		return nil, err
+	}
+	if !sameRoot(p.registry, a.registry) {
+		return nil, errors.New("Aggregate of different registry")
+	}
+	if a.repeats == p.uniqueVoters() {
+		return nil, errors.New("Aggregate of different election")
+	}
+	if err := p.checkVoters(a.n); err != nil {
+		return nil, err
+	}
+
+	t := &BinaryTally{
+		gkX:      new(big.Int).Set(p.gkX),
+		gkY:      new(big.Int).Set(p.gkY),
+		authData: new(big.Int).Set(p.authData),
+		HX:       new(big.Int).Set(a.HX),
+		HY:       new(big.Int).Set(a.HY),
+		YX:       new(big.Int).Set(a.YX),
+		YY:       new(big.Int).Set(a.YY),
+		n:        a.n,
+		weight:   int(a.weight),
+		root:     a.Root(),
+		params:   p,
+	}
+	if a.registry != nil {
+		t.bound = int(a.weight)
+	}
+
+	return t, nil
+}
+
+// BuildJSONAggregate builds json object
+func (a *Aggregate) BuildJSONAggregate() *JSONAggregate {
+	obj := &JSONAggregate{
+		GKX:     common.BigIntToHexStr(a.gkX),
+		GKY:     common.BigIntToHexStr(a.gkY),
+		N:       a.n,
+		Weight:  a.weight,
+		Repeats: a.repeats,
+		HX:      common.BigIntToHexStr(a.HX),
+		HY:      common.BigIntToHexStr(a.HY),
+		YX:      common.BigIntToHexStr(a.YX),
+		YY:      common.BigIntToHexStr(a.YY),
+		Root:    merkle.HashToHexStr(a.Root()),
+		Voters:  make([]string, len(a.leaves)),
+		Ballots: make([]string, len(a.leaves)),
+	}
+	if a.election != nil {
+		obj.Election = common.BytesToHexStr(a.election)
+	}
+	if a.registry != nil {
+		obj.Registry = merkle.HashToHexStr(*a.registry)
+	}
+	for i, l := range a.leaves {
+		obj.Voters[i] = merkle.HashToHexStr(l.voter)
+		obj.Ballots[i] = merkle.HashToHexStr(l.ballot)
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (a *Aggregate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.BuildJSONAggregate())
+}
+
+// FromJSONAggregate reconstructs from json object and checks the root
+func (a *Aggregate) FromJSONAggregate(obj *JSONAggregate) error {
+	var err error
+
+	a1 := &Aggregate{n: obj.N, weight: obj.Weight, repeats: obj.Repeats}
+	fields := []struct {
+		dst **big.Int
+		src string
+	}{
+		{&a1.gkX, obj.GKX}, {&a1.gkY, obj.GKY},
+		{&a1.HX, obj.HX}, {&a1.HY, obj.HY},
+		{&a1.YX, obj.YX}, {&a1.YY, obj.YY},
+	}
+	for _, f := range fields {
+		if *f.dst, err = common.HexStrToBigInt(f.src); err != nil {
+			return err
+		}
+	}
+	if !isOnCurve(a1.gkX, a1.gkY) {
+		return errors.New("Invalid g^k")
+	}
+	if !isSumPoint(a1.HX, a1.HY) || !isSumPoint(a1.YX, a1.YY) {
+		return errors.New("Invalid aggregate ciphertext")
+	}
+	if obj.Election != "" {
+		if a1.election, err = common.HexStrToBytes(obj.Election); err != nil {
+			return err
+		}
+	}
+
+	if obj.Registry != "" {
+		root, err := merkle.HexStrToHash(obj.Registry)
+		if err != nil {
+			return err
+		}
+		a1.registry = &root
+	}
+
+	if len(obj.Voters) != a1.n || len(obj.Ballots) != a1.n {
+		return errors.New("Numbers of ballots and voters don't match")
+	}
+	a1.leaves = make([]ballotLeaf, len(obj.Voters))
+	for i := range obj.Voters {
+		l := &a1.leaves[i]
+		if l.voter, err = merkle.HexStrToHash(obj.Voters[i]); err != nil {
+			return err
+		}
+		if l.ballot, err = merkle.HexStrToHash(obj.Ballots[i]); err != nil {
+			return err
+		}
+		if i > 0 {
+			prev := &a1.leaves[i-1]
+			if compareLeaves(prev, l) > 0 || (!a1.repeats && prev.voter == l.voter) {
+				return errors.New("Voters not sorted or duplicate")
+			}
+		}
+	}
+
+	root, err := merkle.HexStrToHash(obj.Root)
+	if err != nil {
+		return err
+	}
+	if root != a1.Root() {
+		return errors.New("Aggregate root doesn't match ballots")
+	}
+
+	*a = *a1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (a *Aggregate) UnmarshalJSON(data []byte) error {
+	var obj JSONAggregate
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return a.FromJSONAggregate(&obj)
+}
@@ -0,0 +1,113 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+
+	var ballots []*BinaryBallot
+	for _, value := range []bool{true, false, true, true, false} {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		ballots = append(ballots, genBinaryBallot(value, addr, k.PublicKey.X, k.PublicKey.Y, t))
+	}
+
+	// two shards
+	a1, err := NewAggregate(p, ballots[:2], 0)
+	assert.Nil(t, err)
+	a2, err := NewAggregate(p, ballots[2:], 0)
+	assert.Nil(t, err)
+	assert.Nil(t, a1.Verify(p, ballots[:2], 0))
+	assert.NotNil(t, a1.Verify(p, ballots[1:3], 0))
+
+	data, err := json.Marshal(a2)
+	assert.Nil(t, err)
+	a2r := new(Aggregate)
+	assert.Nil(t, json.Unmarshal(data, a2r))
+	assert.Nil(t, a2r.Verify(p, ballots[2:], 0))
+
+	merged, err := MergeAggregates([]*Aggregate{a1, a2r})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, merged.Size())
+	assert.Nil(t, VerifyMerge(merged, []*Aggregate{a2, a1}))
+	assert.NotNil(t, VerifyMerge(merged, []*Aggregate{a1}))
+
+	all, err := NewAggregate(p, ballots, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, all.Root(), merged.Root())
+
+	// same result as tallying all ballots
+	tal, err := NewBinaryTallyFromAggregate(p, merged)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.V)
+
+	tal, err = NewBinaryTallyWithParams(p, ballots, 0)
+	assert.Nil(t, err)
+	res1, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, res1.V, res.V)
+
+	// overlapping shards count voters again, as tallies do if voters aren't
+	// identified by a registry or a ring
+	a3, err := NewAggregate(p, ballots[1:3], 0)
+	assert.Nil(t, err)
+	overlap, err := MergeAggregates([]*Aggregate{a1, a2, a3})
+	assert.Nil(t, err)
+	assert.Nil(t, overlap.Verify(p, append(append([]*BinaryBallot{}, ballots...), ballots[1:3]...), 0))
+	_, err = NewBinaryTallyWithParams(p, []*BinaryBallot{ballots[0], ballots[0]}, 0)
+	assert.Nil(t, err)
+	twice, err := NewAggregate(p, []*BinaryBallot{ballots[0], ballots[0]}, 0)
+	assert.Nil(t, err)
+	data1, err := json.Marshal(twice)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data1, new(Aggregate)))
+
+	// tampered root or voters
+	var obj JSONAggregate
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.Voters = obj.Voters[1:]
+	obj.N = len(obj.Voters)
+	assert.NotNil(t, new(Aggregate).FromJSONAggregate(&obj))
+
+	// points off the curve, the empty aggregate sums to (0, 0)
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.HX = "0x01"
+	assert.NotNil(t, new(Aggregate).FromJSONAggregate(&obj))
+	empty, err := NewAggregate(p, nil, 0)
+	assert.Nil(t, err)
+	data, err = json.Marshal(empty)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, new(Aggregate)))
+
+	// different keys or elections
+	k1, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	p1, err := NewElectionParams(k1.PublicKey.X, k1.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	_, err = NewAggregate(p1, ballots, 0)
+	assert.NotNil(t, err)
+	_, err = NewBinaryTallyFromAggregate(p1, merged)
+	assert.NotNil(t, err)
+
+	p1, err = NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, p1.SetRegistry([32]byte{1}))
+	_, err = NewBinaryTallyFromAggregate(p1, merged)
+	assert.NotNil(t, err)
+
+	p.SetElection([32]byte{1})
+	_, err = NewBinaryTallyFromAggregate(p, merged)
+	assert.NotNil(t, err)
+}
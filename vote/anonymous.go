@@ -0,0 +1,103 @@
+package vote
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/ring"
+)
+
+// NewAnonymousBinaryBallot generates a binary ballot signed with a linkable
+// ring signature by private key x whose public key is in ring r
+//
+// Instead of the voter's address, the ballot is bound to the hash of the key
+// image of x, which identifies the voter as the same member of the ring in
+// every ballot but not which member.
+func NewAnonymousBinaryBallot(value bool, a, gkX, gkY, x *big.Int, r *ring.Ring) (*BinaryBallot, error) {
+	return newAnonymousBinaryBallot(value, a, gkX, gkY, x, r, nil)
+}
+
+// NewAnonymousBinaryBallotInElection generates an anonymous binary ballot
+// bound to the election described by m. The key image is bound to the
+// election too so that ballots of the same voter can't be linked across
+// elections.
+func NewAnonymousBinaryBallotInElection(m *Manifest, value bool, a, x *big.Int, r *ring.Ring) (*BinaryBallot, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash := m.Hash()
+	return newAnonymousBinaryBallot(value, a, m.GKX, m.GKY, x, r, hash[:])
+}
+
+func newAnonymousBinaryBallot(value bool, a, gkX, gkY, x *big.Int, r *ring.Ring, ctx []byte) (*BinaryBallot, error) {
+	if !isInRange(x) {
+		return nil, errors.New("Invalid private key")
+	}
+
+	ring.SetEllipticCurve(curve)
+	b, err := newBinaryBallot(value, a, gkX, gkY, anonymousData(ring.KeyImage(x, ctx)), ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := b.ringMessage()
+	if err != nil {
+		return nil, err
+	}
+	if b.signature, err = ring.Sign(r, x, ctx, msg); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// anonymousData returns the data identifying the voter with key image I
+func anonymousData(IX, IY *big.Int) *big.Int {
+	h := sha256.Sum256(elliptic.Marshal(curve, IX, IY))
+	return new(big.Int).SetBytes(h[:])
+}
+
+// ringMessage returns the message signed by the ring signature, i.e., the
+// hash of the ballot without eligibility proof and signature
+func (b *BinaryBallot) ringMessage() ([]byte, error) {
+	obj := b.BuildJSONBinaryBallot()
+	obj.Eligibility, obj.Signature = nil, nil
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(data)
+	return h[:], nil
+}
+
+// Signature returns the ring signature of the ballot, nil if not anonymous
+func (b *BinaryBallot) Signature() *ring.Signature {
+	return b.signature
+}
+
+// VerifySignature checks that the ballot is signed by a member of ring r and
+// bound to the key image of the signer in the context of the ballot
+func (b *BinaryBallot) VerifySignature(r *ring.Ring) error {
+	if b.signature == nil {
+		return errors.New("Missing ring signature")
+	}
+	if !bytes.Equal(b.proof.Context(), b.signature.Context()) {
+		return errors.New("Invalid ring signature context")
+	}
+	if anonymousData(b.signature.KeyImage()).Cmp(b.proof.Data()) != 0 {
+		return errors.New("Ballot not bound to key image")
+	}
+
+	msg, err := b.ringMessage()
+	if err != nil {
+		return err
+	}
+	ring.SetEllipticCurve(curve)
+	return b.signature.Verify(r, msg)
+}
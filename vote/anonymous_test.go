@@ -0,0 +1,109 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zzGHzz/zkVote/ring"
+)
+
+func TestAnonymousBallot(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	var (
+		keys   []*ecdsa.PrivateKey
+		xs, ys []*big.Int
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		keys = append(keys, key)
+		xs, ys = append(xs, key.PublicKey.X), append(ys, key.PublicKey.Y)
+	}
+	r, err := ring.NewRing(xs, ys)
+	assert.Nil(t, err)
+
+	m, err := NewManifest("e1", "Q", nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	p, err := m.Params(authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, p.SetRing(r))
+
+	data, err := json.Marshal(p)
+	assert.Nil(t, err)
+	p1 := new(ElectionParams)
+	assert.Nil(t, json.Unmarshal(data, p1))
+	assert.Equal(t, r.Hash(), p1.Ring().Hash())
+
+	// voters 0, 1 and 2 vote yes, voter 3 no
+	var ballots []*BinaryBallot
+	for i, key := range keys {
+		a, _ := randScalar()
+		b, err := NewAnonymousBinaryBallotInElection(m, i < 3, a, key.D, r)
+		assert.Nil(t, err)
+
+		data, err := json.Marshal(b)
+		assert.Nil(t, err)
+		b1 := new(BinaryBallot)
+		assert.Nil(t, json.Unmarshal(data, b1))
+		assert.Equal(t, b, b1)
+		ballots = append(ballots, b1)
+	}
+
+	tal, err := NewBinaryTallyWithParams(p1, ballots, 0)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.V)
+
+	// the same key votes twice
+	a, _ := randScalar()
+	b, err := NewAnonymousBinaryBallotInElection(m, true, a, keys[3].D, r)
+	assert.Nil(t, err)
+	_, err = NewBinaryTallyWithParams(p, append(ballots, b), 0)
+	assert.NotNil(t, err)
+
+	v, err := NewBinaryVoteWithParams(p)
+	assert.Nil(t, err)
+	assert.Nil(t, v.Open())
+	assert.Nil(t, v.Cast(ballots[3], ballots[3].proof.Data()))
+	assert.NotNil(t, v.Cast(b, authAddr))
+	assert.Nil(t, v.Cast(b, b.proof.Data()))
+	assert.Equal(t, 1, len(v.ballots))
+
+	// key not in the ring
+	outsider, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	_, err = NewAnonymousBinaryBallotInElection(m, true, a, outsider.D, r)
+	assert.NotNil(t, err)
+
+	// ballot without ring signature
+	b = genBinaryBallot(true, authAddr, k.PublicKey.X, k.PublicKey.Y, t)
+	_, err = NewBinaryTallyWithParams(p, []*BinaryBallot{b}, 0)
+	assert.NotNil(t, err)
+
+	// tampered ballot
+	b, err = NewAnonymousBinaryBallotInElection(m, true, a, keys[0].D, r)
+	assert.Nil(t, err)
+	b.signature = ballots[1].signature
+	_, err = NewBinaryTallyWithParams(p, []*BinaryBallot{b}, 0)
+	assert.NotNil(t, err)
+
+	// another election
+	b, err = NewAnonymousBinaryBallot(true, a, k.PublicKey.X, k.PublicKey.Y, keys[0].D, r)
+	assert.Nil(t, err)
+	assert.Nil(t, b.VerifySignature(r))
+	_, err = NewBinaryTallyWithParams(p, []*BinaryBallot{b}, 0)
+	assert.NotNil(t, err)
+
+	// ring and registry are exclusive
+	assert.NotNil(t, p.SetRegistry([32]byte{1}))
+	_, ok := p.Registry()
+	assert.False(t, ok)
+	p.ring = nil
+	assert.Nil(t, p.SetRegistry([32]byte{1}))
+	assert.NotNil(t, p.SetRing(r))
+}
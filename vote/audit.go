@@ -0,0 +1,134 @@
+package vote
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+)
+
+// ErrSpoiled is returned by Cast for a ballot whose randomness is revealed
+var ErrSpoiled = errors.New("Ballot spoiled")
+
+// SpoiledBallot - ballot spoiled in a cast-or-audit challenge
+//
+// The voting device commits to a ballot, e.g., by showing its hash, before
+// the voter decides to cast or spoil it. Spoiling reveals a and v so that
+// anyone can check that the ballot encrypts the voter's choice. A spoiled
+// ballot is no longer secret and can't be cast.
+type SpoiledBallot struct {
+	ballot *BinaryBallot
+	a      *big.Int // h = g^a
+	value  bool
+}
+
+// NewSpoiledBallot spoils b by revealing its randomness a and value
+func NewSpoiledBallot(b *BinaryBallot, a *big.Int, value bool) (*SpoiledBallot, error) {
+	s := &SpoiledBallot{ballot: b, a: new(big.Int).Set(a), value: value}
+	if err := s.Audit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Audit checks that the ballot is valid and encrypts the revealed value
+// with the revealed randomness
+func (s *SpoiledBallot) Audit() error {
+	if err := s.ballot.VerifyBallot(); err != nil {
+		return err
+	}
+	if !isInRange(s.a) {
+		return errors.New("Invalid a")
+	}
+
+	hX, hY := curve.ScalarBaseMult(s.a.Bytes())
+	if hX.Cmp(s.ballot.hX) != 0 || hY.Cmp(s.ballot.hY) != 0 {
+		return errors.New("h doesn't match a")
+	}
+
+	gkX, gkY := s.ballot.proof.PublicKey()
+	yX, yY := curve.ScalarMult(gkX, gkY, s.a.Bytes())
+	if s.value {
+		yX, yY = curve.Add(yX, yY, curve.Params().Gx, curve.Params().Gy)
+	}
+	if yX.Cmp(s.ballot.yX) != 0 || yY.Cmp(s.ballot.yY) != 0 {
+		return errors.New("y doesn't match a and v")
+	}
+
+	return nil
+}
+
+// Ballot returns the spoiled ballot
+func (s *SpoiledBallot) Ballot() *BinaryBallot {
+	return s.ballot
+}
+
+// Value returns the revealed value
+func (s *SpoiledBallot) Value() bool {
+	return s.value
+}
+
+// Hash returns the hash of the json encoding of the ballot, which the
+// voting device may show as its commitment before the voter casts or
+// spoils it
+func (b *BinaryBallot) Hash() ([32]byte, error) {
+	return ballotHash(b)
+}
+
+// spoilID returns the id of the randomness of b, i.e., the hash of h. Any
+// ballot with the same h is spoiled by revealing a.
+func spoilID(b *BinaryBallot) [32]byte {
+	return sha256.Sum256(elliptic.Marshal(curve, b.hX, b.hY))
+}
+
+// BuildJSONSpoiledBallot builds json object
+func (s *SpoiledBallot) BuildJSONSpoiledBallot() *JSONSpoiledBallot {
+	obj := &JSONSpoiledBallot{
+		Ballot: s.ballot.BuildJSONBinaryBallot(),
+		A:      common.BigIntToHexStr(s.a),
+	}
+	if s.value {
+		obj.V = 1
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (s *SpoiledBallot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.BuildJSONSpoiledBallot())
+}
+
+// FromJSONSpoiledBallot reconstructs from json object
+func (s *SpoiledBallot) FromJSONSpoiledBallot(obj *JSONSpoiledBallot) error {
+	if obj.Ballot == nil {
+		return errors.New("Missing ballot")
+	}
+	if obj.V > 1 {
+		return errors.New("Invalid v")
+	}
+
+	s1 := &SpoiledBallot{ballot: new(BinaryBallot), value: obj.V == 1}
+	if err := s1.ballot.FromJSONBinaryBallot(obj.Ballot); err != nil {
+		return err
+	}
+	var err error
+	if s1.a, err = common.HexStrToBigInt(obj.A); err != nil {
+		return err
+	}
+
+	*s = *s1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (s *SpoiledBallot) UnmarshalJSON(data []byte) error {
+	var obj JSONSpoiledBallot
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return s.FromJSONSpoiledBallot(&obj)
+}
@@ -0,0 +1,87 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpoiledBallot(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+	addr := new(big.Int).SetBytes(getRandAddr())
+
+	a, _ := randScalar()
+	b, err := NewBinaryBallot(true, a, k.PublicKey.X, k.PublicKey.Y, addr)
+	assert.Nil(t, err)
+	commitment, err := b.Hash()
+	assert.Nil(t, err)
+
+	// wrong reveals
+	_, err = NewSpoiledBallot(b, a, false)
+	assert.NotNil(t, err)
+	a1, _ := randScalar()
+	_, err = NewSpoiledBallot(b, a1, true)
+	assert.NotNil(t, err)
+
+	s, err := NewSpoiledBallot(b, a, true)
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(s)
+	assert.Nil(t, err)
+	s1 := new(SpoiledBallot)
+	assert.Nil(t, json.Unmarshal(data, s1))
+	assert.Nil(t, s1.Audit())
+	assert.True(t, s1.Value())
+	h, err := s1.Ballot().Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, commitment, h)
+
+	var obj JSONSpoiledBallot
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.V = 0
+	assert.Nil(t, s1.FromJSONSpoiledBallot(&obj))
+	assert.NotNil(t, s1.Audit())
+
+	// spoiled ballots are refused, also when re-encrypted with the same a
+	v, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Spoil(s))
+	assert.Nil(t, v.Open())
+	assert.Nil(t, v.Spoil(s))
+	assert.NotNil(t, v.Spoil(nil))
+	assert.Equal(t, ErrSpoiled, v.Cast(b, addr))
+	b1, err := NewBinaryBallot(false, a, k.PublicKey.X, k.PublicKey.Y, addr)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrSpoiled, v.Cast(b1, addr))
+
+	data, err = json.Marshal(v)
+	assert.Nil(t, err)
+	v1 := new(BinaryVote)
+	assert.Nil(t, json.Unmarshal(data, v1))
+	assert.Equal(t, ErrSpoiled, v1.Cast(b, addr))
+
+	// a ballot cast can't be spoiled
+	b2 := genBinaryBallot(false, addr, k.PublicKey.X, k.PublicKey.Y, t)
+	assert.Nil(t, v.Cast(b2, addr))
+	a2, _ := randScalar()
+	b3, err := NewBinaryBallot(false, a2, k.PublicKey.X, k.PublicKey.Y, addr)
+	assert.Nil(t, err)
+	assert.Nil(t, v.Cast(b3, addr))
+	s3, err := NewSpoiledBallot(b3, a2, false)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Spoil(s3))
+
+	// nor once the vote is closed
+	a4, _ := randScalar()
+	b4, err := NewBinaryBallot(true, a4, k.PublicKey.X, k.PublicKey.Y, addr)
+	assert.Nil(t, err)
+	s4, err := NewSpoiledBallot(b4, a4, true)
+	assert.Nil(t, err)
+	assert.Nil(t, v.Close())
+	assert.NotNil(t, v.Spoil(s4))
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/ring"
 
 	"github.com/zzGHzz/zkVote/zk"
 )
@@ -16,12 +17,30 @@ type BinaryBallot struct {
 	hX, hY *big.Int // h = g^a
 	yX, yY *big.Int // y = g^{a*k} * g^v
 	proof  *zk.BinaryProof
+
+	eligibility *Eligibility    // inclusion proof in the voter registry, optional
+	signature   *ring.Signature // ring signature of an anonymous voter, optional
 }
 
 // NewBinaryBallot generates a binary ballot
 //
 // data contains the data (e.g., account address) that identifies the voter.
 func NewBinaryBallot(value bool, a, gkX, gkY *big.Int, data *big.Int) (*BinaryBallot, error) {
+	return newBinaryBallot(value, a, gkX, gkY, data, nil)
+}
+
+// NewBinaryBallotInElection generates a binary ballot bound to the election
+// described by m, which is invalid in any other election
+func NewBinaryBallotInElection(m *Manifest, value bool, a, data *big.Int) (*BinaryBallot, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash := m.Hash()
+	return newBinaryBallot(value, a, m.GKX, m.GKY, data, hash[:])
+}
+
+func newBinaryBallot(value bool, a, gkX, gkY *big.Int, data *big.Int, ctx []byte) (*BinaryBallot, error) {
 	var (
 		yX, yY *big.Int
 
@@ -57,15 +76,15 @@ func NewBinaryBallot(value bool, a, gkX, gkY *big.Int, data *big.Int) (*BinaryBa
 	}
 
 	// Generate proof
-	proof, err = prover.Prove(data)
+	proof, err = prover.ProveInContext(ctx, data)
 	if err != nil {
 		return nil, err
 	}
 
 	return &BinaryBallot{
-		hX, hY,
-		yX, yY,
-		proof,
+		hX: hX, hY: hY,
+		yX: yX, yY: yY,
+		proof: proof,
 	}, nil
 }
 
@@ -90,6 +109,86 @@ func (b *BinaryBallot) VerifyBallot() error {
 	return nil
 }
 
+// SetEligibility attaches the inclusion proof of the voter in the registry
+func (b *BinaryBallot) SetEligibility(e *Eligibility) {
+	b.eligibility = e
+}
+
+// Eligibility returns the inclusion proof of the voter, nil if not attached
+func (b *BinaryBallot) Eligibility() *Eligibility {
+	return b.eligibility
+}
+
+// VerifyEligibility checks the attached inclusion proof of the voter
+// identified by the data bound to the ballot against the registry root
+func (b *BinaryBallot) VerifyEligibility(root [32]byte) error {
+	if b.eligibility == nil {
+		return errors.New("Missing eligibility proof")
+	}
+	return b.eligibility.Verify(root, b.proof.Data())
+}
+
+// weighted returns the ballot raised to the weight, i.e., (h^w, y^w) which
+// encrypts w*v
+func (b *BinaryBallot) weighted(w uint64) (hX, hY, yX, yY *big.Int) {
+	if w == 1 {
+		return b.hX, b.hY, b.yX, b.yY
+	}
+
+	e := new(big.Int).SetUint64(w).Bytes()
+	hX, hY = curve.ScalarMult(b.hX, b.hY, e)
+	yX, yY = curve.ScalarMult(b.yX, b.yY, e)
+	return
+}
+
+// CheckKey checks that the ballot is encrypted with the authority public
+// key g^k
+func (b *BinaryBallot) CheckKey(gkX, gkY *big.Int) error {
+	x, y := b.proof.PublicKey()
+	if x.Cmp(gkX) != 0 || y.Cmp(gkY) != 0 {
+		return errors.New("Ballot not encrypted with g^k")
+	}
+	return nil
+}
+
+// CheckElection checks that the ballot is bound to the election described
+// by m, or to none if m is nil
+func (b *BinaryBallot) CheckElection(m *Manifest) error {
+	var ctx []byte
+	if m != nil {
+		hash := m.Hash()
+		ctx = hash[:]
+	}
+	return checkContext(ctx, b.proof.Context())
+}
+
+// Kind returns BinaryKind
+func (b *BinaryBallot) Kind() BallotKind {
+	return BinaryKind
+}
+
+// Voter returns the data bound to the ballot that identifies the voter
+func (b *BinaryBallot) Voter() *big.Int {
+	return b.proof.Data()
+}
+
+// PublicKey returns g^k the ballot is encrypted with
+func (b *BinaryBallot) PublicKey() (*big.Int, *big.Int) {
+	return b.proof.PublicKey()
+}
+
+// Ciphertexts returns (h, y)
+func (b *BinaryBallot) Ciphertexts() []*zk.Ciphertext {
+	return []*zk.Ciphertext{b.ciphertext()}
+}
+
+func (b *BinaryBallot) ciphertext() *zk.Ciphertext {
+	return &zk.Ciphertext{
+		HX: new(big.Int).Set(b.hX), HY: new(big.Int).Set(b.hY),
+		YX: new(big.Int).Set(b.yX), YY: new(big.Int).Set(b.yY),
+	}
+}
+
 func (b *BinaryBallot) String() (string, string) {
 	return fmt.Sprintf("h = (%x, %x); y = (%x, %x)", b.hX, b.hY, b.yX, b.yY), b.proof.String()
 }
@@ -98,7 +197,7 @@ func (b *BinaryBallot) String() (string, string) {
 func (b *BinaryBallot) BuildJSONBinaryBallot() *JSONBinaryBallot {
 	_p := b.proof.BuildJSONBinaryProof()
 
-	return &JSONBinaryBallot{
+	obj := &JSONBinaryBallot{
 		HX: common.BigIntToHexStr(b.hX),
 		HY: common.BigIntToHexStr(b.hY),
 		YX: common.BigIntToHexStr(b.yX),
@@ -119,8 +218,18 @@ func (b *BinaryBallot) BuildJSONBinaryBallot() *JSONBinaryBallot {
 			A2Y:  _p.A2Y,
 			B2X:  _p.B2X,
 			B2Y:  _p.B2Y,
+			Ctx:  _p.Ctx,
 		},
 	}
+
+	if b.eligibility != nil {
+		obj.Eligibility = b.eligibility.BuildJSONEligibility()
+	}
+	if b.signature != nil {
+		obj.Signature = b.signature.BuildJSONSignature()
+	}
+
+	return obj
 }
 
 // MarshalJSON implements json marshal
@@ -167,6 +276,7 @@ func (b *BinaryBallot) FromJSONBinaryBallot(obj *JSONBinaryBallot) error {
 		A2Y:  _p.A2Y,
 		B2X:  _p.B2X,
 		B2Y:  _p.B2Y,
+		Ctx:  _p.Ctx,
 
 		GAX: obj.HX,
 		GAY: obj.HY,
@@ -178,6 +288,22 @@ func (b *BinaryBallot) FromJSONBinaryBallot(obj *JSONBinaryBallot) error {
 		return err
 	}
 
+	b.eligibility = nil
+	if obj.Eligibility != nil {
+		b.eligibility = new(Eligibility)
+		if err = b.eligibility.FromJSONEligibility(obj.Eligibility); err != nil {
+			return err
+		}
+	}
+
+	b.signature = nil
+	if obj.Signature != nil {
+		b.signature = new(ring.Signature)
+		if err = b.signature.FromJSONSignature(obj.Signature); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -1,12 +1,17 @@
 package vote
 
 import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
 	"github.com/zzGHzz/zkVote/zk"
 )
 
@@ -18,51 +23,134 @@ type BinaryTally struct {
 	HX, HY *big.Int // H = prod_i h_i = prod_i g^a_i
 	YX, YY *big.Int // Y = prod_i y_i = prod_i g^{a_i*k + v_i}
 	n      int      // number of ballots
+	weight int      // total weight of ballots
+	bound  int      // upper bound of V, n if zero
+	root   [32]byte // commitment to the ballots, see Board
+
+	excluded *[32]byte // commitment to the excluded ballots, nil unless created by NewBinaryTallyExcluding
+
+	params *ElectionParams // turnout and pass rules
 }
 
 // BinaryTallyRes structure
 type BinaryTallyRes struct {
 	// gkX, gkY *big.Int
 
-	V int // V = sum_i v_i
-	// HX, HY *big.Int // h = prod_i g^a_i
+	V  int // V = sum_i v_i
+	No int // total weight of ballots not voting yes
+
+	HX, HY *big.Int // h = prod_i g^a_i, nil in legacy results
 	XX, XY *big.Int // X = h^k
 	YX, YY *big.Int // Y = X * g^v
 
+	ballots  *[32]byte // commitment to the ballots counted, nil in legacy results
+	excluded *[32]byte // commitment to the ballots excluded, nil if none can be
+
 	// hashedAuthAddr []byte
 	proof *zk.ECFSProof // zkp proves the correctness of h^k
+	link  *zk.DLEQProof // zkp proves that X = h^k for the k of g^k
+
+	Turnout   int  // number of ballots counted, n
+	QuorumMet bool // whether the minimum turnout is reached
+	Passed    bool // whether the quorum is met and the yes votes pass
+
+	// threshold decryption, used instead of proof
+	pub      *ThresholdKey
+	partials []*PartialDecryption // partial decryptions combined into X
 }
 
 // NewBinaryTally creates a new tally
 func NewBinaryTally(gkX, gkY, authData *big.Int, ballots []*BinaryBallot) (*BinaryTally, error) {
-	if !isOnCurve(gkX, gkY) {
+	p, err := NewElectionParams(gkX, gkY, authData)
+	if err != nil {
 		return nil, errors.New("Invalid authority public key")
 	}
 
-	HX := new(big.Int)
-	HY := new(big.Int)
-	YX := new(big.Int)
-	YY := new(big.Int)
+	return NewBinaryTallyWithParams(p, ballots, 0)
+}
+
+// NewBinaryTallyWithParams creates a new tally with election parameters
+//
+// If a voter registry is set, every ballot must carry a valid eligibility
+// proof, each voter may appear once and ballots are counted with the
+// voters' weights. If a ring is set, every ballot must carry a valid ring
+// signature and each key image may appear once. More ballots than the
+// maximum number of voters are rejected.
+//
+// Ballots are verified and aggregated on workers goroutines, one per CPU if
+// workers <= 0. The tally doesn't depend on it.
+func NewBinaryTallyWithParams(p *ElectionParams, ballots []*BinaryBallot, workers int) (*BinaryTally, error) {
+	if err := p.checkVoters(len(ballots)); err != nil {
+		return nil, err
+	}
+
+	registry := p.uniqueVoters()
+	voters := make(map[[32]byte]bool)
 
-	for _, b := range ballots {
-		if err := b.VerifyBallot(); err != nil {
-			return nil, err
+	// ballots are verified in parallel, errors are reported in order
+	checked := checkBinaryBallots(p, ballots, workers)
+	for _, c := range checked {
+		if c.err != nil {
+			return nil, c.err
 		}
+		if registry {
+			if voters[c.leaf.voter] {
+				return nil, errors.New("Duplicate voter")
+			}
+			voters[c.leaf.voter] = true
+		}
+	}
 
-		HX, HY = curve.Add(HX, HY, b.hX, b.hY)
-		YX, YY = curve.Add(YX, YY, b.yX, b.yY)
+	return newCheckedBinaryTally(p, ballots, checked, workers), nil
+}
+
+// newCheckedBinaryTally creates a tally of ballots all found valid by
+// checkBinaryBallots
+func newCheckedBinaryTally(p *ElectionParams, ballots []*BinaryBallot, checked []checkedBallot, workers int) *BinaryTally {
+	var (
+		total  uint64
+		leaves []ballotLeaf
+	)
+	for _, c := range checked {
+		leaves = append(leaves, c.leaf)
+		total = total + c.w
 	}
+	HX, HY, YX, YY := sumWeighted(ballots, checked, workers)
 
-	return &BinaryTally{
-		gkX:      new(big.Int).Set(gkX),
-		gkY:      new(big.Int).Set(gkY),
-		authData: new(big.Int).Set(authData),
+	t := &BinaryTally{
+		gkX:      new(big.Int).Set(p.gkX),
+		gkY:      new(big.Int).Set(p.gkY),
+		authData: new(big.Int).Set(p.authData),
 		HX:       HX,
 		HY:       HY,
 		YX:       YX,
 		YY:       YY,
 		n:        len(ballots),
-	}, nil
+		weight:   int(total),
+		root:     ballotsRoot(leaves),
+		params:   p,
+	}
+	if p.uniqueVoters() {
+		t.bound = int(total)
+	}
+
+	return t
+}
+
+// H returns H = prod_i h_i, which is decrypted by the authority
+func (t *BinaryTally) H() (*big.Int, *big.Int) {
+	return new(big.Int).Set(t.HX), new(big.Int).Set(t.HY)
+}
+
+// SetBound sets the upper bound of V searched by the discrete-log solver,
+// e.g., the total weight of a weighted vote. It defaults to the number of
+// ballots.
+func (t *BinaryTally) SetBound(max int) error {
+	if max < 0 {
+		return errors.New("Invalid bound")
+	}
+	t.bound = max
+	return nil
 }
 
 // Tally computes result and zk proof
@@ -71,6 +159,17 @@ func (t *BinaryTally) Tally(k *big.Int) (*BinaryTallyRes, error) {
 }
 
 func (t *BinaryTally) tally(k *big.Int) (*BinaryTallyRes, error) {
+	res, err := t.decryptV(k)
+	if err != nil {
+		return nil, err
+	}
+	t.evaluate(res)
+
+	return res, nil
+}
+
+// decryptV computes V and zk proofs without evaluating the result
+func (t *BinaryTally) decryptV(k *big.Int) (*BinaryTallyRes, error) {
 	if !isInRange(k) {
 		return nil, errors.New("Invalid k")
 	}
@@ -80,55 +179,386 @@ func (t *BinaryTally) tally(k *big.Int) (*BinaryTallyRes, error) {
 		return nil, errors.New("k doesn't match saved g^k")
 	}
 
-	// X = h^k where h = prod_i g^a_i
-	XX, XY := curve.ScalarMult(t.HX, t.HY, k.Bytes())
-
-	V := 0
-	if XX.Cmp(t.YX) != 0 || XY.Cmp(t.YY) != 0 {
-		// g^v = Y/X
-		iXX, iXY := new(big.Int).Set(XX), new(big.Int).Sub(curve.Params().P, XY)
-		gVX, gVY := curve.Add(t.YX, t.YY, iXX, iXY)
-
-		// power break v
-		X, Y := big.NewInt(0), big.NewInt(0)
-		for {
-			V = V + 1
-			X, Y = curve.Add(X, Y, curve.Params().Gx, curve.Params().Gy)
-			if X.Cmp(gVX) == 0 && Y.Cmp(gVY) == 0 {
-				break
-			}
-			if V > t.n {
-				return nil, errors.New("Tally failed")
-			}
+	max := t.n
+	if t.bound > 0 {
+		max = t.bound
+	}
+
+	r, err := solve(k, t.HX, t.HY, t.YX, t.YY, 0, max)
+	if err != nil {
+		return nil, err
+	}
+	r.complete(t)
+	if err := r.prove(k, t.HX, t.HY, t.authData, t.params.context()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// evaluate records the turnout and whether the result passes
+func (t *BinaryTally) evaluate(r *BinaryTallyRes) {
+	r.Turnout = t.n
+	r.QuorumMet, r.Passed = t.params.evaluate(t.n, t.weight, r.V)
+}
+
+// Combine computes result from partial decryptions of H published by the
+// trustees holding shares of k. Any t valid partial decryptions suffice.
+// Partial decryptions bound to another election are ignored.
+func (t *BinaryTally) Combine(pub *ThresholdKey, partials []*PartialDecryption) (*BinaryTallyRes, error) {
+	gkX, gkY := pub.PublicKey()
+	if gkX.Cmp(t.gkX) != 0 || gkY.Cmp(t.gkY) != 0 {
+		return nil, errors.New("Threshold key doesn't match saved g^k")
+	}
+
+	var bound []*PartialDecryption
+	for _, d := range partials {
+		if d != nil && d.proof != nil && checkContext(t.params.context(), d.proof.Context()) == nil {
+			bound = append(bound, d)
 		}
 	}
 
-	// Generate zkp for proving the correctness of h^k
-	prover, err := zk.NewECFSProver(k, t.HX, t.HY)
+	XX, XY, used, err := combinePartials(pub, t.HX, t.HY, bound)
+	if err != nil {
+		return nil, err
+	}
+
+	max := t.n
+	if t.bound > 0 {
+		max = t.bound
+	}
+
+	V, err := solveV(XX, XY, t.YX, t.YY, 0, max)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BinaryTallyRes{
+		V:        V,
+		XX:       XX,
+		XY:       XY,
+		YX:       new(big.Int).Set(t.YX),
+		YY:       new(big.Int).Set(t.YY),
+		pub:      pub,
+		partials: used,
+	}
+	res.complete(t)
+	t.evaluate(res)
+
+	return res, nil
+}
+
+// decrypt computes X = H^k, recovers V\in[min, max] from Y = X * g^V and
+// proves the correctness of X in context ctx
+func decrypt(k, HX, HY, YX, YY *big.Int, min, max int, authData *big.Int, ctx []byte) (*BinaryTallyRes, error) {
+	r, err := solve(k, HX, HY, YX, YY, min, max)
 	if err != nil {
 		return nil, err
 	}
-	proof, err := prover.Prove(t.authData)
+	if err := r.prove(k, HX, HY, authData, ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// solve computes X = H^k and recovers V\in[min, max] from Y = X * g^V
+func solve(k, HX, HY, YX, YY *big.Int, min, max int) (*BinaryTallyRes, error) {
+	// X = h^k where h = prod_i g^a_i
+	XX, XY := curve.ScalarMult(HX, HY, k.Bytes())
+
+	V, err := solveV(XX, XY, YX, YY, min, max)
 	if err != nil {
 		return nil, err
 	}
 
 	return &BinaryTallyRes{
-		// new(big.Int).Set(t.gkX), new(big.Int).Set(t.gkY),
-		V,
-		// new(big.Int).Set(t.HX), new(big.Int).Set(t.HY),
-		XX, XY,
-		new(big.Int).Set(t.YX), new(big.Int).Set(t.YY),
-		// append([]byte(nil), t.hashedAuthData...),
-		proof,
+		V:  V,
+		XX: XX, XY: XY,
+		YX: new(big.Int).Set(YX), YY: new(big.Int).Set(YY),
 	}, nil
 }
 
+// prove generates the zkps of X = H^k for the k of g^k, bound to ctx and,
+// unless the result is legacy, to the statement of the result
+func (r *BinaryTallyRes) prove(k, HX, HY, authData *big.Int, ctx []byte) error {
+	if r.ballots != nil {
+		ctx = append(append([]byte(nil), ctx...), r.statement()...)
+	}
+
+	// Generate zkp for proving the correctness of h^k
+	prover, err := zk.NewECFSProver(k, HX, HY)
+	if err != nil {
+		return err
+	}
+	if r.proof, err = prover.ProveInContext(ctx, authData); err != nil {
+		return err
+	}
+
+	// Link X to the authority public key g^k
+	linker, err := zk.NewDLEQProver(k, curve.Params().Gx, curve.Params().Gy, HX, HY)
+	if err != nil {
+		return err
+	}
+	r.link, err = linker.ProveInContext(ctx, authData)
+
+	return err
+}
+
+// complete records the no-count, the number of ballots, H and the
+// commitment to the ballots of t
+func (r *BinaryTallyRes) complete(t *BinaryTally) {
+	root := t.root
+	r.No = t.weight - r.V
+	r.Turnout = t.n
+	r.HX, r.HY = new(big.Int).Set(t.HX), new(big.Int).Set(t.HY)
+	r.ballots = &root
+	if t.excluded != nil {
+		excluded := *t.excluded
+		r.excluded = &excluded
+	}
+}
+
+// statement returns the digest of the counts, H, Y and the commitments to
+// the ballots counted and excluded, which is bound into the tally proofs
+func (r *BinaryTallyRes) statement() []byte {
+	var buf bytes.Buffer
+	for _, v := range []int{r.V, r.No, r.Turnout} {
+		binary.Write(&buf, binary.BigEndian, int64(v))
+	}
+	buf.Write(elliptic.Marshal(curve, r.HX, r.HY))
+	buf.Write(elliptic.Marshal(curve, r.YX, r.YY))
+	buf.Write(r.ballots[:])
+	if r.excluded != nil {
+		buf.Write(r.excluded[:])
+	}
+
+	h := sha256.Sum256(buf.Bytes())
+	return h[:]
+}
+
+// solveV recovers V\in[min, max] from Y = X * g^V
+func solveV(XX, XY, YX, YY *big.Int, min, max int) (int, error) {
+	// g^{V - min} = Y / X / g^min
+	iXX, iXY := ecinv(XX, XY)
+	gVX, gVY := curve.Add(YX, YY, iXX, iXY)
+	if min != 0 {
+		gMinX, gMinY := curve.ScalarBaseMult(big.NewInt(int64(min)).Bytes())
+		if min > 0 {
+			gMinX, gMinY = ecinv(gMinX, gMinY)
+		}
+		gVX, gVY = curve.Add(gVX, gVY, gMinX, gMinY)
+	}
+
+	v, err := dlogSolver.Solve(gVX, gVY, uint64(max-min))
+	if err != nil {
+		return 0, errors.New("Tally failed")
+	}
+
+	return int(v) + min, nil
+}
+
 // Verify verifies tally result
+//
+// A result combined from partial decryptions is rejected: the partial
+// decryptions are computed before the result and don't bind its counts and
+// commitments, which are only checked by VerifyBallots or VerifyAggregate.
 func (r *BinaryTallyRes) Verify() error {
+	if r.proof == nil {
+		return errors.New("Result not bound to zkp")
+	}
 	return r.verify()
 }
 
+// VerifyBallots fully verifies the result against the ballots it is claimed
+// to be computed from. Besides Verify, it re-validates the ballots,
+// recomputes H and Y, and checks that X is decrypted with the key of g^k,
+// the number of ballots counted and whether the result passes. If the
+// result excludes invalid ballots, the ballots are those given to
+// NewBinaryTallyExcluding and the excluded ones must match the commitment.
+// workers is the number of goroutines verifying the ballots, as in
+// NewBinaryTallyWithParams.
+func (r *BinaryTallyRes) VerifyBallots(p *ElectionParams, ballots []*BinaryBallot, workers int) error {
+	var (
+		t   *BinaryTally
+		err error
+	)
+	if r.excluded != nil {
+		t, _, err = NewBinaryTallyExcluding(p, ballots, workers)
+	} else {
+		t, err = NewBinaryTallyWithParams(p, ballots, workers)
+	}
+	if err != nil {
+		return err
+	}
+	return r.verifyTally(t)
+}
+
+// ExcludesInvalid returns whether the tally excluded invalid ballots
+// instead of failing, see NewBinaryTallyExcluding
+func (r *BinaryTallyRes) ExcludesInvalid() bool {
+	return r.excluded != nil
+}
+
+// VerifyAggregate fully verifies the result against an aggregate of the
+// ballots, as VerifyBallots does
+func (r *BinaryTallyRes) VerifyAggregate(p *ElectionParams, a *Aggregate) error {
+	t, err := NewBinaryTallyFromAggregate(p, a)
+	if err != nil {
+		return err
+	}
+	return r.verifyTally(t)
+}
+
+// verifyTally checks that the result is the decryption of the aggregates of
+// t by the authority of t and evaluated by the rules of t
+func (r *BinaryTallyRes) verifyTally(t *BinaryTally) error {
+	if err := r.verifyDecryption(t); err != nil {
+		return err
+	}
+
+	quorum, passed := t.params.evaluate(t.n, t.weight, r.V)
+	if r.Turnout != t.n || r.QuorumMet != quorum || r.Passed != passed {
+		return errors.New("Invalid quorum or pass result")
+	}
+
+	return nil
+}
+
+// verifyDecryption checks that the result is the decryption of the
+// aggregates of t by the authority of t
+func (r *BinaryTallyRes) verifyDecryption(t *BinaryTally) error {
+	if err := r.verify(); err != nil {
+		return err
+	}
+	if err := r.checkContext(t.params.context()); err != nil {
+		return err
+	}
+
+	if r.YX.Cmp(t.YX) != 0 || r.YY.Cmp(t.YY) != 0 {
+		return errors.New("Y doesn't match ballots")
+	}
+	HX, HY := r.base()
+	if HX.Cmp(t.HX) != 0 || HY.Cmp(t.HY) != 0 {
+		return errors.New("H doesn't match ballots")
+	}
+	if r.ballots != nil {
+		if *r.ballots != t.root {
+			return errors.New("Ballots don't match commitment")
+		}
+		if r.No != t.weight-r.V || r.Turnout != t.n {
+			return errors.New("Counts don't match ballots")
+		}
+	}
+	if !sameRoot(r.excluded, t.excluded) {
+		return errors.New("Excluded ballots don't match commitment")
+	}
+	return r.verifyKey(t.gkX, t.gkY)
+}
+
+// base returns the H decrypted to X
+func (r *BinaryTallyRes) base() (*big.Int, *big.Int) {
+	if r.proof != nil {
+		return r.proof.Base()
+	}
+	_, _, HX, HY := r.partials[0].proof.Bases()
+	return HX, HY
+}
+
+// verifyKey checks that X is decrypted with the private key of g^k
+func (r *BinaryTallyRes) verifyKey(gkX, gkY *big.Int) error {
+	if r.proof == nil {
+		if x, y := r.pub.PublicKey(); x.Cmp(gkX) != 0 || y.Cmp(gkY) != 0 {
+			return errors.New("Threshold key doesn't match g^k")
+		}
+		return nil
+	}
+
+	if r.link == nil {
+		return errors.New("Missing zkp of decryption key")
+	}
+	g1X, g1Y, g2X, g2Y := r.link.Bases()
+	y1X, y1Y, y2X, y2Y := r.link.Values()
+	HX, HY := r.proof.Base()
+	if g1X.Cmp(curve.Params().Gx) != 0 || g1Y.Cmp(curve.Params().Gy) != 0 ||
+		g2X.Cmp(HX) != 0 || g2Y.Cmp(HY) != 0 {
+		return errors.New("Invalid zkp of decryption key")
+	}
+	if y1X.Cmp(gkX) != 0 || y1Y.Cmp(gkY) != 0 {
+		return errors.New("Decryption key doesn't match g^k")
+	}
+	if y2X.Cmp(r.XX) != 0 || y2Y.Cmp(r.XY) != 0 {
+		return errors.New("X doesn't match zkp of decryption key")
+	}
+	if err := checkContext(r.proof.Context(), r.link.Context()); err != nil {
+		return err
+	}
+
+	res, err := r.link.Verify()
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Invalid zkp of decryption key")
+	}
+
+	return nil
+}
+
+// CheckElection checks that the tally proofs are bound to the election
+// described by m, or to none if m is nil
+func (r *BinaryTallyRes) CheckElection(m *Manifest) error {
+	var ctx []byte
+	if m != nil {
+		hash := m.Hash()
+		ctx = hash[:]
+	}
+
+	return r.checkContext(ctx)
+}
+
+// BallotsRoot returns the committed root of the bulletin board of the
+// ballots counted
+func (r *BinaryTallyRes) BallotsRoot() ([32]byte, error) {
+	if r.ballots == nil {
+		return [32]byte{}, errors.New("Legacy result without commitment to ballots")
+	}
+	return *r.ballots, nil
+}
+
+// context returns the election context the tally proofs are bound to
+func (r *BinaryTallyRes) context() []byte {
+	if r.proof != nil {
+		ctx := r.proof.Context()
+		if r.ballots != nil && len(ctx) >= sha256.Size {
+			ctx = ctx[:len(ctx)-sha256.Size]
+		}
+		if len(ctx) == 0 {
+			return nil
+		}
+		return ctx
+	}
+	if len(r.partials) > 0 {
+		return r.partials[0].proof.Context()
+	}
+	return nil
+}
+
+func (r *BinaryTallyRes) checkContext(ctx []byte) error {
+	if r.proof != nil {
+		return checkContext(ctx, r.context())
+	}
+	if len(r.partials) == 0 {
+		return errors.New("Missing zkp")
+	}
+	for _, d := range r.partials {
+		if err := checkContext(ctx, d.proof.Context()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Verify verifies tally result
 func (r *BinaryTallyRes) verify() error {
 	// if !isOnCurve(r.gkX, r.gkY) {
@@ -149,12 +579,25 @@ func (r *BinaryTallyRes) verify() error {
 
 	// Check the correctness of V
 	gVX, gVY := curve.ScalarBaseMult(big.NewInt(int64(r.V)).Bytes())
+	if r.V < 0 {
+		gVX, gVY = ecinv(gVX, gVY)
+	}
 	XgVX, XgVY := curve.Add(r.XX, r.XY, gVX, gVY)
 
 	if XgVX.Cmp(r.YX) != 0 || XgVY.Cmp(r.YY) != 0 {
 		return errors.New("Y != X * g^v")
 	}
 
+	if r.ballots != nil {
+		if err := r.verifyStatement(); err != nil {
+			return err
+		}
+	}
+
+	if r.proof == nil {
+		return r.verifyPartials()
+	}
+
 	// Verify zkp
 	res, err := r.proof.Verify()
 	if err != nil {
@@ -167,29 +610,112 @@ func (r *BinaryTallyRes) verify() error {
 	return nil
 }
 
+// verifyStatement checks the no-count and that H and the statement of the
+// result are bound into the tally proof
+func (r *BinaryTallyRes) verifyStatement() error {
+	if r.No < 0 || r.Turnout < 0 {
+		return errors.New("Invalid counts")
+	}
+	if r.HX == nil || r.HY == nil || !isOnCurve(r.HX, r.HY) {
+		return errors.New("Invalid h = prod_i g^a_i")
+	}
+	if r.proof == nil && len(r.partials) == 0 {
+		return errors.New("Missing zkp")
+	}
+
+	HX, HY := r.base()
+	if HX.Cmp(r.HX) != 0 || HY.Cmp(r.HY) != 0 {
+		return errors.New("H doesn't match zkp")
+	}
+
+	// partial decryptions are computed before the result and can't be
+	// bound to it, its statement is checked against the ballots instead
+	if r.proof == nil {
+		return nil
+	}
+	ctx := r.proof.Context()
+	if len(ctx) < sha256.Size || !bytes.Equal(ctx[len(ctx)-sha256.Size:], r.statement()) {
+		return errors.New("Result not bound to zkp")
+	}
+
+	return nil
+}
+
+// verifyPartials checks that X is combined from valid partial decryptions
+func (r *BinaryTallyRes) verifyPartials() error {
+	if r.pub == nil || len(r.partials) == 0 {
+		return errors.New("Missing zkp")
+	}
+
+	// all partial decryptions are computed for the same H
+	_, _, HX, HY := r.partials[0].proof.Bases()
+
+	XX, XY, _, err := combinePartials(r.pub, HX, HY, r.partials)
+	if err != nil {
+		return err
+	}
+	if XX.Cmp(r.XX) != 0 || XY.Cmp(r.XY) != 0 {
+		return errors.New("X doesn't match partial decryptions")
+	}
+
+	return nil
+}
+
 func (r *BinaryTallyRes) String() (string, string) {
+	if r.proof == nil {
+		return fmt.Sprintf("No. YES = %d", r.V), fmt.Sprintf("%d partial decryptions", len(r.partials))
+	}
 	return fmt.Sprintf("No. YES = %d", r.V), r.proof.String()
 }
 
 // BuildJSONBinaryTallyRes builds json object
 func (r *BinaryTallyRes) BuildJSONBinaryTallyRes() *JSONBinaryTallyRes {
-	_p := r.proof.BuildJSONJSONECFSProof()
-
-	return &JSONBinaryTallyRes{
+	obj := &JSONBinaryTallyRes{
 		V:  r.V,
 		XX: common.BigIntToHexStr(r.XX),
 		XY: common.BigIntToHexStr(r.XY),
 		YX: common.BigIntToHexStr(r.YX),
 		YY: common.BigIntToHexStr(r.YY),
-		Proof: &JSONCompressedECFSProof{
+
+		Turnout:   r.Turnout,
+		QuorumMet: r.QuorumMet,
+		Passed:    r.Passed,
+	}
+
+	if r.ballots != nil {
+		obj.No = r.No
+		obj.HX = common.BigIntToHexStr(r.HX)
+		obj.HY = common.BigIntToHexStr(r.HY)
+		obj.Ballots = merkle.HashToHexStr(*r.ballots)
+	}
+	if r.excluded != nil {
+		obj.Excluded = merkle.HashToHexStr(*r.excluded)
+	}
+
+	if r.proof != nil {
+		_p := r.proof.BuildJSONJSONECFSProof()
+		obj.Proof = &JSONCompressedECFSProof{
 			Data: _p.Data,
 			HX:   _p.HX,
 			HY:   _p.HY,
 			TX:   _p.TX,
 			TY:   _p.TY,
 			R:    _p.R,
-		},
+			Ctx:  _p.Ctx,
+		}
 	}
+
+	if r.link != nil {
+		obj.Link = r.link.BuildJSONDLEQProof()
+	}
+	if r.pub != nil {
+		obj.PubKey = r.pub.BuildJSONThresholdKey()
+	}
+	for _, d := range r.partials {
+		obj.Partials = append(obj.Partials, d.BuildJSONPartialDecryption())
+	}
+
+	return obj
 }
 
 // MarshalJSON implements json marshal
@@ -202,6 +728,35 @@ func (r *BinaryTallyRes) FromJSONBinaryTallyRes(obj *JSONBinaryTallyRes) error {
 	var err error
 
 	r.V = obj.V
+	r.Turnout, r.QuorumMet, r.Passed = obj.Turnout, obj.QuorumMet, obj.Passed
+
+	// legacy results carry neither the no-count, H nor the commitment to
+	// the ballots
+	r.No, r.HX, r.HY, r.ballots = 0, nil, nil, nil
+	if obj.Ballots != "" {
+		root, err := merkle.HexStrToHash(obj.Ballots)
+		if err != nil {
+			return err
+		}
+		if r.HX, err = common.HexStrToBigInt(obj.HX); err != nil {
+			return err
+		}
+		if r.HY, err = common.HexStrToBigInt(obj.HY); err != nil {
+			return err
+		}
+		r.No, r.ballots = obj.No, &root
+	}
+	r.excluded = nil
+	if obj.Excluded != "" {
+		if r.ballots == nil {
+			return errors.New("Excluded ballots in legacy result")
+		}
+		root, err := merkle.HexStrToHash(obj.Excluded)
+		if err != nil {
+			return err
+		}
+		r.excluded = &root
+	}
 
 	// if r.HX, err = common.HexStrToBigInt(obj.Proof.HX); err != nil {
 	// 	return err
@@ -224,6 +779,39 @@ func (r *BinaryTallyRes) FromJSONBinaryTallyRes(obj *JSONBinaryTallyRes) error {
 		return err
 	}
 
+	r.link = nil
+	if obj.Link != nil {
+		r.link = new(zk.DLEQProof)
+		if err := r.link.FromJSONDLEQProof(obj.Link); err != nil {
+			return err
+		}
+	}
+
+	if obj.Proof == nil {
+		r.proof = nil
+		if obj.PubKey == nil {
+			return errors.New("Missing zkp")
+		}
+		r.pub = new(ThresholdKey)
+		if err := r.pub.FromJSONThresholdKey(obj.PubKey); err != nil {
+			return err
+		}
+		if len(obj.Partials) == 0 {
+			return errors.New("Missing partial decryptions")
+		}
+		r.partials = make([]*PartialDecryption, len(obj.Partials))
+		for i, d := range obj.Partials {
+			if d == nil {
+				return errors.New("Missing partial decryption")
+			}
+			r.partials[i] = new(PartialDecryption)
+			if err := r.partials[i].FromJSONPartialDecryption(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	if r.proof == nil {
 		r.proof = new(zk.ECFSProof)
 	}
@@ -236,6 +824,7 @@ func (r *BinaryTallyRes) FromJSONBinaryTallyRes(obj *JSONBinaryTallyRes) error {
 		TX:   _p.TX,
 		TY:   _p.TY,
 		R:    _p.R,
+		Ctx:  _p.Ctx,
 
 		YX: obj.XX,
 		YY: obj.XY,
@@ -1,19 +1,46 @@
 package vote
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
 )
 
 // BinaryVote structure
+//
+// A BinaryVote is safe for concurrent use. Ballots are verified without
+// holding the lock so that concurrent casts verify them in parallel.
 type BinaryVote struct {
+	mu *sync.RWMutex // guards the fields below, kept by FromJSONBinaryVote
+
 	gkX, gkY *big.Int // authority's public key
 	authData *big.Int // address of authorty
 
-	// minVoter, maxVoter uint // min and max number of votes
+	params *ElectionParams
 
 	ballots map[[32]byte]*BinaryBallot // binary ballots
+	voters  map[[32]byte]*big.Int      // data of the voters who cast ballots
+	revoteLog
+	lifecycle
+
+	store BallotStore // log of cast ballots, optional
+	head  StoreHead   // last entry in store
+
+	receiptKey *ecdsa.PrivateKey // key signing the receipts, optional
+	castLog    *merkle.Tree      // append-only log of counted casts, nil if none
+	casts      []ballotLeaf      // entries of the cast log in order
+
+	spoiled map[[32]byte]bool // ids of the randomness of spoiled ballots
 
 	HX, HY *big.Int // H = prod_i g^a_i = prod_i x_i
 	YX, YY *big.Int // Y = prod_i y_i
@@ -21,110 +48,614 @@ type BinaryVote struct {
 	res *BinaryTallyRes
 }
 
-// NewBinaryVote news a yes-or-no vote
+// NewBinaryVote news a yes-or-no vote. The vote starts in registration and
+// must be opened before ballots can be cast.
 func NewBinaryVote(gkX, gkY *big.Int, authData *big.Int) (*BinaryVote, error) {
 	if !isOnCurve(gkX, gkY) {
 		return nil, errors.New("Invalid g^k")
 	}
 
 	vote := new(BinaryVote)
-
-	// if maxVoter == 0 || minVoter > maxVoter {
-	// 	return nil, errors.New("Invalid voter number setting")
-	// }
-	// vote.maxVoter = maxVoter
-	// vote.minVoter = minVoter
+	vote.mu = new(sync.RWMutex)
 
 	vote.gkX = new(big.Int).Set(gkX)
 	vote.gkY = new(big.Int).Set(gkY)
 
 	vote.authData = new(big.Int).Set(authData)
 	vote.ballots = make(map[[32]byte]*BinaryBallot)
+	vote.voters = make(map[[32]byte]*big.Int)
+	vote.spoiled = make(map[[32]byte]bool)
+	vote.revoteLog = newRevoteLog()
+	vote.lifecycle = newLifecycle()
 
 	vote.HX = big.NewInt(0)
 	vote.HY = big.NewInt(0)
 	vote.YX = big.NewInt(0)
 	vote.YY = big.NewInt(0)
 
+	var err error
+	if vote.params, err = NewElectionParams(gkX, gkY, authData); err != nil {
+		return nil, err
+	}
+
+	return vote, nil
+}
+
+// NewBinaryVoteWithParams news a yes-or-no vote with election parameters.
+// If a voter registry is set, only ballots carrying a valid eligibility
+// proof can be cast and each is counted with the voter's weight. If a ring
+// is set, only ballots with a valid ring signature can be cast, each by the
+// voter identified by the data bound to it.
+func NewBinaryVoteWithParams(p *ElectionParams) (*BinaryVote, error) {
+	vote, err := NewBinaryVote(p.gkX, p.gkY, p.authData)
+	if err != nil {
+		return nil, err
+	}
+	vote.params = p
+
+	return vote, nil
+}
+
+// NewBinaryVoteWithManifest news a yes-or-no vote for the election
+// described by m. Only ballots bound to the election are accepted and the
+// voting period is taken from the manifest.
+func NewBinaryVoteWithManifest(m *Manifest, authData *big.Int) (*BinaryVote, error) {
+	p, err := m.Params(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	vote, err := NewBinaryVoteWithParams(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := vote.SetSchedule(m.Start, m.End); err != nil {
+		return nil, err
+	}
+
 	return vote, nil
 }
 
-// NewBinaryTally creates a tally
-func (v *BinaryVote) newBinaryTally() *BinaryTally {
+// AttachStore replays the ballots in s and logs to s the ballots cast
+// afterwards. The re-vote policy and parameters must be those of the vote
+// that filled s. If head is given, s must extend it, which detects
+// truncation. It must be called before any ballot is cast and the vote
+// must be discarded if it fails.
+func (v *BinaryVote) AttachStore(s BallotStore, head *StoreHead) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.phase != Registration && v.phase != Voting {
+		return errors.New("Vote closed")
+	}
+	if v.seq > 0 || v.store != nil {
+		return errors.New("Ballots already cast")
+	}
+
+	entries, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if err := verifyChain(entries, head); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.Spoiled != nil {
+			if err := e.Spoiled.Audit(); err != nil {
+				return err
+			}
+			if err := v.spoil(e.Spoiled, false); err != nil {
+				return err
+			}
+			v.head = StoreHead{e.Seq, e.Hash()}
+			continue
+		}
+
+		b, ok := e.Ballot.(*BinaryBallot)
+		if !ok {
+			return errors.New("Invalid ballot type")
+		}
+		if err := v.cast(b, e.Data, false); err != nil && err != ErrRevote {
+			return err
+		}
+		v.head = StoreHead{e.Seq, e.Hash()}
+	}
+	v.store = s
+
+	return nil
+}
+
+// Head returns the head of the attached store
+func (v *BinaryVote) Head() StoreHead {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.head
+}
+
+// Phase returns the current phase
+func (v *BinaryVote) Phase() Phase {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.lifecycle.Phase()
+}
+
+// SetClock replaces the clock against which the voting period is checked,
+// time.Now by default
+func (v *BinaryVote) SetClock(now func() time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.lifecycle.SetClock(now)
+}
+
+// SetSchedule sets the voting period [start, end), see lifecycle
+func (v *BinaryVote) SetSchedule(start, end time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lifecycle.SetSchedule(start, end)
+}
+
+// Schedule returns the voting period
+func (v *BinaryVote) Schedule() (time.Time, time.Time) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.lifecycle.Schedule()
+}
+
+// Open ends registration and starts accepting ballots
+func (v *BinaryVote) Open() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lifecycle.Open()
+}
+
+// Close stops accepting ballots, see lifecycle
+func (v *BinaryVote) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lifecycle.Close()
+}
+
+// SetRevotePolicy sets the re-vote policy, see revoteLog
+func (v *BinaryVote) SetRevotePolicy(p RevotePolicy) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.revoteLog.SetRevotePolicy(p)
+}
+
+// RevotePolicy returns the re-vote policy
+func (v *BinaryVote) RevotePolicy() RevotePolicy {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.revoteLog.RevotePolicy()
+}
+
+// History returns the ballots replaced by re-votes
+func (v *BinaryVote) History() []*Replacement {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.revoteLog.History()
+}
+
+// newBinaryTally creates a tally
+func (v *BinaryVote) newBinaryTally() (*BinaryTally, error) {
+	var w uint64
+	for _, b := range v.ballots {
+		weight, err := v.params.checkBallot(b)
+		if err != nil {
+			return nil, err
+		}
+		w = w + weight
+	}
+
 	return &BinaryTally{
-		new(big.Int).Set(v.gkX), new(big.Int).Set(v.gkY),
-		new(big.Int).Set(v.authData),
-		new(big.Int).Set(v.HX), new(big.Int).Set(v.HY),
-		new(big.Int).Set(v.YX), new(big.Int).Set(v.YY),
-		len(v.ballots),
+		gkX:      new(big.Int).Set(v.gkX),
+		gkY:      new(big.Int).Set(v.gkY),
+		authData: new(big.Int).Set(v.authData),
+		HX:       new(big.Int).Set(v.HX),
+		HY:       new(big.Int).Set(v.HY),
+		YX:       new(big.Int).Set(v.YX),
+		YY:       new(big.Int).Set(v.YY),
+		n:        len(v.ballots),
+		weight:   int(w),
+		bound:    int(w),
+		root:     ballotsRoot(v.leaves()),
+		params:   v.params,
+	}, nil
+}
+
+// leaves returns the bulletin board entries of the counted ballots
+func (v *BinaryVote) leaves() []ballotLeaf {
+	leaves := make([]ballotLeaf, 0, len(v.ballots))
+	for id, b := range v.ballots {
+		leaves = append(leaves, ballotLeaf{voterID(b), v.counted[id]})
 	}
+	return leaves
+}
+
+// Board returns the bulletin board of the counted ballots
+func (v *BinaryVote) Board() (*Board, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if len(v.ballots) == 0 {
+		return nil, errors.New("No ballots")
+	}
+	return newBoard(v.leaves()), nil
+}
+
+// SetReceiptKey sets the key signing the receipts returned by
+// CastWithReceipt
+func (v *BinaryVote) SetReceiptKey(k *ecdsa.PrivateKey) error {
+	if k == nil || !isInRange(k.D) {
+		return errors.New("Invalid receipt key")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.receiptKey = k
+	return nil
 }
 
 // Cast casts a ballot
 func (v *BinaryVote) Cast(bt Ballot, data *big.Int) error {
+	b, w, err := v.verifyCast(bt, data)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.applyCast(b, data, w)
+}
+
+// CastWithReceipt casts a ballot and returns the signed receipt of its
+// inclusion in the cast log. It fails if the ballot isn't counted, e.g.,
+// ignored under FirstWins.
+func (v *BinaryVote) CastWithReceipt(bt Ballot, data *big.Int) (*Receipt, error) {
+	b, w, err := v.verifyCast(bt, data)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.receiptKey == nil {
+		return nil, errors.New("No receipt key")
+	}
+	if err := v.applyCast(b, data, w); err != nil {
+		return nil, err
+	}
+	return v.receipt(b, data)
+}
+
+// receipt returns the receipt of ballot b just applied for the voter
+// identified by data. The lock must be held.
+func (v *BinaryVote) receipt(b *BinaryBallot, data *big.Int) (*Receipt, error) {
+	id := sha256.Sum256(data.Bytes())
+	if v.ballots[id] != b {
+		return nil, errors.New("Ballot not counted")
+	}
+
+	// the ballot is the last entry of the cast log
+	l := ballotLeaf{voterID(b), v.counted[id]}
+	return newReceipt(v.castLog, v.castLog.Size()-1, l, v.receiptKey)
+}
+
+// CastLog returns the root and size of the cast log, the append-only log of
+// the counted casts in order, zero if nothing is cast
+func (v *BinaryVote) CastLog() ([32]byte, int) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.castLog == nil {
+		return [32]byte{}, 0
+	}
+	return v.castLog.Root(), v.castLog.Size()
+}
+
+// ProveCast returns the inclusion proof of the i-th entry of the cast log in
+// its current root, e.g., to check a receipt against the final root
+func (v *BinaryVote) ProveCast(i int) (*merkle.Proof, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.castLog == nil {
+		return nil, errors.New("Index out of range")
+	}
+	return v.castLog.Prove(i)
+}
+
+// Spoil audits a spoiled ballot and records it so that Cast refuses it. A
+// ballot already counted can't be spoiled. Like ballots, spoiled ones can
+// only be recorded while the vote is open and are appended to the attached
+// store, if any.
+func (v *BinaryVote) Spoil(s *SpoiledBallot) error {
+	if s == nil {
+		return errors.New("Missing ballot")
+	}
+	if err := s.Audit(); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.checkCast(); err != nil {
+		return err
+	}
+	return v.spoil(s, v.store != nil)
+}
+
+// spoil records an audited spoiled ballot, appending it to the store first
+// if log is set
+func (v *BinaryVote) spoil(s *SpoiledBallot, log bool) error {
+	id := spoilID(s.ballot)
+	for _, b := range v.ballots {
+		if spoilID(b) == id {
+			return errors.New("Ballot already cast")
+		}
+	}
+	if log {
+		if err := v.appendEntry(&StoreEntry{Spoiled: s}); err != nil {
+			return err
+		}
+	}
+	v.spoiled[id] = true
+
+	return nil
+}
+
+// appendEntry chains e to the head of the store and appends it
+func (v *BinaryVote) appendEntry(e *StoreEntry) error {
+	e.Seq, e.Prev = v.head.Size+1, v.head.Hash
+	if err := v.store.Append(e); err != nil {
+		return err
+	}
+	v.head = StoreHead{e.Seq, e.Hash()}
+	return nil
+}
+
+// verifyCast checks that a ballot can be cast by the voter identified by
+// data and returns its weight. The proofs are verified without holding the
+// lock.
+func (v *BinaryVote) verifyCast(bt Ballot, data *big.Int) (*BinaryBallot, uint64, error) {
 	b, ok := bt.(*BinaryBallot)
 	if !ok {
-		return errors.New("Invalid ballot type")
+		return nil, 0, errors.New("Invalid ballot type")
+	}
+
+	v.mu.RLock()
+	p, err := v.params, v.checkCast()
+	v.mu.RUnlock()
+	if err != nil {
+		return nil, 0, err
 	}
 
+	w, err := verifyBinaryBallot(p, b, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return b, w, nil
+}
+
+// verifyBinaryBallot verifies a ballot cast by the voter identified by data
+// under parameters p and returns its weight
+func verifyBinaryBallot(p *ElectionParams, b *BinaryBallot, data *big.Int) (uint64, error) {
 	if err := b.VerifyBallot(); err != nil {
+		return 0, err
+	}
+
+	w, err := p.checkBallot(b)
+	if err != nil {
+		return 0, err
+	}
+	if p.uniqueVoters() && b.proof.Data().Cmp(data) != 0 {
+		return 0, errors.New("Ballot not bound to voter")
+	}
+
+	return w, nil
+}
+
+// applyCast counts a ballot verified by verifyCast if it can still be cast,
+// logging it to the attached store. The lock must be held.
+func (v *BinaryVote) applyCast(b *BinaryBallot, data *big.Int, w uint64) error {
+	if err := v.checkCast(); err != nil {
+		return err
+	}
+	return v.apply(b, data, w, v.store != nil)
+}
+
+// cast verifies and counts a ballot, appending it to the store first if log
+// is set
+func (v *BinaryVote) cast(b *BinaryBallot, data *big.Int, log bool) error {
+	w, err := verifyBinaryBallot(v.params, b, data)
+	if err != nil {
 		return err
 	}
+	return v.apply(b, data, w, log)
+}
+
+// apply counts a verified ballot of weight w, appending it to the store
+// first if log is set
+func (v *BinaryVote) apply(b *BinaryBallot, data *big.Int, w uint64, log bool) error {
+	if v.spoiled[spoilID(b)] {
+		return ErrSpoiled
+	}
 
 	id := sha256.Sum256(data.Bytes())
+	if _, ok := v.ballots[id]; !ok {
+		if err := v.params.checkVoters(len(v.ballots) + 1); err != nil {
+			return err
+		}
+	}
+	if log {
+		if err := v.appendEntry(&StoreEntry{Data: data, Ballot: b}); err != nil {
+			return err
+		}
+	}
+	if counted, err := v.admit(id, data, b); err != nil || !counted {
+		return err
+	}
+
 	if old, ok := v.ballots[id]; ok {
-		iOldhX, iOldhY := new(big.Int).Set(old.hX), new(big.Int).Sub(curve.Params().P, old.hY)
+		oldW, _ := v.params.checkBallot(old)
+		hX, hY, yX, yY := old.weighted(oldW)
+
+		iOldhX, iOldhY := ecinv(hX, hY)
 		v.HX, v.HY = curve.Add(v.HX, v.HY, iOldhX, iOldhY)
 
-		iOldyX, iOldyY := new(big.Int).Set(old.yX), new(big.Int).Sub(curve.Params().P, old.yY)
+		iOldyX, iOldyY := ecinv(yX, yY)
 		v.YX, v.YY = curve.Add(v.YX, v.YY, iOldyX, iOldyY)
 	}
-	// else {
-	// 	if uint(len(v.ballots)) >= v.maxVoter {
-	// 		return errors.New("Max number of voters reached")
-	// 	}
-	// }
 
-	v.HX, v.HY = curve.Add(v.HX, v.HY, b.hX, b.hY)
-	v.YX, v.YY = curve.Add(v.YX, v.YY, b.yX, b.yY)
+	hX, hY, yX, yY := b.weighted(w)
+	v.HX, v.HY = curve.Add(v.HX, v.HY, hX, hY)
+	v.YX, v.YY = curve.Add(v.YX, v.YY, yX, yY)
 
 	v.ballots[id] = b
+	v.voters[id] = new(big.Int).Set(data)
+	v.appendCast(ballotLeaf{voterID(b), v.counted[id]})
 
 	return nil
 }
 
-// Tally tallies the voting results
+// appendCast appends a counted cast to the cast log
+func (v *BinaryVote) appendCast(l ballotLeaf) {
+	v.casts = append(v.casts, l)
+	if v.castLog == nil {
+		v.castLog, _ = merkle.NewTree([][32]byte{l.hash()})
+		return
+	}
+	v.castLog.Append(l.hash())
+}
+
+// checkCastLog checks that the entries of a restored cast log are the
+// ballots counted in turn for each voter, i.e., the first ballot and the
+// replacing ones in the history ending with the counted ballot, in the
+// order of the history
+func (v *BinaryVote) checkCastLog(casts []ballotLeaf) error {
+	chains := make(map[[32]byte][][32]byte) // voter => hashes of the ballots counted in turn
+	seqs := make(map[[32]byte][]int)        // voter => seq of each replacing ballot
+	for _, r := range v.history {
+		if r.Seq <= 0 || r.Seq > v.seq {
+			return errors.New("Invalid sequence number")
+		}
+		if !r.Replaced {
+			continue
+		}
+		id := sha256.Sum256(r.Data.Bytes())
+		if _, ok := v.ballots[id]; !ok {
+			return errors.New("Replacement of unknown voter")
+		}
+		c := chains[id]
+		if len(c) == 0 {
+			c = [][32]byte{r.Old}
+		} else if c[len(c)-1] != r.Old {
+			return errors.New("Inconsistent replacement history")
+		}
+		chains[id] = append(c, r.New)
+		seqs[id] = append(seqs[id], r.Seq)
+	}
+
+	n := 0
+	owners := make(map[[32]byte][][32]byte) // ballot hash => voters counting it
+	for id := range v.ballots {
+		c := chains[id]
+		if len(c) == 0 {
+			c = [][32]byte{v.counted[id]}
+			chains[id] = c
+		} else if c[len(c)-1] != v.counted[id] {
+			return errors.New("Replacement history doesn't match ballots")
+		}
+		for _, h := range c {
+			owners[h] = append(owners[h], id)
+		}
+		n = n + len(c)
+	}
+	if len(casts) != n {
+		return errors.New("Cast log doesn't match ballots")
+	}
+
+	next := make(map[[32]byte]int)
+	last := 0
+	for i, l := range casts {
+		found := false
+		for _, id := range owners[l.ballot] {
+			j := next[id]
+			c := chains[id]
+			if j >= len(c) || c[j] != l.ballot {
+				continue
+			}
+			// the voter id of a replaced ballot is only known if ballots
+			// are bound to their voters
+			if j == len(c)-1 && l.voter != voterID(v.ballots[id]) ||
+				v.params.uniqueVoters() && l.voter != id {
+				continue
+			}
+			if j > 0 {
+				if seqs[id][j-1] <= last {
+					return fmt.Errorf("Cast log entry [%d] out of order", i)
+				}
+				last = seqs[id][j-1]
+			}
+			next[id] = j + 1
+			found = true
+			break
+		}
+		if !found {
+			return fmt.Errorf("Cast log entry [%d] doesn't match ballots", i)
+		}
+	}
+
+	return nil
+}
+
+// Tally tallies the voting results. The vote must be closed. The result
+// records whether the minimum turnout is reached and whether it passes.
 func (v *BinaryVote) Tally(k *big.Int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.checkTally(); err != nil {
+		return err
+	}
+
 	if !isInRange(k) {
 		return errors.New("Invalid k")
 	}
 
-	// if uint(len(v.ballots)) < v.minVoter {
-	// 	return errors.New("Min number of voters unreached")
-	// }
-
-	t := v.newBinaryTally()
+	t, err := v.newBinaryTally()
+	if err != nil {
+		return err
+	}
 
 	res, err := t.tally(k)
 	if err != nil {
 		return err
 	}
 	v.res = res
+	v.phase = Tallied
 
 	return nil
 }
 
 // VerifyTallyRes verifies the tally results
 func (v *BinaryVote) VerifyTallyRes() error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
 	if v.res == nil {
 		return errors.New("No tally results")
 	}
 
-	if err := v.res.verify(); err != nil {
+	t, err := v.newBinaryTally()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return v.res.verifyTally(t)
 }
 
 // GetAuthPublicKey returns authority public key
@@ -134,5 +665,217 @@ func (v *BinaryVote) GetAuthPublicKey() (*big.Int, *big.Int) {
 
 // GetTallyRes gets the tally result
 func (v *BinaryVote) GetTallyRes() *BinaryTallyRes {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 	return v.res
 }
+
+// BuildJSONBinaryVote builds json object of the vote state including its
+// phase and the counted ballots
+func (v *BinaryVote) BuildJSONBinaryVote() *JSONBinaryVote {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	obj := &JSONBinaryVote{
+		Params:  v.params.BuildJSONElectionParams(),
+		Phase:   v.phase.String(),
+		Policy:  v.policy.String(),
+		Seq:     v.seq,
+		Ballots: []*JSONCastBallot{},
+	}
+	if !v.start.IsZero() {
+		start := v.start
+		obj.Start = &start
+	}
+	if !v.end.IsZero() {
+		end := v.end
+		obj.End = &end
+	}
+
+	ids := make([][32]byte, 0, len(v.ballots))
+	for id := range v.ballots {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	for _, id := range ids {
+		obj.Ballots = append(obj.Ballots, &JSONCastBallot{
+			Data:   common.BigIntToHexStr(v.voters[id]),
+			Ballot: v.ballots[id].BuildJSONBinaryBallot(),
+		})
+	}
+
+	for _, r := range v.history {
+		obj.History = append(obj.History, r.BuildJSONReplacement())
+	}
+	for id := range v.spoiled {
+		obj.Spoiled = append(obj.Spoiled, merkle.HashToHexStr(id))
+	}
+	sort.Strings(obj.Spoiled)
+	for _, l := range v.casts {
+		obj.Log = append(obj.Log, &JSONCastEntry{
+			Voter:  merkle.HashToHexStr(l.voter),
+			Ballot: merkle.HashToHexStr(l.ballot),
+		})
+	}
+	if v.res != nil {
+		obj.Res = v.res.BuildJSONBinaryTallyRes()
+	}
+
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (v *BinaryVote) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.BuildJSONBinaryVote())
+}
+
+// FromJSONBinaryVote restores the vote state from json object. Ballots are
+// verified again and the aggregates recomputed. The clock is reset to
+// time.Now. A vote already in use keeps its lock.
+func (v *BinaryVote) FromJSONBinaryVote(obj *JSONBinaryVote) error {
+	if obj.Params == nil {
+		return errors.New("Missing election parameters")
+	}
+	p := new(ElectionParams)
+	if err := p.FromJSONElectionParams(obj.Params); err != nil {
+		return err
+	}
+
+	v1, err := NewBinaryVoteWithParams(p)
+	if err != nil {
+		return err
+	}
+
+	if v1.phase, err = parsePhase(obj.Phase); err != nil {
+		return err
+	}
+	if obj.Start != nil {
+		v1.start = *obj.Start
+	}
+	if obj.End != nil {
+		v1.end = *obj.End
+	}
+	if v1.policy, err = parseRevotePolicy(obj.Policy); err != nil {
+		return err
+	}
+	v1.seq = obj.Seq
+
+	for _, s := range obj.Spoiled {
+		id, err := merkle.HexStrToHash(s)
+		if err != nil {
+			return err
+		}
+		v1.spoiled[id] = true
+	}
+
+	for _, c := range obj.Ballots {
+		if c == nil || c.Ballot == nil {
+			return errors.New("Missing ballot")
+		}
+
+		data, err := common.HexStrToBigInt(c.Data)
+		if err != nil {
+			return err
+		}
+		b := new(BinaryBallot)
+		if err := b.FromJSONBinaryBallot(c.Ballot); err != nil {
+			return err
+		}
+		w, err := verifyBinaryBallot(p, b, data)
+		if err != nil {
+			return err
+		}
+
+		if v1.spoiled[spoilID(b)] {
+			return ErrSpoiled
+		}
+
+		id := sha256.Sum256(data.Bytes())
+		if _, ok := v1.ballots[id]; ok {
+			return errors.New("Duplicate voter")
+		}
+		if v1.counted[id], err = ballotHash(b); err != nil {
+			return err
+		}
+		v1.ballots[id] = b
+		v1.voters[id] = data
+
+		hX, hY, yX, yY := b.weighted(w)
+		v1.HX, v1.HY = curve.Add(v1.HX, v1.HY, hX, hY)
+		v1.YX, v1.YY = curve.Add(v1.YX, v1.YY, yX, yY)
+	}
+	if err := p.checkVoters(len(v1.ballots)); err != nil {
+		return err
+	}
+	if v1.seq < len(v1.ballots) {
+		return errors.New("Invalid sequence number")
+	}
+
+	for _, o := range obj.History {
+		r := new(Replacement)
+		if err := r.FromJSONReplacement(o); err != nil {
+			return err
+		}
+		v1.history = append(v1.history, r)
+	}
+
+	// the cast log is rebuilt from its entries, which must match the
+	// ballots and the history
+	casts := make([]ballotLeaf, len(obj.Log))
+	for i, e := range obj.Log {
+		if e == nil {
+			return errors.New("Missing cast log entry")
+		}
+		if casts[i].voter, err = merkle.HexStrToHash(e.Voter); err != nil {
+			return err
+		}
+		if casts[i].ballot, err = merkle.HexStrToHash(e.Ballot); err != nil {
+			return err
+		}
+	}
+	if err := v1.checkCastLog(casts); err != nil {
+		return err
+	}
+	for _, l := range casts {
+		v1.appendCast(l)
+	}
+
+	if (obj.Res != nil) != (v1.phase == Tallied) {
+		return errors.New("Tally result doesn't match phase")
+	}
+	if obj.Res != nil {
+		v1.res = new(BinaryTallyRes)
+		if err := v1.res.FromJSONBinaryTallyRes(obj.Res); err != nil {
+			return err
+		}
+		if err := v1.res.verify(); err != nil {
+			return err
+		}
+		if err := v1.res.checkContext(p.context()); err != nil {
+			return err
+		}
+		if v1.res.YX.Cmp(v1.YX) != 0 || v1.res.YY.Cmp(v1.YY) != 0 {
+			return errors.New("Tally result doesn't match ballots")
+		}
+	}
+
+	if v.mu != nil {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v1.mu = v.mu
+	}
+	*v = *v1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (v *BinaryVote) UnmarshalJSON(data []byte) error {
+	var obj JSONBinaryVote
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return v.FromJSONBinaryVote(&obj)
+}
@@ -39,6 +39,7 @@ func TestBinaryVoteCast(t *testing.T) {
 
 	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
 	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
 
 	voterAddr := new(big.Int).SetBytes(getRandAddr())
 
@@ -94,16 +95,25 @@ func TestBinaryVoteTally(t *testing.T) {
 
 	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, new(big.Int).SetBytes(addr))
 	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
 
 	// Cast ballots
 	V := castRandBallots(binaryVote, n, t)
 
 	// Tally
+	assert.Nil(t, binaryVote.Close())
 	err = binaryVote.Tally(k.D)
 	assert.Nil(t, err)
 	assert.Equal(t, binaryVote.res.V, V)
 	err = binaryVote.VerifyTallyRes()
 	assert.Nil(t, err)
+
+	// ballots failing the election parameters aren't counted with weight 0
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, new(big.Int).SetBytes(addr))
+	assert.Nil(t, err)
+	p.SetElection(sha256.Sum256([]byte("other")))
+	binaryVote.params = p
+	assert.NotNil(t, binaryVote.VerifyTallyRes())
 }
 
 func TestBinaryBallotJSON(t *testing.T) {
@@ -135,8 +145,10 @@ func TestBinaryTallyResJSON(t *testing.T) {
 
 	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, new(big.Int).SetBytes(authAddr))
 	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
 
 	castRandBallots(binaryVote, n, t)
+	assert.Nil(t, binaryVote.Close())
 	err = binaryVote.Tally(k.D)
 	assert.Nil(t, err)
 
@@ -151,3 +163,349 @@ func TestBinaryTallyResJSON(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, *res, reconstruct)
 }
+
+func TestBinaryTallyBound(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	var ballots []*BinaryBallot
+	for i := 0; i < 3; i++ {
+		ballots = append(ballots, genBinaryBallot(true, new(big.Int).SetBytes(getRandAddr()), k.PublicKey.X, k.PublicKey.Y, t))
+	}
+
+	tal, err := NewBinaryTally(k.PublicKey.X, k.PublicKey.Y, authAddr, ballots)
+	assert.Nil(t, err)
+
+	// V = 3 is out of bound
+	assert.Nil(t, tal.SetBound(2))
+	_, err = tal.Tally(k.D)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, tal.SetBound(1000))
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.V)
+	assert.Nil(t, res.Verify())
+}
+
+func TestVerifyTallyRes(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+
+	var ballots []*BinaryBallot
+	for _, value := range []bool{true, false, true} {
+		ballots = append(ballots, genBinaryBallot(value, new(big.Int).SetBytes(getRandAddr()), k.PublicKey.X, k.PublicKey.Y, t))
+	}
+
+	tal, err := NewBinaryTallyWithParams(p, ballots, 0)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(res)
+	assert.Nil(t, err)
+	res1 := new(BinaryTallyRes)
+	assert.Nil(t, json.Unmarshal(data, res1))
+	assert.Nil(t, res1.VerifyBallots(p, ballots, 0))
+
+	agg, err := NewAggregate(p, ballots, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, res1.VerifyAggregate(p, agg))
+
+	// a ballot missing, added or encrypted with another key
+	assert.NotNil(t, res1.VerifyBallots(p, ballots[1:], 0))
+	extra := genBinaryBallot(false, new(big.Int).SetBytes(getRandAddr()), k.PublicKey.X, k.PublicKey.Y, t)
+	assert.NotNil(t, res1.VerifyBallots(p, append(ballots[:3:3], extra), 0))
+	k1, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	other := genBinaryBallot(false, new(big.Int).SetBytes(getRandAddr()), k1.PublicKey.X, k1.PublicKey.Y, t)
+	assert.NotNil(t, res1.VerifyBallots(p, append(ballots[:3:3], other), 0))
+
+	// another authority key
+	p1, err := NewElectionParams(k1.PublicKey.X, k1.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, res1.VerifyAggregate(p1, agg))
+
+	// wrong turnout
+	res1.Turnout = 2
+	assert.NotNil(t, res1.Verify())
+	assert.NotNil(t, res1.VerifyBallots(p, ballots, 0))
+	res1.Turnout = 3
+
+	// results without zkp of the decryption key verify only alone
+	var obj JSONBinaryTallyRes
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.Link = nil
+	legacy := new(BinaryTallyRes)
+	assert.Nil(t, legacy.FromJSONBinaryTallyRes(&obj))
+	assert.Nil(t, legacy.Verify())
+	assert.NotNil(t, legacy.VerifyBallots(p, ballots, 0))
+
+	// zkp of another result
+	tal1, err := NewBinaryTallyWithParams(p, ballots[:2], 0)
+	assert.Nil(t, err)
+	res2, err := tal1.Tally(k.D)
+	assert.Nil(t, err)
+	res1.link = res2.link
+	assert.NotNil(t, res1.VerifyBallots(p, ballots, 0))
+}
+
+func TestCompleteTallyRes(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+
+	var ballots []*BinaryBallot
+	for _, value := range []bool{true, false, true} {
+		ballots = append(ballots, genBinaryBallot(value, new(big.Int).SetBytes(getRandAddr()), k.PublicKey.X, k.PublicKey.Y, t))
+	}
+
+	tal, err := NewBinaryTallyWithParams(p, ballots, 0)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res.V)
+	assert.Equal(t, 1, res.No)
+	assert.Equal(t, 3, res.Turnout)
+	assert.Equal(t, tal.HX, res.HX)
+
+	agg, err := NewAggregate(p, ballots, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, agg.Root(), *res.ballots)
+
+	data, err := json.Marshal(res)
+	assert.Nil(t, err)
+	res1 := new(BinaryTallyRes)
+	assert.Nil(t, json.Unmarshal(data, res1))
+	assert.Equal(t, *res, *res1)
+	assert.Nil(t, res1.VerifyBallots(p, ballots, 0))
+
+	// counts, H and commitment are covered by the zkp
+	res1.No = 0
+	assert.NotNil(t, res1.Verify())
+	res1.No = 1
+	res1.ballots = &[32]byte{1}
+	assert.NotNil(t, res1.Verify())
+	res1.ballots = res.ballots
+	res1.HX, res1.HY = ballots[0].hX, ballots[0].hY
+	assert.NotNil(t, res1.Verify())
+
+	// legacy results
+	legacy, err := decrypt(k.D, tal.HX, tal.HY, tal.YX, tal.YY, 0, 3, authAddr, nil)
+	assert.Nil(t, err)
+	tal.evaluate(legacy)
+	data, err = json.Marshal(legacy)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "ballots")
+
+	legacy1 := new(BinaryTallyRes)
+	assert.Nil(t, json.Unmarshal(data, legacy1))
+	assert.Nil(t, legacy1.ballots)
+	assert.Nil(t, legacy1.Verify())
+	assert.Nil(t, legacy1.VerifyBallots(p, ballots, 0))
+}
+
+func TestBinaryVoteRevotePolicy(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+	voterAddr := new(big.Int).SetBytes(getRandAddr())
+
+	ballot1 := genBinaryBallot(true, voterAddr, k.PublicKey.X, k.PublicKey.Y, t)
+	ballot2 := genBinaryBallot(false, voterAddr, k.PublicKey.X, k.PublicKey.Y, t)
+	id := sha256.Sum256(voterAddr.Bytes())
+
+	for _, p := range []RevotePolicy{LastWins, FirstWins, Reject} {
+		binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+		assert.Nil(t, err)
+		assert.Nil(t, binaryVote.Open())
+		assert.Nil(t, binaryVote.SetRevotePolicy(p))
+
+		assert.Nil(t, binaryVote.Cast(ballot1, voterAddr))
+		assert.Empty(t, binaryVote.History())
+
+		// policy can't be changed once ballots are cast
+		assert.NotNil(t, binaryVote.SetRevotePolicy(LastWins))
+
+		err = binaryVote.Cast(ballot2, voterAddr)
+		switch p {
+		case LastWins:
+			assert.Nil(t, err)
+			assert.Equal(t, ballot2, binaryVote.ballots[id])
+			assert.Equal(t, ballot2.hX, binaryVote.HX)
+		case FirstWins:
+			assert.Nil(t, err)
+			assert.Equal(t, ballot1, binaryVote.ballots[id])
+			assert.Equal(t, ballot1.hX, binaryVote.HX)
+		case Reject:
+			assert.Equal(t, ErrRevote, err)
+			assert.Equal(t, ballot1, binaryVote.ballots[id])
+		}
+
+		h1, _ := ballotHash(ballot1)
+		h2, _ := ballotHash(ballot2)
+		history := binaryVote.History()
+		assert.Equal(t, 1, len(history))
+		assert.Equal(t, 2, history[0].Seq)
+		assert.Equal(t, voterAddr, history[0].Data)
+		assert.Equal(t, h1, history[0].Old)
+		assert.Equal(t, h2, history[0].New)
+		assert.Equal(t, p == LastWins, history[0].Replaced)
+
+		_, err = json.Marshal(history)
+		assert.Nil(t, err)
+
+		// invalid ballots are not recorded
+		obj := ballot1.BuildJSONBinaryBallot()
+		obj.YX, obj.YY = ballot2.BuildJSONBinaryBallot().YX, ballot2.BuildJSONBinaryBallot().YY
+		ballot3 := new(BinaryBallot)
+		assert.Nil(t, ballot3.FromJSONBinaryBallot(obj))
+		assert.NotNil(t, binaryVote.Cast(ballot3, voterAddr))
+		assert.Equal(t, 1, len(binaryVote.History()))
+	}
+}
+
+func TestBinaryVoteRestore(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, p.SetVoterLimits(0, 2))
+	binaryVote, err := NewBinaryVoteWithParams(p)
+	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
+
+	// the first voter votes three times, the second one once in between
+	addr1 := new(big.Int).SetBytes(getRandAddr())
+	addr2 := new(big.Int).SetBytes(getRandAddr())
+	for i, addr := range []*big.Int{addr1, addr2, addr1, addr1} {
+		b := genBinaryBallot(i%2 == 0, addr, k.PublicKey.X, k.PublicKey.Y, t)
+		assert.Nil(t, binaryVote.Cast(b, addr))
+	}
+	root, n := binaryVote.CastLog()
+	assert.Equal(t, 4, n)
+
+	data, err := json.Marshal(binaryVote)
+	assert.Nil(t, err)
+	restored := new(BinaryVote)
+	assert.Nil(t, json.Unmarshal(data, restored))
+	root1, n1 := restored.CastLog()
+	assert.Equal(t, root, root1)
+	assert.Equal(t, n, n1)
+
+	tamper := func(f func(obj *JSONBinaryVote)) error {
+		var obj JSONBinaryVote
+		assert.Nil(t, json.Unmarshal(data, &obj))
+		f(&obj)
+		return new(BinaryVote).FromJSONBinaryVote(&obj)
+	}
+	assert.Nil(t, tamper(func(obj *JSONBinaryVote) {}))
+
+	// entries dropped, reordered or forged
+	assert.NotNil(t, tamper(func(obj *JSONBinaryVote) { obj.Log = obj.Log[:3] }))
+	assert.NotNil(t, tamper(func(obj *JSONBinaryVote) { obj.Log = nil }))
+	assert.NotNil(t, tamper(func(obj *JSONBinaryVote) {
+		obj.Log[2], obj.Log[3] = obj.Log[3], obj.Log[2]
+	}))
+	assert.NotNil(t, tamper(func(obj *JSONBinaryVote) {
+		obj.Log[3].Ballot = obj.Log[1].Ballot
+	}))
+	assert.NotNil(t, tamper(func(obj *JSONBinaryVote) {
+		obj.Log[3].Voter = obj.Log[1].Voter
+	}))
+
+	// replacements that don't lead to the counted ballot
+	assert.NotNil(t, tamper(func(obj *JSONBinaryVote) { obj.History = obj.History[1:] }))
+
+	// more voters than allowed
+	assert.NotNil(t, tamper(func(obj *JSONBinaryVote) { obj.Params.MaxVoters = 1 }))
+}
+
+func TestBinaryVoteQuorum(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, p.SetVoterLimits(4, 3))
+	assert.Nil(t, p.SetVoterLimits(3, 4))
+	assert.NotNil(t, p.SetPassRule(0, 3, 2))
+	assert.Nil(t, p.SetPassRule(2, 2, 3))
+
+	data, err := json.Marshal(p)
+	assert.Nil(t, err)
+	var p1 ElectionParams
+	assert.Nil(t, json.Unmarshal(data, &p1))
+	assert.Equal(t, *p, p1)
+
+	binaryVote, err := NewBinaryVoteWithParams(p)
+	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
+
+	// yes, yes, no, yes
+	var ballots []*BinaryBallot
+	for i, value := range []bool{true, true, false, true} {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		b := genBinaryBallot(value, addr, k.PublicKey.X, k.PublicKey.Y, t)
+		assert.Nil(t, binaryVote.Cast(b, addr))
+		ballots = append(ballots, b)
+
+		// quorum unreached with two ballots
+		if i == 1 {
+			tal, err := NewBinaryTallyWithParams(p, ballots, 0)
+			assert.Nil(t, err)
+			res, err := tal.Tally(k.D)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, res.Turnout)
+			assert.False(t, res.QuorumMet)
+			assert.False(t, res.Passed)
+		}
+	}
+
+	// max number of voters reached
+	addr := new(big.Int).SetBytes(getRandAddr())
+	assert.NotNil(t, binaryVote.Cast(genBinaryBallot(true, addr, k.PublicKey.X, k.PublicKey.Y, t), addr))
+	_, err = NewBinaryTallyWithParams(p, append(ballots, ballots[0]), 0)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, binaryVote.Close())
+	assert.Nil(t, binaryVote.Tally(k.D))
+	res := binaryVote.GetTallyRes()
+	assert.Equal(t, 3, res.V)
+	assert.Equal(t, 4, res.Turnout)
+	assert.True(t, res.QuorumMet)
+	assert.True(t, res.Passed)
+	assert.Nil(t, binaryVote.VerifyTallyRes())
+
+	data, err = json.Marshal(res)
+	assert.Nil(t, err)
+	var res1 BinaryTallyRes
+	assert.Nil(t, json.Unmarshal(data, &res1))
+	assert.Equal(t, *res, res1)
+
+	res.Passed = false
+	assert.NotNil(t, binaryVote.VerifyTallyRes())
+
+	// 2 of 3 yes votes pass 2/3 but not 3/4
+	tal, err := NewBinaryTallyWithParams(p, ballots[1:], 0)
+	assert.Nil(t, err)
+	r, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.True(t, r.Passed)
+
+	assert.Nil(t, p.SetPassRule(0, 3, 4))
+	r, err = tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.True(t, r.QuorumMet)
+	assert.False(t, r.Passed)
+
+	// simple majority by default
+	assert.Nil(t, p.SetPassRule(0, 0, 0))
+	r, err = tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.True(t, r.Passed)
+}
@@ -0,0 +1,363 @@
+package vote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// CumulativeBallot - ballot that distributes a budget of B points across m
+// candidates
+//
+// Each allocation v_i is encrypted as (g^{a_i}, g^{a_i*k} * g^{v_i}) and comes
+// with a range proof of v_i\in[0, 2^l) where l is the bit length of B. The
+// product of all ciphertexts encrypts sum_i v_i, which is proved to be B. If
+// the budget need not be spent exactly, the unspent points are encrypted and
+// range-proved as an extra slack allocation.
+type CumulativeBallot struct {
+	budget uint64
+	allocs []*zk.RangeProof // encrypted allocation for each candidate
+	slack  *zk.RangeProof   // encrypted unspent points, nil if the budget is spent exactly
+	sum    *zk.DLEQProof    // proves that allocs and slack add up to budget
+}
+
+// NewCumulativeBallot generates a cumulative ballot
+//
+// allocs contains the points given to each candidate. If exact is true,
+// allocs must add up to budget; otherwise they must add up to at most budget.
+// data contains the data (e.g., account address) that identifies the voter.
+func NewCumulativeBallot(allocs []uint64, budget uint64, exact bool, gkX, gkY *big.Int, data *big.Int) (*CumulativeBallot, error) {
+	return newCumulativeBallot(allocs, budget, exact, gkX, gkY, data, nil)
+}
+
+// NewCumulativeBallotInElection generates a cumulative ballot bound to the
+// election described by m
+func NewCumulativeBallotInElection(m *Manifest, allocs []uint64, budget uint64, exact bool, data *big.Int) (*CumulativeBallot, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash := m.Hash()
+	return newCumulativeBallot(allocs, budget, exact, m.GKX, m.GKY, data, hash[:])
+}
+
+func newCumulativeBallot(allocs []uint64, budget uint64, exact bool, gkX, gkY *big.Int, data *big.Int, ctx []byte) (*CumulativeBallot, error) {
+	if !isOnCurve(gkX, gkY) {
+		return nil, errors.New("Invalid g^k")
+	}
+
+	if len(allocs) == 0 {
+		return nil, errors.New("No candidates")
+	}
+
+	if budget == 0 {
+		return nil, errors.New("Invalid budget")
+	}
+
+	var total uint64
+	for _, v := range allocs {
+		if v > budget-total {
+			return nil, errors.New("Budget exceeded")
+		}
+		total = total + v
+	}
+	if exact && total != budget {
+		return nil, errors.New("Budget must be spent exactly")
+	}
+
+	// Set global ECC var for zk package
+	zk.SetEllipticCurve(curve)
+
+	l := bits.Len64(budget)
+	values := append([]uint64(nil), allocs...)
+	if !exact {
+		values = append(values, budget-total)
+	}
+
+	// A = sum_i a_i
+	A := big.NewInt(0)
+	proofs := make([]*zk.RangeProof, len(values))
+	for i, v := range values {
+		prover, err := zk.NewRangeProver(new(big.Int).SetUint64(v), l, nil, gkX, gkY)
+		if err != nil {
+			return nil, err
+		}
+
+		if proofs[i], err = prover.ProveInContext(ctx, data); err != nil {
+			return nil, err
+		}
+
+		A = A.Add(A, prover.A())
+	}
+	A = A.Mod(A, curve.Params().N)
+
+	// Prove log_g(prod_i g^{a_i}) = log_{g^k}(prod_i y_i / g^B)
+	prover, err := zk.NewDLEQProver(A, curve.Params().Gx, curve.Params().Gy, gkX, gkY)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := prover.ProveInContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &CumulativeBallot{
+		budget: budget,
+		allocs: proofs[:len(allocs)],
+		sum:    sum,
+	}
+	if !exact {
+		b.slack = proofs[len(allocs)]
+	}
+
+	return b, nil
+}
+
+// VerifyBallot verifies cumulative ballot
+func (b *CumulativeBallot) VerifyBallot() error {
+	if b.budget == 0 {
+		return errors.New("Invalid budget")
+	}
+
+	if len(b.allocs) == 0 {
+		return errors.New("No candidates")
+	}
+
+	if b.sum == nil {
+		return errors.New("Missing sum proof")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	l := bits.Len64(b.budget)
+	proofs := b.proofs()
+
+	gkX, gkY := proofs[0].PublicKey()
+	data := proofs[0].Data()
+	ctx := proofs[0].Context()
+	if gkX == nil || !isOnCurve(gkX, gkY) {
+		return errors.New("Invalid g^k")
+	}
+
+	// H = prod_i h_i, Y = prod_i y_i
+	HX, HY := new(big.Int), new(big.Int)
+	YX, YY := new(big.Int), new(big.Int)
+	for i, p := range proofs {
+		if p == nil {
+			return fmt.Errorf("Missing range proof [%d]", i)
+		}
+
+		if p.Bits() != l {
+			return fmt.Errorf("Invalid range of allocation [%d]", i)
+		}
+
+		X, Y := p.PublicKey()
+		if X == nil || X.Cmp(gkX) != 0 || Y.Cmp(gkY) != 0 || p.Data().Cmp(data) != 0 ||
+			!bytes.Equal(p.Context(), ctx) {
+			return fmt.Errorf("Inconsistent range proof [%d]", i)
+		}
+
+		res, err := p.Verify()
+		if err != nil {
+			return err
+		}
+		if !res {
+			return fmt.Errorf("Failed to verify allocation [%d]", i)
+		}
+
+		hX, hY, yX, yY := p.Ciphertext()
+		HX, HY = curve.Add(HX, HY, hX, hY)
+		YX, YY = curve.Add(YX, YY, yX, yY)
+	}
+
+	// Y = Y / g^B
+	gBX, gBY := curve.ScalarBaseMult(new(big.Int).SetUint64(b.budget).Bytes())
+	YX, YY = curve.Add(YX, YY, new(big.Int).Set(gBX), new(big.Int).Sub(curve.Params().P, gBY))
+
+	g1X, g1Y, g2X, g2Y := b.sum.Bases()
+	y1X, y1Y, y2X, y2Y := b.sum.Values()
+	if g1X.Cmp(curve.Params().Gx) != 0 || g1Y.Cmp(curve.Params().Gy) != 0 ||
+		g2X.Cmp(gkX) != 0 || g2Y.Cmp(gkY) != 0 ||
+		y1X.Cmp(HX) != 0 || y1Y.Cmp(HY) != 0 ||
+		y2X.Cmp(YX) != 0 || y2Y.Cmp(YY) != 0 ||
+		b.sum.Data().Cmp(data) != 0 || !bytes.Equal(b.sum.Context(), ctx) {
+		return errors.New("Allocations do not add up to budget")
+	}
+
+	res, err := b.sum.Verify()
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Failed to verify sum proof")
+	}
+
+	return nil
+}
+
+// proofs returns range proofs of all allocations including slack
+func (b *CumulativeBallot) proofs() []*zk.RangeProof {
+	proofs := append([]*zk.RangeProof(nil), b.allocs...)
+	if b.slack != nil {
+		proofs = append(proofs, b.slack)
+	}
+	return proofs
+}
+
+// checkCumulativeBallot verifies the ballot and checks it against election
+// settings, including the election ctx it must be bound to
+func checkCumulativeBallot(b *CumulativeBallot, gkX, gkY *big.Int, ctx []byte, candidates int, budget uint64, exact bool) error {
+	if err := b.VerifyBallot(); err != nil {
+		return err
+	}
+
+	if err := checkContext(ctx, b.allocs[0].Context()); err != nil {
+		return err
+	}
+
+	if b.budget != budget {
+		return errors.New("Invalid budget")
+	}
+
+	if len(b.allocs) != candidates {
+		return errors.New("Invalid number of candidates")
+	}
+
+	if exact && b.slack != nil {
+		return errors.New("Budget must be spent exactly")
+	}
+
+	X, Y := b.allocs[0].PublicKey()
+	if X.Cmp(gkX) != 0 || Y.Cmp(gkY) != 0 {
+		return errors.New("g^k doesn't match")
+	}
+
+	return nil
+}
+
+// CheckElection checks that the ballot is bound to the election described
+// by m, or to none if m is nil
+func (b *CumulativeBallot) CheckElection(m *Manifest) error {
+	var ctx []byte
+	if m != nil {
+		hash := m.Hash()
+		ctx = hash[:]
+	}
+	return checkContext(ctx, b.allocs[0].Context())
+}
+
+// Budget returns the number of points to be distributed
+func (b *CumulativeBallot) Budget() uint64 {
+	return b.budget
+}
+
+// Candidates returns the number of candidates
+func (b *CumulativeBallot) Candidates() int {
+	return len(b.allocs)
+}
+
+// Kind returns CumulativeKind
+func (b *CumulativeBallot) Kind() BallotKind {
+	return CumulativeKind
+}
+
+// Voter returns the data bound to the ballot that identifies the voter
+func (b *CumulativeBallot) Voter() *big.Int {
+	return b.allocs[0].Data()
+}
+
+// PublicKey returns g^k the ballot is encrypted with
+func (b *CumulativeBallot) PublicKey() (*big.Int, *big.Int) {
+	return b.allocs[0].PublicKey()
+}
+
+// Ciphertexts returns the encrypted allocation for each candidate
+func (b *CumulativeBallot) Ciphertexts() []*zk.Ciphertext {
+	cs := make([]*zk.Ciphertext, len(b.allocs))
+	for i, p := range b.allocs {
+		e := new(zk.Ciphertext)
+		e.HX, e.HY, e.YX, e.YY = p.Ciphertext()
+		cs[i] = e
+	}
+	return cs
+}
+
+func (b *CumulativeBallot) String() (string, string) {
+	s := fmt.Sprintf("budget = %d", b.budget)
+	for i, p := range b.allocs {
+		hX, hY, yX, yY := p.Ciphertext()
+		s = s + fmt.Sprintf("; [%d] h = (%x, %x), y = (%x, %x)", i, hX, hY, yX, yY)
+	}
+	return s, b.sum.String()
+}
+
+// BuildJSONCumulativeBallot builds JSON object
+func (b *CumulativeBallot) BuildJSONCumulativeBallot() *JSONCumulativeBallot {
+	obj := &JSONCumulativeBallot{
+		Budget: b.budget,
+		Allocs: make([]*zk.JSONRangeProof, len(b.allocs)),
+		Sum:    b.sum.BuildJSONDLEQProof(),
+	}
+
+	for i, p := range b.allocs {
+		obj.Allocs[i] = p.BuildJSONRangeProof()
+	}
+
+	if b.slack != nil {
+		obj.Slack = b.slack.BuildJSONRangeProof()
+	}
+
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (b *CumulativeBallot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.BuildJSONCumulativeBallot())
+}
+
+// FromJSONCumulativeBallot reconstructs from json object
+func (b *CumulativeBallot) FromJSONCumulativeBallot(obj *JSONCumulativeBallot) error {
+	if len(obj.Allocs) == 0 {
+		return errors.New("No candidates")
+	}
+
+	b.budget = obj.Budget
+
+	b.allocs = make([]*zk.RangeProof, len(obj.Allocs))
+	for i, p := range obj.Allocs {
+		b.allocs[i] = new(zk.RangeProof)
+		if err := b.allocs[i].FromJSONRangeProof(p); err != nil {
+			return err
+		}
+	}
+
+	b.slack = nil
+	if obj.Slack != nil {
+		b.slack = new(zk.RangeProof)
+		if err := b.slack.FromJSONRangeProof(obj.Slack); err != nil {
+			return err
+		}
+	}
+
+	b.sum = new(zk.DLEQProof)
+	if err := b.sum.FromJSONDLEQProof(obj.Sum); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (b *CumulativeBallot) UnmarshalJSON(data []byte) error {
+	var obj JSONCumulativeBallot
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	return b.FromJSONCumulativeBallot(&obj)
+}
@@ -0,0 +1,108 @@
+package vote
+
+import (
+	"errors"
+	"math/big"
+)
+
+// CumulativeTally structure
+type CumulativeTally struct {
+	gkX, gkY *big.Int
+	authData *big.Int
+	budget   uint64
+	ctx      []byte // election bound into the ballots and proofs, nil if none
+
+	HX, HY []*big.Int // H_j = prod_i h_ij for candidate j
+	YX, YY []*big.Int // Y_j = prod_i y_ij for candidate j
+	n      int        // number of ballots
+}
+
+// NewCumulativeTally creates a new tally
+func NewCumulativeTally(gkX, gkY, authData *big.Int, candidates int, budget uint64, exact bool, ballots []*CumulativeBallot) (*CumulativeTally, error) {
+	if !isOnCurve(gkX, gkY) {
+		return nil, errors.New("Invalid authority public key")
+	}
+
+	return newCumulativeTally(gkX, gkY, authData, nil, candidates, budget, exact, ballots)
+}
+
+// NewCumulativeTallyWithParams creates a new tally of ballots bound to the
+// election of p, if set, within the turnout limits of p. Voter registries
+// and rings are not supported.
+func NewCumulativeTallyWithParams(p *ElectionParams, candidates int, budget uint64, exact bool, ballots []*CumulativeBallot) (*CumulativeTally, error) {
+	if p.registry != nil || p.ring != nil {
+		return nil, errors.New("Voter registry not supported")
+	}
+	if err := p.checkVoters(len(ballots)); err != nil {
+		return nil, err
+	}
+
+	return newCumulativeTally(p.gkX, p.gkY, p.authData, p.context(), candidates, budget, exact, ballots)
+}
+
+func newCumulativeTally(gkX, gkY, authData *big.Int, ctx []byte, candidates int, budget uint64, exact bool, ballots []*CumulativeBallot) (*CumulativeTally, error) {
+	if candidates <= 0 {
+		return nil, errors.New("Invalid number of candidates")
+	}
+
+	t := &CumulativeTally{
+		gkX:      new(big.Int).Set(gkX),
+		gkY:      new(big.Int).Set(gkY),
+		authData: new(big.Int).Set(authData),
+		ctx:      ctx,
+		budget:   budget,
+		HX:       make([]*big.Int, candidates),
+		HY:       make([]*big.Int, candidates),
+		YX:       make([]*big.Int, candidates),
+		YY:       make([]*big.Int, candidates),
+		n:        len(ballots),
+	}
+	for j := 0; j < candidates; j++ {
+		t.HX[j], t.HY[j] = new(big.Int), new(big.Int)
+		t.YX[j], t.YY[j] = new(big.Int), new(big.Int)
+	}
+
+	for _, b := range ballots {
+		if err := checkCumulativeBallot(b, gkX, gkY, ctx, candidates, budget, exact); err != nil {
+			return nil, err
+		}
+
+		for j, p := range b.allocs {
+			hX, hY, yX, yY := p.Ciphertext()
+			t.HX[j], t.HY[j] = curve.Add(t.HX[j], t.HY[j], hX, hY)
+			t.YX[j], t.YY[j] = curve.Add(t.YX[j], t.YY[j], yX, yY)
+		}
+	}
+
+	return t, nil
+}
+
+// Tally computes result and zk proof for each candidate
+func (t *CumulativeTally) Tally(k *big.Int) (*OptionTallyRes, error) {
+	return t.tally(k)
+}
+
+func (t *CumulativeTally) tally(k *big.Int) (*OptionTallyRes, error) {
+	if !isInRange(k) {
+		return nil, errors.New("Invalid k")
+	}
+
+	x, y := curve.ScalarBaseMult(k.Bytes())
+	if x.Cmp(t.gkX) != 0 || y.Cmp(t.gkY) != 0 {
+		return nil, errors.New("k doesn't match saved g^k")
+	}
+
+	// each candidate receives at most n*B points
+	max := t.n * int(t.budget)
+
+	res := make([]*BinaryTallyRes, len(t.HX))
+	for j := range t.HX {
+		r, err := decrypt(k, t.HX[j], t.HY[j], t.YX[j], t.YY[j], 0, max, t.authData, t.ctx)
+		if err != nil {
+			return nil, err
+		}
+		res[j] = r
+	}
+
+	return &OptionTallyRes{res}, nil
+}
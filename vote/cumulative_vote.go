@@ -0,0 +1,193 @@
+package vote
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// CumulativeVote structure
+type CumulativeVote struct {
+	gkX, gkY *big.Int // authority's public key
+	authData *big.Int // address of authorty
+
+	candidates int    // number of candidates
+	budget     uint64 // number of points each voter distributes
+	exact      bool   // whether the budget must be spent exactly
+	ctx        []byte // election bound into the ballots and proofs, nil if none
+
+	ballots map[[32]byte]*CumulativeBallot // cumulative ballots
+	revoteLog
+	lifecycle
+
+	HX, HY []*big.Int // H_j = prod_i h_ij for candidate j
+	YX, YY []*big.Int // Y_j = prod_i y_ij for candidate j
+
+	res *OptionTallyRes
+}
+
+// NewCumulativeVote news a vote in which each voter distributes budget
+// points across candidates. The vote starts in registration and must be
+// opened before ballots can be cast.
+func NewCumulativeVote(gkX, gkY *big.Int, authData *big.Int, candidates int, budget uint64, exact bool) (*CumulativeVote, error) {
+	if !isOnCurve(gkX, gkY) {
+		return nil, errors.New("Invalid g^k")
+	}
+
+	if candidates <= 0 {
+		return nil, errors.New("Invalid number of candidates")
+	}
+
+	if budget == 0 {
+		return nil, errors.New("Invalid budget")
+	}
+
+	vote := new(CumulativeVote)
+
+	vote.gkX = new(big.Int).Set(gkX)
+	vote.gkY = new(big.Int).Set(gkY)
+
+	vote.authData = new(big.Int).Set(authData)
+
+	vote.candidates = candidates
+	vote.budget = budget
+	vote.exact = exact
+
+	vote.ballots = make(map[[32]byte]*CumulativeBallot)
+	vote.revoteLog = newRevoteLog()
+	vote.lifecycle = newLifecycle()
+
+	vote.HX = make([]*big.Int, candidates)
+	vote.HY = make([]*big.Int, candidates)
+	vote.YX = make([]*big.Int, candidates)
+	vote.YY = make([]*big.Int, candidates)
+	for j := 0; j < candidates; j++ {
+		vote.HX[j], vote.HY[j] = big.NewInt(0), big.NewInt(0)
+		vote.YX[j], vote.YY[j] = big.NewInt(0), big.NewInt(0)
+	}
+
+	return vote, nil
+}
+
+// SetElection binds the vote to the election with the given manifest hash.
+// Only ballots bound to the election are accepted and the tally proofs are
+// bound to it. It can only be set during registration.
+func (v *CumulativeVote) SetElection(hash [32]byte) error {
+	if v.phase != Registration {
+		return errors.New("Election can only be set during registration")
+	}
+	v.ctx = hash[:]
+	return nil
+}
+
+// newCumulativeTally creates a tally
+func (v *CumulativeVote) newCumulativeTally() *CumulativeTally {
+	t := &CumulativeTally{
+		gkX:      new(big.Int).Set(v.gkX),
+		gkY:      new(big.Int).Set(v.gkY),
+		authData: new(big.Int).Set(v.authData),
+		ctx:      v.ctx,
+		budget:   v.budget,
+		HX:       make([]*big.Int, v.candidates),
+		HY:       make([]*big.Int, v.candidates),
+		YX:       make([]*big.Int, v.candidates),
+		YY:       make([]*big.Int, v.candidates),
+		n:        len(v.ballots),
+	}
+	for j := 0; j < v.candidates; j++ {
+		t.HX[j], t.HY[j] = new(big.Int).Set(v.HX[j]), new(big.Int).Set(v.HY[j])
+		t.YX[j], t.YY[j] = new(big.Int).Set(v.YX[j]), new(big.Int).Set(v.YY[j])
+	}
+	return t
+}
+
+// Cast casts a ballot
+func (v *CumulativeVote) Cast(bt Ballot, data *big.Int) error {
+	b, ok := bt.(*CumulativeBallot)
+	if !ok {
+		return errors.New("Invalid ballot type")
+	}
+
+	if err := v.checkCast(); err != nil {
+		return err
+	}
+
+	if err := checkCumulativeBallot(b, v.gkX, v.gkY, v.ctx, v.candidates, v.budget, v.exact); err != nil {
+		return err
+	}
+
+	id := sha256.Sum256(data.Bytes())
+	if counted, err := v.admit(id, data, b); err != nil || !counted {
+		return err
+	}
+
+	if old, ok := v.ballots[id]; ok {
+		for j, p := range old.allocs {
+			hX, hY, yX, yY := p.Ciphertext()
+
+			iOldhX, iOldhY := ecinv(hX, hY)
+			v.HX[j], v.HY[j] = curve.Add(v.HX[j], v.HY[j], iOldhX, iOldhY)
+
+			iOldyX, iOldyY := ecinv(yX, yY)
+			v.YX[j], v.YY[j] = curve.Add(v.YX[j], v.YY[j], iOldyX, iOldyY)
+		}
+	}
+
+	for j, p := range b.allocs {
+		hX, hY, yX, yY := p.Ciphertext()
+		v.HX[j], v.HY[j] = curve.Add(v.HX[j], v.HY[j], hX, hY)
+		v.YX[j], v.YY[j] = curve.Add(v.YX[j], v.YY[j], yX, yY)
+	}
+
+	v.ballots[id] = b
+
+	return nil
+}
+
+// Tally tallies the voting results. The vote must be closed.
+func (v *CumulativeVote) Tally(k *big.Int) error {
+	if err := v.checkTally(); err != nil {
+		return err
+	}
+
+	if !isInRange(k) {
+		return errors.New("Invalid k")
+	}
+
+	t := v.newCumulativeTally()
+
+	res, err := t.tally(k)
+	if err != nil {
+		return err
+	}
+	v.res = res
+	v.phase = Tallied
+
+	return nil
+}
+
+// VerifyTallyRes verifies the tally results
+func (v *CumulativeVote) VerifyTallyRes() error {
+	if v.res == nil {
+		return errors.New("No tally results")
+	}
+
+	if err := v.res.verify(); err != nil {
+		return err
+	}
+	if err := v.res.checkContext(v.ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAuthPublicKey returns authority public key
+func (v *CumulativeVote) GetAuthPublicKey() (*big.Int, *big.Int) {
+	return new(big.Int).Set(v.gkX), new(big.Int).Set(v.gkY)
+}
+
+// GetTallyRes gets the tally result
+func (v *CumulativeVote) GetTallyRes() *OptionTallyRes {
+	return v.res
+}
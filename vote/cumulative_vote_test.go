@@ -0,0 +1,163 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCumulativeVoteTally(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	cumVote, err := NewCumulativeVote(k.PublicKey.X, k.PublicKey.Y, authAddr, 3, 5, false)
+	assert.Nil(t, err)
+	assert.Nil(t, cumVote.Open())
+
+	allocs := [][]uint64{{5, 0, 0}, {1, 2, 2}, {0, 3, 1}, {2, 0, 0}}
+	expected := []int{8, 5, 3}
+
+	for _, alloc := range allocs {
+		voterAddr := new(big.Int).SetBytes(getRandAddr())
+		b, err := NewCumulativeBallot(alloc, 5, false, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+		assert.Nil(t, err)
+		assert.Nil(t, cumVote.Cast(b, voterAddr))
+	}
+
+	assert.NotNil(t, cumVote.Tally(k.D))
+	assert.Nil(t, cumVote.Close())
+	err = cumVote.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, cumVote.GetTallyRes().Counts())
+	assert.Nil(t, cumVote.VerifyTallyRes())
+
+	// json round trip of the tally result
+	data, err := json.Marshal(cumVote.GetTallyRes())
+	assert.Nil(t, err)
+	var res OptionTallyRes
+	assert.Nil(t, json.Unmarshal(data, &res))
+	assert.Nil(t, res.Verify())
+	assert.Equal(t, expected, res.Counts())
+}
+
+func TestCumulativeBallot(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	voterAddr := new(big.Int).SetBytes(getRandAddr())
+
+	// budget exceeded
+	_, err := NewCumulativeBallot([]uint64{3, 3}, 5, false, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.NotNil(t, err)
+
+	// budget not spent exactly
+	_, err = NewCumulativeBallot([]uint64{1, 3}, 5, true, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.NotNil(t, err)
+
+	b, err := NewCumulativeBallot([]uint64{2, 3}, 5, true, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, b.VerifyBallot())
+
+	data, err := json.Marshal(b)
+	assert.Nil(t, err)
+	var reconstruct CumulativeBallot
+	assert.Nil(t, json.Unmarshal(data, &reconstruct))
+	assert.Nil(t, reconstruct.VerifyBallot())
+
+	// a ballot without allocations is rejected
+	obj := b.BuildJSONCumulativeBallot()
+	obj.Allocs = nil
+	assert.NotNil(t, new(CumulativeBallot).FromJSONCumulativeBallot(obj))
+
+	// a ballot for a different budget is rejected
+	cumVote, err := NewCumulativeVote(k.PublicKey.X, k.PublicKey.Y, voterAddr, 2, 6, true)
+	assert.Nil(t, err)
+	assert.Nil(t, cumVote.Open())
+	assert.NotNil(t, cumVote.Cast(b, voterAddr))
+
+	// swapping allocations breaks the sum proof
+	other, err := NewCumulativeBallot([]uint64{5, 0}, 5, true, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.Nil(t, err)
+	reconstruct.allocs[0] = other.allocs[0]
+	assert.NotNil(t, reconstruct.VerifyBallot())
+}
+
+func TestCumulativeVoteInElection(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	m, err := NewManifest("e1", "Budget", []string{"a", "b"}, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	m2, err := NewManifest("e2", "Budget", []string{"a", "b"}, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+
+	cumVote, err := NewCumulativeVote(k.PublicKey.X, k.PublicKey.Y, authAddr, 2, 3, true)
+	assert.Nil(t, err)
+	assert.Nil(t, cumVote.SetElection(m.Hash()))
+	assert.Nil(t, cumVote.Open())
+	assert.NotNil(t, cumVote.SetElection(m2.Hash()))
+
+	var ballots []*CumulativeBallot
+	for _, alloc := range [][]uint64{{3, 0}, {1, 2}} {
+		voterAddr := new(big.Int).SetBytes(getRandAddr())
+		b, err := NewCumulativeBallotInElection(m, alloc, 3, true, voterAddr)
+		assert.Nil(t, err)
+		assert.Nil(t, b.CheckElection(m))
+		assert.NotNil(t, b.CheckElection(m2))
+		assert.Nil(t, cumVote.Cast(b, voterAddr))
+		ballots = append(ballots, b)
+	}
+
+	// ballots of another election or of none are rejected
+	voterAddr := new(big.Int).SetBytes(getRandAddr())
+	b, err := NewCumulativeBallotInElection(m2, []uint64{0, 3}, 3, true, voterAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, cumVote.Cast(b, voterAddr))
+	b, err = NewCumulativeBallot([]uint64{0, 3}, 3, true, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, cumVote.Cast(b, voterAddr))
+
+	// a proof moved from a ballot of another election breaks the ballot
+	other, err := NewCumulativeBallotInElection(m2, []uint64{1, 2}, 3, true, ballots[1].Voter())
+	assert.Nil(t, err)
+	forged := *ballots[1]
+	forged.sum = other.sum
+	assert.NotNil(t, forged.VerifyBallot())
+
+	assert.Nil(t, cumVote.Close())
+	assert.Nil(t, cumVote.Tally(k.D))
+	assert.Nil(t, cumVote.VerifyTallyRes())
+	res := cumVote.GetTallyRes()
+	assert.Equal(t, []int{4, 2}, res.Counts())
+	assert.Nil(t, res.CheckElection(m))
+	assert.NotNil(t, res.CheckElection(m2))
+	assert.NotNil(t, res.CheckElection(nil))
+
+	// tallies of the ballots are bound to the election of the params
+	p, err := m.Params(authAddr)
+	assert.Nil(t, err)
+	tal, err := NewCumulativeTallyWithParams(p, 2, 3, true, ballots)
+	assert.Nil(t, err)
+	res, err = tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Nil(t, res.Verify())
+	assert.Nil(t, res.CheckElection(m))
+	_, err = NewCumulativeTally(k.PublicKey.X, k.PublicKey.Y, authAddr, 2, 3, true, ballots)
+	assert.NotNil(t, err)
+	p2, err := m2.Params(authAddr)
+	assert.Nil(t, err)
+	_, err = NewCumulativeTallyWithParams(p2, 2, 3, true, ballots)
+	assert.NotNil(t, err)
+
+	// options of a result bound to different elections
+	b, err = NewCumulativeBallot([]uint64{1, 2}, 3, true, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.Nil(t, err)
+	unbound, err := NewCumulativeTally(k.PublicKey.X, k.PublicKey.Y, authAddr, 2, 3, true, []*CumulativeBallot{b})
+	assert.Nil(t, err)
+	ures, err := unbound.Tally(k.D)
+	assert.Nil(t, err)
+	mixed := &OptionTallyRes{res: []*BinaryTallyRes{res.res[0], ures.res[1]}}
+	assert.NotNil(t, mixed.Verify())
+}
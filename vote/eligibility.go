@@ -0,0 +1,232 @@
+package vote
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
+)
+
+// Registry - Merkle tree of eligible voters
+//
+// Leaf i commits to the address (or other identifying data) of voter i and
+// the weight of the voter's ballot. The root is published as part of the
+// election parameters and each ballot carries the inclusion proof of its
+// voter.
+type Registry struct {
+	tree    *merkle.Tree
+	voters  []*big.Int
+	weights []uint64
+	index   map[[32]byte]int // sha256(addr) => i
+}
+
+// Eligibility - inclusion proof of a voter in the registry
+type Eligibility struct {
+	weight uint64
+	proof  *merkle.Proof
+}
+
+// eligibilityLeaf hashes the registry entry (addr, weight)
+func eligibilityLeaf(addr *big.Int, weight uint64) ([32]byte, error) {
+	b := addr.Bytes()
+	if len(b) > 32 {
+		return [32]byte{}, errors.New("Address too long")
+	}
+
+	buf := make([]byte, 40)
+	copy(buf[32-len(b):32], b)
+	binary.BigEndian.PutUint64(buf[32:], weight)
+
+	return merkle.HashLeaf(buf), nil
+}
+
+// NewRegistry builds the registry of voters. All weights are one if weights
+// is nil.
+func NewRegistry(voters []*big.Int, weights []uint64) (*Registry, error) {
+	if weights == nil {
+		weights = make([]uint64, len(voters))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(voters) != len(weights) {
+		return nil, errors.New("Numbers of voters and weights don't match")
+	}
+
+	r := &Registry{
+		voters:  make([]*big.Int, len(voters)),
+		weights: append([]uint64(nil), weights...),
+		index:   make(map[[32]byte]int),
+	}
+
+	leaves := make([][32]byte, len(voters))
+	for i, addr := range voters {
+		if weights[i] == 0 {
+			return nil, errors.New("Invalid weight")
+		}
+
+		id := sha256.Sum256(addr.Bytes())
+		if _, ok := r.index[id]; ok {
+			return nil, errors.New("Duplicate voter")
+		}
+		r.index[id] = i
+		r.voters[i] = new(big.Int).Set(addr)
+
+		var err error
+		if leaves[i], err = eligibilityLeaf(addr, weights[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if r.tree, err = merkle.NewTree(leaves); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Root returns the Merkle root
+func (r *Registry) Root() [32]byte {
+	return r.tree.Root()
+}
+
+// TotalWeight returns the sum of the weights of all voters
+func (r *Registry) TotalWeight() uint64 {
+	var w uint64
+	for _, x := range r.weights {
+		w = w + x
+	}
+	return w
+}
+
+// Eligibility returns the inclusion proof of a voter
+func (r *Registry) Eligibility(addr *big.Int) (*Eligibility, error) {
+	i, ok := r.index[sha256.Sum256(addr.Bytes())]
+	if !ok {
+		return nil, errors.New("Voter not registered")
+	}
+
+	p, err := r.tree.Prove(i)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Eligibility{r.weights[i], p}, nil
+}
+
+// Weight returns the weight of the voter
+func (e *Eligibility) Weight() uint64 {
+	return e.weight
+}
+
+// Verify checks that (addr, weight) is in the registry with the given root
+func (e *Eligibility) Verify(root [32]byte, addr *big.Int) error {
+	if e.weight == 0 || e.proof == nil {
+		return errors.New("Invalid eligibility proof")
+	}
+
+	leaf, err := eligibilityLeaf(addr, e.weight)
+	if err != nil {
+		return err
+	}
+	if !e.proof.Verify(root, leaf) {
+		return errors.New("Voter not eligible")
+	}
+
+	return nil
+}
+
+// BuildJSONEligibility builds json object
+func (e *Eligibility) BuildJSONEligibility() *JSONEligibility {
+	return &JSONEligibility{
+		Weight: e.weight,
+		Proof:  e.proof.BuildJSONProof(),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (e *Eligibility) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.BuildJSONEligibility())
+}
+
+// FromJSONEligibility reconstructs from json object
+func (e *Eligibility) FromJSONEligibility(obj *JSONEligibility) error {
+	e.weight = obj.Weight
+	e.proof = new(merkle.Proof)
+	return e.proof.FromJSONProof(obj.Proof)
+}
+
+// UnmarshalJSON implements json unmarshal
+func (e *Eligibility) UnmarshalJSON(data []byte) error {
+	var obj JSONEligibility
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return e.FromJSONEligibility(&obj)
+}
+
+// BuildJSONRegistry builds json object including the inclusion proofs of
+// all voters
+func (r *Registry) BuildJSONRegistry() *JSONRegistry {
+	obj := &JSONRegistry{Root: merkle.HashToHexStr(r.Root())}
+	for i, addr := range r.voters {
+		p, _ := r.tree.Prove(i)
+		obj.Voters = append(obj.Voters, &JSONRegistryEntry{
+			Address: common.BigIntToHexStr(addr),
+			JSONEligibility: JSONEligibility{
+				Weight: r.weights[i],
+				Proof:  p.BuildJSONProof(),
+			},
+		})
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.BuildJSONRegistry())
+}
+
+// FromJSONRegistry rebuilds the registry from json object and checks the
+// root
+func (r *Registry) FromJSONRegistry(obj *JSONRegistry) error {
+	voters := make([]*big.Int, len(obj.Voters))
+	weights := make([]uint64, len(obj.Voters))
+	for i, v := range obj.Voters {
+		var err error
+		if voters[i], err = common.HexStrToBigInt(v.Address); err != nil {
+			return err
+		}
+		weights[i] = v.Weight
+	}
+
+	root, err := merkle.HexStrToHash(obj.Root)
+	if err != nil {
+		return err
+	}
+
+	r1, err := NewRegistry(voters, weights)
+	if err != nil {
+		return err
+	}
+	if r1.Root() != root {
+		return errors.New("Registry root doesn't match")
+	}
+	*r = *r1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (r *Registry) UnmarshalJSON(data []byte) error {
+	var obj JSONRegistry
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return r.FromJSONRegistry(&obj)
+}
@@ -0,0 +1,140 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEligibility(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	var voters []*big.Int
+	for i := 0; i < 5; i++ {
+		voters = append(voters, new(big.Int).SetBytes(getRandAddr()))
+	}
+	weights := []uint64{1, 2, 3, 4, 5}
+
+	reg, err := NewRegistry(voters, weights)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(15), reg.TotalWeight())
+
+	data, err := json.Marshal(reg)
+	assert.Nil(t, err)
+	var reg1 Registry
+	assert.Nil(t, json.Unmarshal(data, &reg1))
+	assert.Equal(t, reg.Root(), reg1.Root())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, p.SetRegistry(reg.Root()))
+
+	data, err = json.Marshal(p)
+	assert.Nil(t, err)
+	var p1 ElectionParams
+	assert.Nil(t, json.Unmarshal(data, &p1))
+	assert.Equal(t, *p, p1)
+
+	binaryVote, err := NewBinaryVoteWithParams(p)
+	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
+
+	// voters 0, 2 and 4 vote yes, voters 1 and 3 vote no
+	var ballots []*BinaryBallot
+	for i, addr := range voters {
+		b := genBinaryBallot(i%2 == 0, addr, k.PublicKey.X, k.PublicKey.Y, t)
+
+		// no eligibility proof
+		assert.NotNil(t, binaryVote.Cast(b, addr))
+
+		e, err := reg.Eligibility(addr)
+		assert.Nil(t, err)
+		b.SetEligibility(e)
+
+		// json round trip keeps the proof
+		data, err := json.Marshal(b)
+		assert.Nil(t, err)
+		b1 := new(BinaryBallot)
+		assert.Nil(t, json.Unmarshal(data, b1))
+		assert.Equal(t, b, b1)
+
+		// cast under another voter's address
+		assert.NotNil(t, binaryVote.Cast(b1, voters[(i+1)%len(voters)]))
+
+		assert.Nil(t, binaryVote.Cast(b1, addr))
+		ballots = append(ballots, b1)
+	}
+
+	// claiming a higher weight
+	b := genBinaryBallot(true, voters[0], k.PublicKey.X, k.PublicKey.Y, t)
+	e, _ := reg.Eligibility(voters[0])
+	e.weight = 5
+	b.SetEligibility(e)
+	assert.NotNil(t, binaryVote.Cast(b, voters[0]))
+
+	// voter not in the registry
+	outsider := new(big.Int).SetBytes(getRandAddr())
+	b = genBinaryBallot(true, outsider, k.PublicKey.X, k.PublicKey.Y, t)
+	e, _ = reg.Eligibility(voters[1])
+	b.SetEligibility(e)
+	assert.NotNil(t, binaryVote.Cast(b, outsider))
+	_, err = reg.Eligibility(outsider)
+	assert.NotNil(t, err)
+
+	// restored ballots must be bound to their voters
+	data, err = json.Marshal(binaryVote)
+	assert.Nil(t, err)
+	var obj JSONBinaryVote
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.Ballots[0].Data, obj.Ballots[1].Data = obj.Ballots[1].Data, obj.Ballots[0].Data
+	assert.NotNil(t, new(BinaryVote).FromJSONBinaryVote(&obj))
+
+	// weighted result 1 + 3 + 5
+	assert.Nil(t, binaryVote.Close())
+	assert.Nil(t, binaryVote.Tally(k.D))
+	assert.Equal(t, 9, binaryVote.GetTallyRes().V)
+	assert.Nil(t, binaryVote.VerifyTallyRes())
+
+	tal, err := NewBinaryTallyWithParams(p, ballots, 0)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, res.V)
+
+	// the same voter counted twice
+	_, err = NewBinaryTallyWithParams(p, append(ballots, ballots[0]), 0)
+	assert.NotNil(t, err)
+	_, err = NewAggregate(p, append(ballots, ballots[0]), 0)
+	assert.NotNil(t, err)
+	a1, err := NewAggregate(p, ballots[:2], 0)
+	assert.Nil(t, err)
+	a2, err := NewAggregate(p, ballots[1:], 0)
+	assert.Nil(t, err)
+	_, err = MergeAggregates([]*Aggregate{a1, a2})
+	assert.NotNil(t, err)
+
+	// an aggregate that lets voters repeat
+	a0, err := NewAggregate(p, ballots, 0)
+	assert.Nil(t, err)
+	_, err = NewBinaryTallyFromAggregate(p, a0)
+	assert.Nil(t, err)
+	a0.repeats = true
+	_, err = NewBinaryTallyFromAggregate(p, a0)
+	assert.NotNil(t, err)
+
+	// registry without weights
+	reg2, err := NewRegistry(voters, nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, reg.Root(), reg2.Root())
+	assert.Nil(t, p.SetRegistry(reg2.Root()))
+	_, err = NewBinaryTallyWithParams(p, ballots, 0)
+	assert.NotNil(t, err)
+
+	_, err = NewRegistry(append(voters, voters[0]), nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,127 @@
+package vote
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/zzGHzz/zkVote/merkle"
+)
+
+// ExcludedBallot - ballot left out of a tally created by
+// NewBinaryTallyExcluding
+type ExcludedBallot struct {
+	Index  int      // position in the ballots given to the tally
+	Hash   [32]byte // hash of the ballot, zero if missing
+	Reason string   // why the ballot is excluded, not committed
+}
+
+// leaf returns the Merkle leaf of the excluded ballot
+func (e *ExcludedBallot) leaf() [32]byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(e.Index))
+	return merkle.HashLeaf(append(buf[:], e.Hash[:]...))
+}
+
+// excludedRoot returns the root of the Merkle tree over the excluded
+// ballots in order, which commits to the partition of the ballots given to
+// the tally, zero if there are none
+func excludedRoot(excluded []*ExcludedBallot) [32]byte {
+	if len(excluded) == 0 {
+		return [32]byte{}
+	}
+
+	leaves := make([][32]byte, len(excluded))
+	for i, e := range excluded {
+		leaves[i] = e.leaf()
+	}
+	t, _ := merkle.NewTree(leaves)
+	return t.Root()
+}
+
+// NewBinaryTallyExcluding creates a tally of the valid ballots under p,
+// leaving out the invalid ones instead of failing
+//
+// A ballot is excluded if it is missing, fails the checks of
+// NewBinaryTallyWithParams or, if voters must be unique, repeats an earlier
+// voter. The excluded ballots are returned with the reasons in order and
+// committed in the tally result, so that VerifyBallots recomputes the same
+// partition from the same ballots. More valid ballots than the maximum
+// number of voters still fail the tally. workers is the number of goroutines
+// verifying the ballots, as in NewBinaryTallyWithParams.
+func NewBinaryTallyExcluding(p *ElectionParams, ballots []*BinaryBallot, workers int) (*BinaryTally, []*ExcludedBallot, error) {
+	unique := p.uniqueVoters()
+	voters := make(map[[32]byte]bool)
+
+	var (
+		valids   []*BinaryBallot
+		checks   []checkedBallot
+		excluded = []*ExcludedBallot{}
+	)
+	for i, c := range checkBinaryBallots(p, ballots, workers) {
+		if c.err == nil && unique && voters[c.leaf.voter] {
+			c.err = errors.New("Duplicate voter")
+		}
+		if c.err != nil {
+			e := &ExcludedBallot{Index: i, Reason: c.err.Error()}
+			if ballots[i] != nil {
+				// a ballot that can't be hashed is committed as zero
+				e.Hash, _ = ballotHash(ballots[i])
+			}
+			excluded = append(excluded, e)
+			continue
+		}
+		if unique {
+			voters[c.leaf.voter] = true
+		}
+		valids = append(valids, ballots[i])
+		checks = append(checks, c)
+	}
+	if err := p.checkVoters(len(valids)); err != nil {
+		return nil, nil, err
+	}
+
+	t := newCheckedBinaryTally(p, valids, checks, workers)
+	root := excludedRoot(excluded)
+	t.excluded = &root
+
+	return t, excluded, nil
+}
+
+// BuildJSONExcludedBallot builds json object
+func (e *ExcludedBallot) BuildJSONExcludedBallot() *JSONExcludedBallot {
+	return &JSONExcludedBallot{
+		Index:  e.Index,
+		Hash:   merkle.HashToHexStr(e.Hash),
+		Reason: e.Reason,
+	}
+}
+
+// MarshalJSON implements json marshal
+func (e *ExcludedBallot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.BuildJSONExcludedBallot())
+}
+
+// FromJSONExcludedBallot reconstructs from json object
+func (e *ExcludedBallot) FromJSONExcludedBallot(obj *JSONExcludedBallot) error {
+	if obj.Index < 0 {
+		return errors.New("Invalid index")
+	}
+	h, err := merkle.HexStrToHash(obj.Hash)
+	if err != nil {
+		return err
+	}
+
+	e.Index, e.Hash, e.Reason = obj.Index, h, obj.Reason
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (e *ExcludedBallot) UnmarshalJSON(data []byte) error {
+	var obj JSONExcludedBallot
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return e.FromJSONExcludedBallot(&obj)
+}
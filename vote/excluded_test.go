@@ -0,0 +1,85 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryTallyExcluding(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	other, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+
+	var ballots []*BinaryBallot
+	for _, value := range []bool{true, false, true, true, false} {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		ballots = append(ballots, genBinaryBallot(value, addr, k.PublicKey.X, k.PublicKey.Y, t))
+	}
+	// off-curve h, encrypted with another key and missing
+	invalid := *ballots[1]
+	invalid.hX = big.NewInt(1)
+	ballots = append(ballots[:2], append([]*BinaryBallot{&invalid}, ballots[2:]...)...)
+	ballots = append(ballots, genBinaryBallot(true, big.NewInt(1), other.PublicKey.X, other.PublicKey.Y, t), nil)
+
+	_, err = NewBinaryTallyWithParams(p, ballots, 0)
+	assert.NotNil(t, err)
+
+	tal, excluded, err := NewBinaryTallyExcluding(p, ballots, 0)
+	assert.Nil(t, err)
+	assert.Len(t, excluded, 3)
+	for i, idx := range []int{2, 6, 7} {
+		assert.Equal(t, idx, excluded[i].Index)
+		assert.NotEmpty(t, excluded[i].Reason)
+	}
+	h, _ := ballotHash(&invalid)
+	assert.Equal(t, h, excluded[0].Hash)
+	assert.Equal(t, [32]byte{}, excluded[2].Hash)
+
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.V)
+	assert.Equal(t, 5, res.Turnout)
+	assert.True(t, res.ExcludesInvalid())
+
+	data, err := json.Marshal(res)
+	assert.Nil(t, err)
+	res1 := new(BinaryTallyRes)
+	assert.Nil(t, json.Unmarshal(data, res1))
+	assert.True(t, res1.ExcludesInvalid())
+	assert.Nil(t, res1.VerifyBallots(p, ballots, 0))
+
+	// the partition must be the same
+	assert.NotNil(t, res1.VerifyBallots(p, ballots[:len(ballots)-1], 0))
+	assert.NotNil(t, res1.VerifyBallots(p, append([]*BinaryBallot{nil}, ballots...), 0))
+
+	// a strict tally of the valid ballots commits to no exclusions
+	var valids []*BinaryBallot
+	for _, i := range []int{0, 1, 3, 4, 5} {
+		valids = append(valids, ballots[i])
+	}
+	strict, err := NewBinaryTallyWithParams(p, valids, 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, res1.verifyTally(strict))
+
+	// the commitment is bound into the proofs
+	var obj JSONBinaryTallyRes
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.Excluded = obj.Ballots
+	res2 := new(BinaryTallyRes)
+	assert.Nil(t, res2.FromJSONBinaryTallyRes(&obj))
+	assert.NotNil(t, res2.Verify())
+
+	data, err = json.Marshal(excluded)
+	assert.Nil(t, err)
+	var excluded1 []*ExcludedBallot
+	assert.Nil(t, json.Unmarshal(data, &excluded1))
+	assert.Equal(t, excluded, excluded1)
+}
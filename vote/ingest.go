@@ -0,0 +1,134 @@
+package vote
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// CastRequest - a ballot to be cast by the voter identified by Data
+type CastRequest struct {
+	Ballot Ballot
+	Data   *big.Int
+}
+
+// Progress is called with the number of ballots processed so far and the
+// total number of ballots
+type Progress func(done, total int)
+
+// verifiedCast - result of verifying request i
+type verifiedCast struct {
+	i   int
+	b   *BinaryBallot
+	w   uint64
+	err error
+}
+
+// CastMany casts ballots, verifying them on a pool of workers and applying
+// them in the order given, so that the vote ends up as if they were cast one
+// by one with Cast. It returns the error of each ballot, nil if cast, and,
+// if a receipt key is set, the receipt of each ballot counted as
+// CastWithReceipt does, nil for the others.
+//
+// workers <= 0 uses one worker per CPU. progress, if not nil, is called on
+// the calling goroutine after each ballot is applied. If ctx is done before
+// all ballots are applied, CastMany stops and returns ctx.Err(), which is
+// also the error of each ballot not applied.
+func (v *BinaryVote) CastMany(ctx context.Context, reqs []*CastRequest, workers int, progress Progress) ([]*Receipt, []error, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	receipts := make([]*Receipt, len(reqs))
+	errs := make([]error, len(reqs))
+
+	// stops the workers on return
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range reqs {
+			select {
+			case jobs <- i:
+			case <-wctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan verifiedCast, workers)
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := verifiedCast{i: i}
+				if reqs[i] == nil {
+					r.err = errors.New("Missing ballot")
+				} else {
+					r.b, r.w, r.err = v.verifyCast(reqs[i].Ballot, reqs[i].Data)
+				}
+				select {
+				case results <- r:
+				case <-wctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// apply in order, holding back ballots verified ahead of their turn
+	pending := make(map[int]verifiedCast)
+	next := 0
+	for next < len(reqs) {
+		if err := ctx.Err(); err != nil {
+			for i := next; i < len(reqs); i++ {
+				errs[i] = err
+			}
+			return receipts, errs, err
+		}
+
+		select {
+		case <-ctx.Done():
+			continue
+		case r, ok := <-results:
+			if !ok {
+				// workers stopped, ctx is done
+				results = nil
+				continue
+			}
+			pending[r.i] = r
+		}
+
+		for r, ok := pending[next]; ok; r, ok = pending[next] {
+			delete(pending, next)
+			errs[next] = r.err
+			if r.err == nil {
+				v.mu.Lock()
+				errs[next] = v.applyCast(r.b, reqs[next].Data, r.w)
+				if errs[next] == nil && v.receiptKey != nil {
+					// ballots ignored under FirstWins get no receipt
+					if rc, err := v.receipt(r.b, reqs[next].Data); err == nil {
+						receipts[next] = rc
+					}
+				}
+				v.mu.Unlock()
+			}
+			next++
+			if progress != nil {
+				progress(next, len(reqs))
+			}
+		}
+	}
+
+	return receipts, errs, nil
+}
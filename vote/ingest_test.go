@@ -0,0 +1,146 @@
+package vote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCastMany(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	other, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	var reqs []*CastRequest
+	for _, value := range []bool{true, false, true, true, false, true} {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		b := genBinaryBallot(value, addr, k.PublicKey.X, k.PublicKey.Y, t)
+		reqs = append(reqs, &CastRequest{b, addr})
+	}
+	// re-vote of the first voter, an invalid ballot and a missing one
+	reqs = append(reqs, &CastRequest{genBinaryBallot(false, reqs[0].Data, k.PublicKey.X, k.PublicKey.Y, t), reqs[0].Data})
+	reqs[2].Ballot.(*BinaryBallot).hX = big.NewInt(1)
+	reqs = append(reqs, nil)
+
+	// cast one by one
+	serial, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, serial.Open())
+	var serialErrs []error
+	for _, r := range reqs[:len(reqs)-1] {
+		serialErrs = append(serialErrs, serial.Cast(r.Ballot, r.Data))
+	}
+
+	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
+
+	var done []int
+	receipts, errs, err := binaryVote.CastMany(context.Background(), reqs, 3, func(n, total int) {
+		assert.Equal(t, len(reqs), total)
+		done = append(done, n)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, done)
+	assert.Equal(t, serialErrs, errs[:len(reqs)-1])
+	assert.NotNil(t, errs[2])
+	assert.NotNil(t, errs[len(reqs)-1])
+	assert.Equal(t, serial.HX, binaryVote.HX)
+	assert.Equal(t, serial.YX, binaryVote.YX)
+	assert.Len(t, binaryVote.History(), 1)
+	assert.Equal(t, make([]*Receipt, len(reqs)), receipts)
+
+	// ballots not encrypted with g^k
+	addr := new(big.Int).SetBytes(getRandAddr())
+	_, errs, err = binaryVote.CastMany(context.Background(), []*CastRequest{
+		{genBinaryBallot(true, addr, other.PublicKey.X, other.PublicKey.Y, t), addr},
+	}, 0, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, errs[0])
+
+	// cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errs, err = binaryVote.CastMany(ctx, reqs[:2], 2, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []error{context.Canceled, context.Canceled}, errs)
+
+	assert.Nil(t, binaryVote.Close())
+	assert.Nil(t, binaryVote.Tally(k.D))
+	assert.Nil(t, binaryVote.VerifyTallyRes())
+	assert.Equal(t, 2, binaryVote.GetTallyRes().V)
+}
+
+func TestCastManyReceipts(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	op, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
+	assert.Nil(t, binaryVote.SetRevotePolicy(FirstWins))
+	assert.Nil(t, binaryVote.SetReceiptKey(op))
+
+	var reqs []*CastRequest
+	for _, value := range []bool{true, false, true} {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		b := genBinaryBallot(value, addr, k.PublicKey.X, k.PublicKey.Y, t)
+		reqs = append(reqs, &CastRequest{b, addr})
+	}
+	// a re-vote ignored under FirstWins
+	reqs = append(reqs, &CastRequest{genBinaryBallot(false, reqs[0].Data, k.PublicKey.X, k.PublicKey.Y, t), reqs[0].Data})
+
+	receipts, errs, err := binaryVote.CastMany(context.Background(), reqs, 2, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, make([]error, len(reqs)), errs)
+	assert.Nil(t, receipts[3])
+
+	root, size := binaryVote.CastLog()
+	assert.Equal(t, 3, size)
+	for i, rc := range receipts[:3] {
+		assert.Equal(t, i, rc.Index)
+		assert.Nil(t, rc.Verify(op.PublicKey.X, op.PublicKey.Y))
+		p, err := binaryVote.ProveCast(rc.Index)
+		assert.Nil(t, err)
+		assert.Nil(t, rc.VerifyLog(root, size, p))
+	}
+}
+
+func TestBinaryVoteConcurrentCast(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
+
+	n := 8
+	ballots := make([]*BinaryBallot, n)
+	addrs := make([]*big.Int, n)
+	for i := range ballots {
+		addrs[i] = new(big.Int).SetBytes(getRandAddr())
+		ballots[i] = genBinaryBallot(i%2 == 0, addrs[i], k.PublicKey.X, k.PublicKey.Y, t)
+	}
+
+	var wg sync.WaitGroup
+	for i := range ballots {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Nil(t, binaryVote.Cast(ballots[i], addrs[i]))
+			binaryVote.Phase()
+			binaryVote.BuildJSONBinaryVote()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Nil(t, binaryVote.Close())
+	assert.Nil(t, binaryVote.Tally(k.D))
+	assert.Equal(t, n/2, binaryVote.GetTallyRes().V)
+}
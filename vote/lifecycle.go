@@ -0,0 +1,133 @@
+package vote
+
+import (
+	"errors"
+	"time"
+)
+
+// Phase - phase of an election
+type Phase int
+
+// election phases, an election moves forward through them in order
+const (
+	Registration Phase = iota // setting up, no ballots accepted
+	Voting                    // ballots accepted
+	Closed                    // no more ballots, ready for tally
+	Tallied                   // result published
+)
+
+func (p Phase) String() string {
+	switch p {
+	case Registration:
+		return "registration"
+	case Voting:
+		return "voting"
+	case Closed:
+		return "closed"
+	case Tallied:
+		return "tallied"
+	}
+	return "unknown"
+}
+
+// parsePhase converts the string of a phase back
+func parsePhase(s string) (Phase, error) {
+	for p := Registration; p <= Tallied; p++ {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return 0, errors.New("Invalid phase")
+}
+
+// lifecycle enforces the phases of an election and the optional voting
+// period. It is embedded by BinaryVote, CumulativeVote and QuadraticVote.
+// OpenVote, whose registration phase ends with Close, keeps its own phase.
+type lifecycle struct {
+	phase      Phase
+	start, end time.Time // voting period, zero if unbounded
+	now        func() time.Time
+}
+
+func newLifecycle() lifecycle {
+	return lifecycle{now: time.Now}
+}
+
+// Phase returns the current phase
+func (l *lifecycle) Phase() Phase {
+	return l.phase
+}
+
+// SetClock replaces the clock against which the voting period is checked,
+// time.Now by default
+func (l *lifecycle) SetClock(now func() time.Time) {
+	l.now = now
+}
+
+// SetSchedule sets the voting period [start, end). Either can be zero to
+// leave that side unbounded. It can only be set during registration.
+func (l *lifecycle) SetSchedule(start, end time.Time) error {
+	if l.phase != Registration {
+		return errors.New("Schedule can only be set during registration")
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return errors.New("Invalid voting period")
+	}
+	l.start, l.end = start, end
+	return nil
+}
+
+// Schedule returns the voting period
+func (l *lifecycle) Schedule() (time.Time, time.Time) {
+	return l.start, l.end
+}
+
+// Open ends registration and starts accepting ballots
+func (l *lifecycle) Open() error {
+	if l.phase != Registration {
+		return errors.New("Vote not in registration")
+	}
+	l.phase = Voting
+	return nil
+}
+
+// Close stops accepting ballots. If an end time is set, it can't be closed
+// earlier.
+func (l *lifecycle) Close() error {
+	if l.phase != Voting {
+		return errors.New("Vote not open")
+	}
+	if !l.end.IsZero() && l.now().Before(l.end) {
+		return errors.New("Voting period not ended")
+	}
+	l.phase = Closed
+	return nil
+}
+
+// checkCast checks that a ballot can be cast now
+func (l *lifecycle) checkCast() error {
+	if l.phase != Voting {
+		return errors.New("Vote not open")
+	}
+
+	now := l.now()
+	if !l.start.IsZero() && now.Before(l.start) {
+		return errors.New("Voting period not started")
+	}
+	if !l.end.IsZero() && !now.Before(l.end) {
+		return errors.New("Voting period ended")
+	}
+
+	return nil
+}
+
+// checkTally checks that the vote can be tallied
+func (l *lifecycle) checkTally() error {
+	if l.phase == Tallied {
+		return errors.New("Vote already tallied")
+	}
+	if l.phase != Closed {
+		return errors.New("Vote not closed")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryVoteLifecycle(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Equal(t, Registration, binaryVote.Phase())
+
+	now := time.Unix(1000, 0)
+	binaryVote.SetClock(func() time.Time { return now })
+
+	start, end := time.Unix(2000, 0), time.Unix(3000, 0)
+	assert.NotNil(t, binaryVote.SetSchedule(end, start))
+	assert.Nil(t, binaryVote.SetSchedule(start, end))
+
+	voterAddr := new(big.Int).SetBytes(getRandAddr())
+	b := genBinaryBallot(true, voterAddr, k.PublicKey.X, k.PublicKey.Y, t)
+
+	// no ballots during registration
+	assert.NotNil(t, binaryVote.Cast(b, voterAddr))
+	assert.NotNil(t, binaryVote.Close())
+	assert.NotNil(t, binaryVote.Tally(k.D))
+
+	assert.Nil(t, binaryVote.Open())
+	assert.Equal(t, Voting, binaryVote.Phase())
+	assert.NotNil(t, binaryVote.Open())
+	assert.NotNil(t, binaryVote.SetSchedule(time.Time{}, time.Time{}))
+
+	// before the voting period
+	assert.NotNil(t, binaryVote.Cast(b, voterAddr))
+
+	now = start
+	assert.Nil(t, binaryVote.Cast(b, voterAddr))
+	assert.NotNil(t, binaryVote.Tally(k.D))
+
+	// can't close before the end
+	assert.NotNil(t, binaryVote.Close())
+
+	// save and restore during voting
+	data, err := json.Marshal(binaryVote)
+	assert.Nil(t, err)
+	restored := new(BinaryVote)
+	assert.Nil(t, json.Unmarshal(data, restored))
+	assert.Equal(t, Voting, restored.Phase())
+	assert.Equal(t, binaryVote.HX, restored.HX)
+	assert.Equal(t, binaryVote.YX, restored.YX)
+	s, e := restored.Schedule()
+	assert.True(t, s.Equal(start))
+	assert.True(t, e.Equal(end))
+	restored.SetClock(func() time.Time { return now })
+
+	// the restored vote goes on
+	voterAddr1 := new(big.Int).SetBytes(getRandAddr())
+	b1 := genBinaryBallot(false, voterAddr1, k.PublicKey.X, k.PublicKey.Y, t)
+	assert.Nil(t, restored.Cast(b1, voterAddr1))
+
+	// after the voting period
+	now = end
+	assert.NotNil(t, restored.Cast(b, voterAddr))
+	assert.Nil(t, restored.Close())
+	assert.Equal(t, Closed, restored.Phase())
+
+	assert.Nil(t, restored.Tally(k.D))
+	assert.Equal(t, Tallied, restored.Phase())
+	assert.Equal(t, 1, restored.GetTallyRes().V)
+
+	// no more ballots or tallies
+	assert.NotNil(t, restored.Tally(k.D))
+	assert.NotNil(t, restored.Cast(b1, voterAddr1))
+	assert.NotNil(t, restored.Open())
+
+	data, err = json.Marshal(restored)
+	assert.Nil(t, err)
+	tallied := new(BinaryVote)
+	assert.Nil(t, json.Unmarshal(data, tallied))
+	assert.Equal(t, Tallied, tallied.Phase())
+	assert.Equal(t, 1, tallied.GetTallyRes().V)
+	assert.Nil(t, tallied.VerifyTallyRes())
+
+	// phase and result must agree
+	var obj JSONBinaryVote
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.Phase = Closed.String()
+	assert.NotNil(t, new(BinaryVote).FromJSONBinaryVote(&obj))
+}
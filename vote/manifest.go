@@ -0,0 +1,189 @@
+package vote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
+)
+
+// Manifest - public description of an election
+//
+// The hash of the manifest is bound into every ballot and tally proof so
+// that they are invalid in any other election, even one run by the same
+// authority key.
+type Manifest struct {
+	ID         string
+	Question   string
+	Questions  []string  // questions answered on a ballot sheet, nil if none
+	Options    []string  // options[v] is the meaning of value v
+	GKX, GKY   *big.Int  // authority public key
+	Curve      string    // name of the elliptic curve
+	Registry   *[32]byte // root of the voter registry, nil if anyone may vote
+	Start, End time.Time // voting period, zero if unbounded
+}
+
+// NewManifest news a manifest for a yes/no election on the current curve.
+// Options default to "no" and "yes".
+func NewManifest(id, question string, options []string, gkX, gkY *big.Int) (*Manifest, error) {
+	if options == nil {
+		options = []string{"no", "yes"}
+	}
+
+	m := &Manifest{
+		ID:       id,
+		Question: question,
+		Options:  append([]string(nil), options...),
+		GKX:      new(big.Int).Set(gkX),
+		GKY:      new(big.Int).Set(gkY),
+		Curve:    curve.Params().Name,
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Validate checks the manifest
+func (m *Manifest) Validate() error {
+	if m.ID == "" {
+		return errors.New("Missing election id")
+	}
+	if len(m.Options) < 2 {
+		return errors.New("Not enough options")
+	}
+	for _, q := range m.Questions {
+		if q == "" {
+			return errors.New("Empty question")
+		}
+	}
+	if m.Curve != curve.Params().Name {
+		return errors.New("Elliptic curve doesn't match")
+	}
+	if m.GKX == nil || m.GKY == nil || !isOnCurve(m.GKX, m.GKY) {
+		return errors.New("Invalid g^k")
+	}
+	if !m.Start.IsZero() && !m.End.IsZero() && !m.Start.Before(m.End) {
+		return errors.New("Invalid voting period")
+	}
+	return nil
+}
+
+// Hash returns the hash of the manifest, i.e., sha256 of its json encoding
+func (m *Manifest) Hash() [32]byte {
+	data, _ := json.Marshal(m.BuildJSONManifest())
+	return sha256.Sum256(data)
+}
+
+// Params returns the election parameters of the manifest
+func (m *Manifest) Params(authData *big.Int) (*ElectionParams, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := NewElectionParams(m.GKX, m.GKY, authData)
+	if err != nil {
+		return nil, err
+	}
+	if m.Registry != nil {
+		if err := p.SetRegistry(*m.Registry); err != nil {
+			return nil, err
+		}
+	}
+	p.SetElection(m.Hash())
+
+	return p, nil
+}
+
+// checkContext checks that a proof is bound to the election ctx, or to no
+// election if ctx is nil
+func checkContext(ctx, proofCtx []byte) error {
+	if !bytes.Equal(ctx, proofCtx) {
+		return errors.New("Proof bound to another election")
+	}
+	return nil
+}
+
+// BuildJSONManifest builds json object
+func (m *Manifest) BuildJSONManifest() *JSONManifest {
+	obj := &JSONManifest{
+		ID:        m.ID,
+		Question:  m.Question,
+		Questions: m.Questions,
+		Options:   m.Options,
+		GKX:       common.BigIntToHexStr(m.GKX),
+		GKY:       common.BigIntToHexStr(m.GKY),
+		Curve:     m.Curve,
+	}
+	if m.Registry != nil {
+		obj.Registry = merkle.HashToHexStr(*m.Registry)
+	}
+	if !m.Start.IsZero() {
+		obj.Start = m.Start.Unix()
+	}
+	if !m.End.IsZero() {
+		obj.End = m.End.Unix()
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.BuildJSONManifest())
+}
+
+// FromJSONManifest reconstructs from json object
+func (m *Manifest) FromJSONManifest(obj *JSONManifest) error {
+	var err error
+
+	m1 := &Manifest{
+		ID:       obj.ID,
+		Question: obj.Question,
+		Options:  append([]string(nil), obj.Options...),
+		Curve:    obj.Curve,
+	}
+	if obj.Questions != nil {
+		m1.Questions = append([]string(nil), obj.Questions...)
+	}
+	if m1.GKX, err = common.HexStrToBigInt(obj.GKX); err != nil {
+		return err
+	}
+	if m1.GKY, err = common.HexStrToBigInt(obj.GKY); err != nil {
+		return err
+	}
+	if obj.Registry != "" {
+		root, err := merkle.HexStrToHash(obj.Registry)
+		if err != nil {
+			return err
+		}
+		m1.Registry = &root
+	}
+	if obj.Start != 0 {
+		m1.Start = time.Unix(obj.Start, 0)
+	}
+	if obj.End != 0 {
+		m1.End = time.Unix(obj.End, 0)
+	}
+	if err := m1.Validate(); err != nil {
+		return err
+	}
+
+	*m = *m1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (m *Manifest) UnmarshalJSON(data []byte) error {
+	var obj JSONManifest
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return m.FromJSONManifest(&obj)
+}
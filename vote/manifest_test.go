@@ -0,0 +1,121 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	_, err := NewManifest("", "Q", nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.NotNil(t, err)
+	_, err = NewManifest("e1", "Q", []string{"yes"}, k.PublicKey.X, k.PublicKey.Y)
+	assert.NotNil(t, err)
+
+	m1, err := NewManifest("e1", "Adopt the proposal?", nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	m1.End = time.Unix(time.Now().Unix()+3600, 0)
+	m2, err := NewManifest("e2", "Adopt the proposal?", nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	assert.NotEqual(t, m1.Hash(), m2.Hash())
+
+	data, err := json.Marshal(m1)
+	assert.Nil(t, err)
+	var m Manifest
+	assert.Nil(t, json.Unmarshal(data, &m))
+	assert.Equal(t, m1.Hash(), m.Hash())
+
+	binaryVote, err := NewBinaryVoteWithManifest(m1, authAddr)
+	assert.Nil(t, err)
+	_, end := binaryVote.Schedule()
+	assert.True(t, end.Equal(m1.End))
+	assert.Nil(t, binaryVote.Open())
+
+	var ballots []*BinaryBallot
+	for i := 0; i < 3; i++ {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		a, _ := ecdsa.GenerateKey(curve, rand.Reader)
+
+		// ballots of another election or of none
+		b, err := NewBinaryBallotInElection(m2, true, a.D, addr)
+		assert.Nil(t, err)
+		assert.Nil(t, b.VerifyBallot())
+		assert.NotNil(t, binaryVote.Cast(b, addr))
+		b = genBinaryBallot(true, addr, k.PublicKey.X, k.PublicKey.Y, t)
+		assert.NotNil(t, binaryVote.Cast(b, addr))
+
+		b, err = NewBinaryBallotInElection(m1, i < 2, a.D, addr)
+		assert.Nil(t, err)
+
+		// the binding survives json
+		data, err := json.Marshal(b)
+		assert.Nil(t, err)
+		b1 := new(BinaryBallot)
+		assert.Nil(t, json.Unmarshal(data, b1))
+		assert.Equal(t, b, b1)
+
+		assert.Nil(t, binaryVote.Cast(b1, addr))
+		ballots = append(ballots, b1)
+	}
+
+	// tallies of other elections reject the ballots
+	_, err = NewBinaryTally(k.PublicKey.X, k.PublicKey.Y, authAddr, ballots)
+	assert.NotNil(t, err)
+	p2, err := m2.Params(authAddr)
+	assert.Nil(t, err)
+	_, err = NewBinaryTallyWithParams(p2, ballots, 0)
+	assert.NotNil(t, err)
+
+	p1, err := m1.Params(authAddr)
+	assert.Nil(t, err)
+	tal, err := NewBinaryTallyWithParams(p1, ballots, 0)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res.V)
+	assert.Nil(t, res.Verify())
+	assert.Nil(t, res.CheckElection(m1))
+	assert.NotNil(t, res.CheckElection(m2))
+	assert.NotNil(t, res.CheckElection(nil))
+
+	// threshold decryption
+	pub, shares, err := SplitKey(k.D, 2, 3)
+	assert.Nil(t, err)
+	var partials []*PartialDecryption
+	for i, s := range shares {
+		mi := m1
+		if i == 0 {
+			mi = m2
+		}
+		d, err := s.PartialDecryptInElection(mi, tal.HX, tal.HY, new(big.Int).SetBytes(getRandAddr()))
+		assert.Nil(t, err)
+		partials = append(partials, d)
+	}
+	res1, err := tal.Combine(pub, partials)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res1.V)
+	assert.Nil(t, res1.CheckElection(m1))
+	_, err = tal.Combine(pub, partials[:2])
+	assert.NotNil(t, err)
+
+	data, err = json.Marshal(res)
+	assert.Nil(t, err)
+	var res2 BinaryTallyRes
+	assert.Nil(t, json.Unmarshal(data, &res2))
+	assert.Nil(t, res2.Verify())
+	assert.Nil(t, res2.CheckElection(m1))
+
+	binaryVote.SetClock(func() time.Time { return m1.End })
+	assert.Nil(t, binaryVote.Close())
+	assert.Nil(t, binaryVote.Tally(k.D))
+	assert.Nil(t, binaryVote.VerifyTallyRes())
+	assert.Nil(t, binaryVote.GetTallyRes().CheckElection(m1))
+}
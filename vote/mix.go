@@ -0,0 +1,438 @@
+package vote
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// Mix - verifiable shuffle of encrypted ballots
+//
+// The output is a secret permutation of re-encryptions of the input, with
+// a proof of shuffle bound to the election. Mixes can be chained, each mix
+// server shuffling the output of the previous one, so that no single
+// server can link the decrypted ballots to the voters.
+type Mix struct {
+	gkX, gkY *big.Int
+	election []byte // hash of the election manifest, nil if none
+
+	in, out []*zk.Ciphertext
+	proof   *zk.ShuffleProof
+}
+
+// MixInput verifies ballots and returns their ciphertexts to be mixed.
+// Weighted ballots can't be mixed since each would decrypt to its weight.
+func MixInput(p *ElectionParams, ballots []*BinaryBallot) ([]*zk.Ciphertext, error) {
+	if err := p.checkVoters(len(ballots)); err != nil {
+		return nil, err
+	}
+
+	voters := make(map[[32]byte]bool)
+	in := make([]*zk.Ciphertext, len(ballots))
+	for i, b := range ballots {
+		if err := b.VerifyBallot(); err != nil {
+			return nil, err
+		}
+		w, err := p.checkBallot(b)
+		if err != nil {
+			return nil, err
+		}
+		if w != 1 {
+			return nil, errors.New("Weighted ballots can't be mixed")
+		}
+		if p.uniqueVoters() {
+			id := voterID(b)
+			if voters[id] {
+				return nil, errors.New("Duplicate voter")
+			}
+			voters[id] = true
+		}
+
+		in[i] = b.ciphertext()
+	}
+	return in, nil
+}
+
+// NewMix shuffles and re-encrypts the ciphertexts in, e.g., returned by
+// MixInput or the output of another mix
+func NewMix(p *ElectionParams, in []*zk.Ciphertext) (*Mix, error) {
+	n := len(in)
+	if n == 0 {
+		return nil, errors.New("No ciphertexts")
+	}
+
+	// random permutation, Fisher-Yates
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		perm[i], perm[j.Int64()] = perm[j.Int64()], perm[i]
+	}
+
+	out := make([]*zk.Ciphertext, n)
+	r := make([]*big.Int, n)
+	for i, j := range perm {
+		var err error
+		if r[i], err = randScalar(); err != nil {
+			return nil, err
+		}
+		out[i] = in[j].ReEncrypt(p.gkX, p.gkY, r[i])
+	}
+
+	zk.SetEllipticCurve(curve)
+	prover, err := zk.NewShuffleProver(p.gkX, p.gkY, in, out, perm, r)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := prover.ProveInContext(p.context())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mix{
+		gkX:      new(big.Int).Set(p.gkX),
+		gkY:      new(big.Int).Set(p.gkY),
+		election: p.context(),
+		in:       in,
+		out:      out,
+		proof:    proof,
+	}, nil
+}
+
+// Input returns the ciphertexts mixed
+func (m *Mix) Input() []*zk.Ciphertext {
+	return append([]*zk.Ciphertext(nil), m.in...)
+}
+
+// Output returns the shuffled ciphertexts
+func (m *Mix) Output() []*zk.Ciphertext {
+	return append([]*zk.Ciphertext(nil), m.out...)
+}
+
+// Verify verifies the proof of shuffle of the mix in the election
+func (m *Mix) Verify(p *ElectionParams) error {
+	if m.gkX.Cmp(p.gkX) != 0 || m.gkY.Cmp(p.gkY) != 0 {
+		return errors.New("Mix of different g^k")
+	}
+	if err := checkContext(p.context(), m.election); err != nil {
+		return err
+	}
+
+	pkX, pkY := m.proof.PublicKey()
+	if pkX.Cmp(m.gkX) != 0 || pkY.Cmp(m.gkY) != 0 {
+		return errors.New("Invalid proof key")
+	}
+	if !bytes.Equal(m.proof.Context(), m.election) {
+		return errors.New("Invalid proof context")
+	}
+
+	zk.SetEllipticCurve(curve)
+	res, err := m.proof.Verify(m.in, m.out)
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Invalid proof of shuffle")
+	}
+	return nil
+}
+
+// VerifyMixes verifies a chain of mixes of ballots: the first mixes the
+// ciphertexts of ballots and each one mixes the output of the previous one
+func VerifyMixes(p *ElectionParams, ballots []*BinaryBallot, mixes []*Mix) error {
+	if len(mixes) == 0 {
+		return errors.New("No mixes")
+	}
+
+	in, err := MixInput(p, ballots)
+	if err != nil {
+		return err
+	}
+	for i, m := range mixes {
+		if !sameCiphertexts(in, m.in) {
+			return fmt.Errorf("Mix [%d]: input doesn't match", i)
+		}
+		if err := m.Verify(p); err != nil {
+			return fmt.Errorf("Mix [%d]: %v", i, err)
+		}
+		in = m.out
+	}
+	return nil
+}
+
+func sameCiphertexts(a, b []*zk.Ciphertext) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].HX.Cmp(b[i].HX) != 0 || a[i].HY.Cmp(b[i].HY) != 0 ||
+			a[i].YX.Cmp(b[i].YX) != 0 || a[i].YY.Cmp(b[i].YY) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MixDecryption - verifiable decryption of the output of a mix
+//
+// Each ciphertext (h, y) of the output is decrypted to X = h^k with a proof,
+// bound to the election, of log_g(g^k) = log_h(X). It is a yes vote if
+// y = X * g and a no vote if y = X.
+type MixDecryption struct {
+	values []bool
+	proofs []*zk.DLEQProof
+}
+
+// DecryptMix decrypts the output of m one by one with the authority key k
+func DecryptMix(p *ElectionParams, k *big.Int, m *Mix) (*MixDecryption, error) {
+	if !isInRange(k) {
+		return nil, errors.New("Invalid k")
+	}
+	if x, y := curve.ScalarBaseMult(k.Bytes()); x.Cmp(p.gkX) != 0 || y.Cmp(p.gkY) != 0 {
+		return nil, errors.New("k doesn't match g^k")
+	}
+	if err := m.Verify(p); err != nil {
+		return nil, err
+	}
+
+	d := &MixDecryption{
+		values: make([]bool, len(m.out)),
+		proofs: make([]*zk.DLEQProof, len(m.out)),
+	}
+	for i, e := range m.out {
+		prover, err := zk.NewDLEQProver(k, curve.Params().Gx, curve.Params().Gy, e.HX, e.HY)
+		if err != nil {
+			return nil, err
+		}
+		if d.proofs[i], err = prover.ProveInContext(p.context(), p.authData); err != nil {
+			return nil, err
+		}
+
+		_, _, XX, XY := d.proofs[i].Values()
+		if d.values[i], err = decryptedValue(e, XX, XY); err != nil {
+			return nil, fmt.Errorf("Ciphertext [%d]: %v", i, err)
+		}
+	}
+
+	return d, nil
+}
+
+// decryptedValue returns the vote of e given X = h^k
+func decryptedValue(e *zk.Ciphertext, XX, XY *big.Int) (bool, error) {
+	if XX.Cmp(e.YX) == 0 && XY.Cmp(e.YY) == 0 {
+		return false, nil
+	}
+	X, Y := curve.Add(XX, XY, curve.Params().Gx, curve.Params().Gy)
+	if X.Cmp(e.YX) == 0 && Y.Cmp(e.YY) == 0 {
+		return true, nil
+	}
+	return false, errors.New("Invalid vote")
+}
+
+// Values returns the votes in the order of the output of the mix
+func (d *MixDecryption) Values() []bool {
+	return append([]bool(nil), d.values...)
+}
+
+// Verify checks that d is the decryption of the output of m with the
+// authority key of the election
+func (d *MixDecryption) Verify(p *ElectionParams, m *Mix) error {
+	if err := m.Verify(p); err != nil {
+		return err
+	}
+	if len(d.values) != len(m.out) || len(d.proofs) != len(m.out) {
+		return errors.New("Numbers of ciphertexts and decryptions don't match")
+	}
+
+	zk.SetEllipticCurve(curve)
+	for i, e := range m.out {
+		if err := d.verifyOne(p, i, e); err != nil {
+			return fmt.Errorf("Ciphertext [%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// verifyOne checks the decryption of the i-th ciphertext e
+func (d *MixDecryption) verifyOne(p *ElectionParams, i int, e *zk.Ciphertext) error {
+	proof := d.proofs[i]
+	g1X, g1Y, g2X, g2Y := proof.Bases()
+	y1X, y1Y, XX, XY := proof.Values()
+	if g1X.Cmp(curve.Params().Gx) != 0 || g1Y.Cmp(curve.Params().Gy) != 0 ||
+		g2X.Cmp(e.HX) != 0 || g2Y.Cmp(e.HY) != 0 ||
+		y1X.Cmp(p.gkX) != 0 || y1Y.Cmp(p.gkY) != 0 {
+		return errors.New("Invalid zkp bases")
+	}
+	if err := checkContext(p.context(), proof.Context()); err != nil {
+		return err
+	}
+	if res, err := proof.Verify(); err != nil || !res {
+		return errors.New("Invalid zkp of decryption")
+	}
+
+	v, err := decryptedValue(e, XX, XY)
+	if err != nil {
+		return err
+	}
+	if v != d.values[i] {
+		return errors.New("Vote doesn't match decryption")
+	}
+	return nil
+}
+
+// BuildJSONMixDecryption builds json object
+func (d *MixDecryption) BuildJSONMixDecryption() *JSONMixDecryption {
+	obj := &JSONMixDecryption{
+		Values: make([]int, len(d.values)),
+		Proofs: make([]*zk.JSONDLEQProof, len(d.proofs)),
+	}
+	for i, v := range d.values {
+		if v {
+			obj.Values[i] = 1
+		}
+		obj.Proofs[i] = d.proofs[i].BuildJSONDLEQProof()
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (d *MixDecryption) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.BuildJSONMixDecryption())
+}
+
+// FromJSONMixDecryption reconstructs from json object
+func (d *MixDecryption) FromJSONMixDecryption(obj *JSONMixDecryption) error {
+	if len(obj.Values) != len(obj.Proofs) {
+		return errors.New("Numbers of votes and proofs don't match")
+	}
+
+	d1 := &MixDecryption{
+		values: make([]bool, len(obj.Values)),
+		proofs: make([]*zk.DLEQProof, len(obj.Proofs)),
+	}
+	for i, v := range obj.Values {
+		if v != 0 && v != 1 {
+			return errors.New("Invalid vote")
+		}
+		d1.values[i] = v == 1
+
+		if obj.Proofs[i] == nil {
+			return errors.New("Missing zkp")
+		}
+		d1.proofs[i] = new(zk.DLEQProof)
+		if err := d1.proofs[i].FromJSONDLEQProof(obj.Proofs[i]); err != nil {
+			return err
+		}
+	}
+
+	*d = *d1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (d *MixDecryption) UnmarshalJSON(data []byte) error {
+	var obj JSONMixDecryption
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return d.FromJSONMixDecryption(&obj)
+}
+
+// BuildJSONMix builds json object
+func (m *Mix) BuildJSONMix() *JSONMix {
+	ciphertexts := func(l []*zk.Ciphertext) []*JSONCiphertext {
+		obj := make([]*JSONCiphertext, len(l))
+		for i, e := range l {
+			obj[i] = &JSONCiphertext{H: newJSONPoint(e.HX, e.HY), Y: newJSONPoint(e.YX, e.YY)}
+		}
+		return obj
+	}
+
+	obj := &JSONMix{
+		GKX:    common.BigIntToHexStr(m.gkX),
+		GKY:    common.BigIntToHexStr(m.gkY),
+		Input:  ciphertexts(m.in),
+		Output: ciphertexts(m.out),
+		Proof:  m.proof.BuildJSONShuffleProof(),
+	}
+	if m.election != nil {
+		obj.Election = common.BytesToHexStr(m.election)
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (m *Mix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.BuildJSONMix())
+}
+
+// FromJSONMix reconstructs from json object
+func (m *Mix) FromJSONMix(obj *JSONMix) error {
+	var err error
+
+	m1 := &Mix{proof: new(zk.ShuffleProof)}
+	if m1.gkX, err = common.HexStrToBigInt(obj.GKX); err != nil {
+		return err
+	}
+	if m1.gkY, err = common.HexStrToBigInt(obj.GKY); err != nil {
+		return err
+	}
+	if !isOnCurve(m1.gkX, m1.gkY) {
+		return errors.New("Invalid g^k")
+	}
+	if obj.Election != "" {
+		if m1.election, err = common.HexStrToBytes(obj.Election); err != nil {
+			return err
+		}
+	}
+
+	lists := []struct {
+		dst *[]*zk.Ciphertext
+		src []*JSONCiphertext
+	}{{&m1.in, obj.Input}, {&m1.out, obj.Output}}
+	for _, l := range lists {
+		*l.dst = make([]*zk.Ciphertext, len(l.src))
+		for i, c := range l.src {
+			if c == nil {
+				return errors.New("Missing ciphertext")
+			}
+			e := new(zk.Ciphertext)
+			if e.HX, e.HY, err = c.H.toPoint(); err != nil {
+				return err
+			}
+			if e.YX, e.YY, err = c.Y.toPoint(); err != nil {
+				return err
+			}
+			(*l.dst)[i] = e
+		}
+	}
+
+	if err := m1.proof.FromJSONShuffleProof(obj.Proof); err != nil {
+		return err
+	}
+
+	*m = *m1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (m *Mix) UnmarshalJSON(data []byte) error {
+	var obj JSONMix
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return m.FromJSONMix(&obj)
+}
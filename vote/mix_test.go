@@ -0,0 +1,82 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMix(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+
+	var ballots []*BinaryBallot
+	for _, value := range []bool{true, false, true, true} {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		ballots = append(ballots, genBinaryBallot(value, addr, k.PublicKey.X, k.PublicKey.Y, t))
+	}
+
+	in, err := MixInput(p, ballots)
+	assert.Nil(t, err)
+	m1, err := NewMix(p, in)
+	assert.Nil(t, err)
+	m2, err := NewMix(p, m1.Output())
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(m2)
+	assert.Nil(t, err)
+	m2r := new(Mix)
+	assert.Nil(t, json.Unmarshal(data, m2r))
+
+	assert.Nil(t, VerifyMixes(p, ballots, []*Mix{m1, m2r}))
+	assert.NotNil(t, VerifyMixes(p, ballots, []*Mix{m2r, m1}))
+	assert.NotNil(t, VerifyMixes(p, ballots[1:], []*Mix{m1, m2r}))
+
+	// the output decrypts to the same values, y / h^k = g^v
+	d, err := DecryptMix(p, k.D, m2r)
+	assert.Nil(t, err)
+	yes := 0
+	for _, v := range d.Values() {
+		if v {
+			yes++
+		}
+	}
+	assert.Equal(t, 3, yes)
+
+	data, err = json.Marshal(d)
+	assert.Nil(t, err)
+	dr := new(MixDecryption)
+	assert.Nil(t, json.Unmarshal(data, dr))
+	assert.Nil(t, dr.Verify(p, m2r))
+	assert.NotNil(t, dr.Verify(p, m1))
+
+	// flipped vote or proofs swapped between ciphertexts
+	dr.values[0] = !dr.values[0]
+	assert.NotNil(t, dr.Verify(p, m2r))
+	dr.values[0] = !dr.values[0]
+	dr.proofs[0], dr.proofs[1] = dr.proofs[1], dr.proofs[0]
+	assert.NotNil(t, dr.Verify(p, m2r))
+
+	k1, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	_, err = DecryptMix(p, k1.D, m2r)
+	assert.NotNil(t, err)
+
+	// another election
+	p1, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	p1.SetElection([32]byte{1})
+	assert.NotNil(t, m1.Verify(p1))
+
+	// tampered output
+	out := m2r.out[0]
+	m2r.out[0] = m2r.out[1]
+	m2r.out[1] = out
+	assert.NotNil(t, m2r.Verify(p))
+}
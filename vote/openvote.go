@@ -0,0 +1,535 @@
+package vote
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// OpenVote - self-tallying yes-or-no vote without an authority (Open Vote
+// Network)
+//
+// In the first round, voter i registers g^{x_i} with a proof of knowledge of
+// x_i. Once registration is closed, voter i computes
+//
+//	g^{y_i} = prod_{j<i} g^{x_j} / prod_{j>i} g^{x_j}
+//
+// and casts a binary ballot (g^{x_i}, g^{x_i*y_i} * g^{v_i}) encrypted with
+// g^{y_i}. Since sum_i x_i*y_i = 0, anyone can compute
+// prod_i g^{x_i*y_i} * g^{v_i} = g^V and recover V.
+//
+// If registered voters fail to cast, the remaining voters publish
+// recovery values (g^{x_j})^{x_i} for each missing voter j, which cancel
+// out the terms x_i*x_j left in the product.
+//
+// The vote moves through the phases Registration, Voting, which Close
+// starts, and Tallied, which no ballots are cast in.
+//
+// Voters can't cast again (Reject): h = g^{x_i} and the mask g^{x_i*y_i}
+// are fixed per voter, so the y values of two ballots of a voter would
+// reveal g^{v2-v1}.
+type OpenVote struct {
+	keys  []*OpenVoteKey   // registered keys in order
+	index map[[32]byte]int // sha256(data) => position in keys
+	phase Phase
+
+	ballots  map[int]*BinaryBallot
+	recovery map[[2]int]*OpenVoteRecovery // (i, j) => (g^{x_j})^{x_i}
+	ctx      []byte                       // election bound into keys, ballots and recovery values, nil if none
+	revoteLog
+}
+
+// OpenVoteKey - voting key g^x registered by a voter with a proof of
+// knowledge of x bound to the voter
+type OpenVoteKey struct {
+	pok *zk.ECFSProof
+}
+
+// OpenVoteRecovery - recovery value R_ij = (g^{x_j})^{x_i} published by
+// voter i for voter j who registered but did not cast, with a proof of
+// log_g g^{x_i} = log_{g^{x_j}} R_ij
+type OpenVoteRecovery struct {
+	proof *zk.DLEQProof
+}
+
+// NewOpenVoteKey generates the voting key g^x
+//
+// data contains the data (e.g., account address) that identifies the voter.
+func NewOpenVoteKey(x, data *big.Int) (*OpenVoteKey, error) {
+	return newOpenVoteKey(x, data, nil)
+}
+
+// NewOpenVoteKeyInElection generates the voting key g^x bound to the
+// election with the given manifest hash
+func NewOpenVoteKeyInElection(election [32]byte, x, data *big.Int) (*OpenVoteKey, error) {
+	return newOpenVoteKey(x, data, election[:])
+}
+
+func newOpenVoteKey(x, data *big.Int, ctx []byte) (*OpenVoteKey, error) {
+	if !isInRange(x) {
+		return nil, errors.New("Invalid x")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	prover, err := zk.NewECFSProver(x, curve.Params().Gx, curve.Params().Gy)
+	if err != nil {
+		return nil, err
+	}
+	pok, err := prover.ProveInContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenVoteKey{pok}, nil
+}
+
+// Verify verifies the proof of knowledge of x
+func (k *OpenVoteKey) Verify() error {
+	if k.pok == nil {
+		return errors.New("Invalid voting key")
+	}
+
+	hX, hY := k.pok.Base()
+	if hX.Cmp(curve.Params().Gx) != 0 || hY.Cmp(curve.Params().Gy) != 0 {
+		return errors.New("Invalid base")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	res, err := k.pok.Verify()
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Failed to verify voting key")
+	}
+
+	return nil
+}
+
+// PublicKey returns g^x
+func (k *OpenVoteKey) PublicKey() (*big.Int, *big.Int) {
+	return k.pok.Value()
+}
+
+// Data returns the data that identifies the voter
+func (k *OpenVoteKey) Data() *big.Int {
+	return k.pok.Data()
+}
+
+// OpenVotePublicKey computes g^{y_i} for the i-th (from 0) registered key
+func OpenVotePublicKey(keys []*OpenVoteKey, i int) (*big.Int, *big.Int, error) {
+	if i < 0 || i >= len(keys) {
+		return nil, nil, errors.New("Invalid index")
+	}
+
+	X, Y := new(big.Int), new(big.Int)
+	for j, k := range keys {
+		if j == i {
+			continue
+		}
+
+		kX, kY := k.PublicKey()
+		if j > i {
+			kX, kY = ecinv(kX, kY)
+		}
+		X, Y = curve.Add(X, Y, kX, kY)
+	}
+
+	if !isOnCurve(X, Y) {
+		return nil, nil, errors.New("Invalid g^y")
+	}
+
+	return X, Y, nil
+}
+
+// NewOpenVoteBallot generates the ballot of a registered voter
+func NewOpenVoteBallot(value bool, x *big.Int, keys []*OpenVoteKey, data *big.Int) (*BinaryBallot, error) {
+	return newOpenVoteBallot(value, x, keys, data, nil)
+}
+
+// NewOpenVoteBallotInElection generates the ballot of a registered voter
+// bound to the election with the given manifest hash
+func NewOpenVoteBallotInElection(election [32]byte, value bool, x *big.Int, keys []*OpenVoteKey, data *big.Int) (*BinaryBallot, error) {
+	return newOpenVoteBallot(value, x, keys, data, election[:])
+}
+
+func newOpenVoteBallot(value bool, x *big.Int, keys []*OpenVoteKey, data *big.Int, ctx []byte) (*BinaryBallot, error) {
+	if !isInRange(x) {
+		return nil, errors.New("Invalid x")
+	}
+
+	gxX, gxY := curve.ScalarBaseMult(x.Bytes())
+
+	for i, k := range keys {
+		kX, kY := k.PublicKey()
+		if kX.Cmp(gxX) != 0 || kY.Cmp(gxY) != 0 || k.Data().Cmp(data) != 0 {
+			continue
+		}
+
+		gyX, gyY, err := OpenVotePublicKey(keys, i)
+		if err != nil {
+			return nil, err
+		}
+
+		return newBinaryBallot(value, x, gyX, gyY, data, ctx)
+	}
+
+	return nil, errors.New("Voting key not registered")
+}
+
+// NewOpenVoteRecovery computes the recovery value of the voter with secret
+// x for the missing voter with key k
+func NewOpenVoteRecovery(x *big.Int, k *OpenVoteKey, data *big.Int) (*OpenVoteRecovery, error) {
+	return newOpenVoteRecovery(x, k, data, nil)
+}
+
+// NewOpenVoteRecoveryInElection computes the recovery value bound to the
+// election with the given manifest hash
+func NewOpenVoteRecoveryInElection(election [32]byte, x *big.Int, k *OpenVoteKey, data *big.Int) (*OpenVoteRecovery, error) {
+	return newOpenVoteRecovery(x, k, data, election[:])
+}
+
+func newOpenVoteRecovery(x *big.Int, k *OpenVoteKey, data *big.Int, ctx []byte) (*OpenVoteRecovery, error) {
+	if !isInRange(x) {
+		return nil, errors.New("Invalid x")
+	}
+
+	kX, kY := k.PublicKey()
+
+	zk.SetEllipticCurve(curve)
+
+	prover, err := zk.NewDLEQProver(x, curve.Params().Gx, curve.Params().Gy, kX, kY)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := prover.ProveInContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenVoteRecovery{proof}, nil
+}
+
+// NewOpenVote news an open vote
+func NewOpenVote() *OpenVote {
+	v := &OpenVote{
+		index:     make(map[[32]byte]int),
+		ballots:   make(map[int]*BinaryBallot),
+		recovery:  make(map[[2]int]*OpenVoteRecovery),
+		revoteLog: newRevoteLog(),
+	}
+	v.policy = Reject
+	return v
+}
+
+// SetRevotePolicy only accepts Reject, see OpenVote
+func (v *OpenVote) SetRevotePolicy(p RevotePolicy) error {
+	if p != Reject {
+		return errors.New("Re-vote policy not supported")
+	}
+	return v.revoteLog.SetRevotePolicy(p)
+}
+
+// SetElection binds the vote to the election with the given manifest hash.
+// Only keys, ballots and recovery values bound to the election are
+// accepted. It can only be set before any key is registered.
+func (v *OpenVote) SetElection(hash [32]byte) error {
+	if v.phase != Registration || len(v.keys) > 0 {
+		return errors.New("Election can only be set before registration")
+	}
+	v.ctx = hash[:]
+	return nil
+}
+
+// Register registers a voting key
+func (v *OpenVote) Register(k *OpenVoteKey) error {
+	if v.phase != Registration {
+		return errors.New("Registration closed")
+	}
+
+	if err := k.Verify(); err != nil {
+		return err
+	}
+	if err := checkContext(v.ctx, k.pok.Context()); err != nil {
+		return err
+	}
+
+	id := sha256.Sum256(k.Data().Bytes())
+	if _, ok := v.index[id]; ok {
+		return errors.New("Voter already registered")
+	}
+
+	kX, kY := k.PublicKey()
+	for _, k1 := range v.keys {
+		X, Y := k1.PublicKey()
+		if X.Cmp(kX) == 0 && Y.Cmp(kY) == 0 {
+			return errors.New("Voting key already registered")
+		}
+	}
+
+	v.index[id] = len(v.keys)
+	v.keys = append(v.keys, k)
+
+	return nil
+}
+
+// Close closes registration so that voters can compute g^{y_i}
+func (v *OpenVote) Close() error {
+	if v.phase != Registration {
+		return errors.New("Registration closed")
+	}
+	if len(v.keys) < 2 {
+		return errors.New("Not enough voters")
+	}
+	v.phase = Voting
+	return nil
+}
+
+// Phase returns the current phase
+func (v *OpenVote) Phase() Phase {
+	return v.phase
+}
+
+// Keys returns the registered keys in order
+func (v *OpenVote) Keys() []*OpenVoteKey {
+	return append([]*OpenVoteKey(nil), v.keys...)
+}
+
+// Cast casts the ballot of a registered voter. Ballots are refused once
+// recovery has started since the recovery values published would reveal
+// the votes of late voters.
+func (v *OpenVote) Cast(bt Ballot, data *big.Int) error {
+	if v.phase != Voting {
+		return errors.New("Vote not open")
+	}
+	if len(v.recovery) > 0 {
+		return errors.New("Vote in recovery")
+	}
+
+	b, ok := bt.(*BinaryBallot)
+	if !ok {
+		return errors.New("Invalid ballot type")
+	}
+
+	id := sha256.Sum256(data.Bytes())
+	i, ok := v.index[id]
+	if !ok {
+		return errors.New("Voter not registered")
+	}
+
+	// h = g^{x_i}
+	kX, kY := v.keys[i].PublicKey()
+	if b.hX.Cmp(kX) != 0 || b.hY.Cmp(kY) != 0 {
+		return errors.New("Ballot doesn't match voting key")
+	}
+
+	// encrypted with g^{y_i} and bound to the voter
+	gyX, gyY, err := OpenVotePublicKey(v.keys, i)
+	if err != nil {
+		return err
+	}
+	pkX, pkY := b.proof.PublicKey()
+	if pkX.Cmp(gyX) != 0 || pkY.Cmp(gyY) != 0 {
+		return errors.New("Ballot not encrypted with g^y")
+	}
+	if b.proof.Data().Cmp(data) != 0 {
+		return errors.New("Ballot not bound to voter")
+	}
+	if err := checkContext(v.ctx, b.proof.Context()); err != nil {
+		return err
+	}
+
+	if err := b.VerifyBallot(); err != nil {
+		return err
+	}
+
+	if counted, err := v.admit(id, data, b); err != nil || !counted {
+		return err
+	}
+
+	v.ballots[i] = b
+
+	return nil
+}
+
+// Missing returns the keys of the registered voters that have not cast
+func (v *OpenVote) Missing() []*OpenVoteKey {
+	var missing []*OpenVoteKey
+	for i, k := range v.keys {
+		if _, ok := v.ballots[i]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	return missing
+}
+
+// Recover accepts a recovery value published by a voter that has cast for
+// a voter that has not
+func (v *OpenVote) Recover(r *OpenVoteRecovery) error {
+	if v.phase != Voting {
+		return errors.New("Vote not open")
+	}
+	if r.proof == nil {
+		return errors.New("Invalid recovery")
+	}
+	if err := checkContext(v.ctx, r.proof.Context()); err != nil {
+		return err
+	}
+
+	i, ok := v.index[sha256.Sum256(r.proof.Data().Bytes())]
+	if !ok {
+		return errors.New("Voter not registered")
+	}
+	if _, ok := v.ballots[i]; !ok {
+		return errors.New("Voter has not cast")
+	}
+
+	g1X, g1Y, g2X, g2Y := r.proof.Bases()
+	y1X, y1Y, _, _ := r.proof.Values()
+
+	kX, kY := v.keys[i].PublicKey()
+	if g1X.Cmp(curve.Params().Gx) != 0 || g1Y.Cmp(curve.Params().Gy) != 0 ||
+		y1X.Cmp(kX) != 0 || y1Y.Cmp(kY) != 0 {
+		return errors.New("Recovery doesn't match voting key")
+	}
+
+	j := -1
+	for m, k := range v.keys {
+		X, Y := k.PublicKey()
+		if X.Cmp(g2X) == 0 && Y.Cmp(g2Y) == 0 {
+			j = m
+			break
+		}
+	}
+	if j < 0 {
+		return errors.New("Unknown missing voter")
+	}
+	if _, ok := v.ballots[j]; ok {
+		return errors.New("Voter not missing")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	res, err := r.proof.Verify()
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Failed to verify recovery")
+	}
+
+	v.recovery[[2]int{i, j}] = r
+
+	return nil
+}
+
+// Tally computes the number of yes votes
+//
+// Recovery values are required from every voter that has cast for every
+// voter that has not.
+func (v *OpenVote) Tally() (int, error) {
+	if v.phase == Registration {
+		return 0, errors.New("Registration not closed")
+	}
+	if v.phase == Tallied {
+		return 0, errors.New("Vote already tallied")
+	}
+
+	YX, YY := new(big.Int), new(big.Int)
+	for i, b := range v.ballots {
+		YX, YY = curve.Add(YX, YY, b.yX, b.yY)
+
+		for j := range v.keys {
+			if _, ok := v.ballots[j]; ok {
+				continue
+			}
+
+			r, ok := v.recovery[[2]int{i, j}]
+			if !ok {
+				return 0, fmt.Errorf("Missing recovery of voter %d for voter %d", i, j)
+			}
+
+			// y_i contains +x_j if j < i and -x_j if j > i
+			_, _, RX, RY := r.proof.Values()
+			if j < i {
+				RX, RY = ecinv(RX, RY)
+			}
+			YX, YY = curve.Add(YX, YY, RX, RY)
+		}
+	}
+
+	V, err := dlogSolver.Solve(YX, YY, uint64(len(v.ballots)))
+	if err != nil {
+		return 0, errors.New("Tally failed")
+	}
+	v.phase = Tallied
+
+	return int(V), nil
+}
+
+// BuildJSONOpenVoteKey builds json object
+func (k *OpenVoteKey) BuildJSONOpenVoteKey() *JSONOpenVoteKey {
+	_p := k.pok.BuildJSONJSONECFSProof()
+
+	return &JSONOpenVoteKey{
+		GXX:  _p.YX,
+		GXY:  _p.YY,
+		Data: _p.Data,
+		TX:   _p.TX,
+		TY:   _p.TY,
+		R:    _p.R,
+		Ctx:  _p.Ctx,
+	}
+}
+
+// MarshalJSON implements json marshal
+func (k *OpenVoteKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.BuildJSONOpenVoteKey())
+}
+
+// FromJSONOpenVoteKey reconstructs from json object
+func (k *OpenVoteKey) FromJSONOpenVoteKey(obj *JSONOpenVoteKey) error {
+	k.pok = new(zk.ECFSProof)
+	return k.pok.FromJSONECFSProof(&zk.JSONECFSProof{
+		Data: obj.Data,
+		HX:   common.BigIntToHexStr(curve.Params().Gx),
+		HY:   common.BigIntToHexStr(curve.Params().Gy),
+		YX:   obj.GXX,
+		YY:   obj.GXY,
+		TX:   obj.TX,
+		TY:   obj.TY,
+		R:    obj.R,
+		Ctx:  obj.Ctx,
+	})
+}
+
+// UnmarshalJSON implements json unmarshal
+func (k *OpenVoteKey) UnmarshalJSON(data []byte) error {
+	var obj JSONOpenVoteKey
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return k.FromJSONOpenVoteKey(&obj)
+}
+
+// MarshalJSON implements json marshal
+func (r *OpenVoteRecovery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.proof.BuildJSONDLEQProof())
+}
+
+// UnmarshalJSON implements json unmarshal
+func (r *OpenVoteRecovery) UnmarshalJSON(data []byte) error {
+	var obj zk.JSONDLEQProof
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	r.proof = new(zk.DLEQProof)
+	return r.proof.FromJSONDLEQProof(&obj)
+}
@@ -0,0 +1,225 @@
+package vote
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type openVoter struct {
+	x    *big.Int
+	data *big.Int
+	key  *OpenVoteKey
+}
+
+func registerOpenVoters(v *OpenVote, n int, t *testing.T) []*openVoter {
+	voters := make([]*openVoter, n)
+	for i := range voters {
+		x, err := randScalar()
+		assert.Nil(t, err)
+		data := new(big.Int).SetBytes(getRandAddr())
+
+		k, err := NewOpenVoteKey(x, data)
+		assert.Nil(t, err)
+		assert.Nil(t, v.Register(k))
+
+		voters[i] = &openVoter{x, data, k}
+	}
+	assert.Nil(t, v.Close())
+
+	return voters
+}
+
+func TestOpenVote(t *testing.T) {
+	v := NewOpenVote()
+	voters := registerOpenVoters(v, 5, t)
+
+	// registering after close
+	k, err := NewOpenVoteKey(voters[0].x, big.NewInt(1))
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Register(k))
+
+	V := 0
+	for i, voter := range voters {
+		b, err := NewOpenVoteBallot(i%3 != 0, voter.x, v.Keys(), voter.data)
+		assert.Nil(t, err)
+		assert.Nil(t, v.Cast(b, voter.data))
+		if i%3 != 0 {
+			V = V + 1
+		}
+	}
+
+	// casting again is rejected and the counted ballot is kept
+	assert.Equal(t, Reject, v.RevotePolicy())
+	assert.NotNil(t, v.SetRevotePolicy(LastWins))
+	counted := v.ballots[0]
+	b, err := NewOpenVoteBallot(true, voters[0].x, v.Keys(), voters[0].data)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrRevote, v.Cast(b, voters[0].data))
+	assert.Equal(t, counted, v.ballots[0])
+
+	// ballot cast under another voter's identity
+	b, err = NewOpenVoteBallot(true, voters[0].x, v.Keys(), voters[0].data)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Cast(b, voters[1].data))
+
+	// ballot encrypted with a wrong key
+	gkX, gkY := voters[1].key.PublicKey()
+	b, err = NewBinaryBallot(true, voters[0].x, gkX, gkY, voters[0].data)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Cast(b, voters[0].data))
+
+	res, err := v.Tally()
+	assert.Nil(t, err)
+	assert.Equal(t, V, res)
+	assert.Equal(t, Tallied, v.Phase())
+
+	// tallied only once
+	_, err = v.Tally()
+	assert.NotNil(t, err)
+
+	// no ballots after tally
+	b, err = NewOpenVoteBallot(true, voters[0].x, v.Keys(), voters[0].data)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Cast(b, voters[0].data))
+}
+
+func TestOpenVoteRecovery(t *testing.T) {
+	v := NewOpenVote()
+	voters := registerOpenVoters(v, 5, t)
+
+	// voters 1 and 3 drop out
+	V := 0
+	for i, voter := range voters {
+		if i == 1 || i == 3 {
+			continue
+		}
+		b, err := NewOpenVoteBallot(i != 2, voter.x, v.Keys(), voter.data)
+		assert.Nil(t, err)
+		assert.Nil(t, v.Cast(b, voter.data))
+		if i != 2 {
+			V = V + 1
+		}
+	}
+
+	missing := v.Missing()
+	assert.Equal(t, 2, len(missing))
+
+	_, err := v.Tally()
+	assert.NotNil(t, err)
+
+	for i, voter := range voters {
+		if i == 1 || i == 3 {
+			continue
+		}
+		for _, k := range missing {
+			r, err := NewOpenVoteRecovery(voter.x, k, voter.data)
+			assert.Nil(t, err)
+
+			data, err := json.Marshal(r)
+			assert.Nil(t, err)
+			var r1 OpenVoteRecovery
+			assert.Nil(t, json.Unmarshal(data, &r1))
+			assert.Nil(t, v.Recover(&r1))
+		}
+	}
+
+	// recovery from a voter that has not cast
+	r, err := NewOpenVoteRecovery(voters[1].x, missing[1], voters[1].data)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Recover(r))
+
+	// a missing voter can't cast once recovery values are published
+	b, err := NewOpenVoteBallot(true, voters[1].x, v.Keys(), voters[1].data)
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Cast(b, voters[1].data))
+
+	res, err := v.Tally()
+	assert.Nil(t, err)
+	assert.Equal(t, V, res)
+	assert.Equal(t, Tallied, v.Phase())
+	_, err = v.Tally()
+	assert.NotNil(t, err)
+}
+
+func TestOpenVoteKeyJSON(t *testing.T) {
+	x, err := randScalar()
+	assert.Nil(t, err)
+	k, err := NewOpenVoteKey(x, big.NewInt(100))
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(k)
+	assert.Nil(t, err)
+	var k1 OpenVoteKey
+	assert.Nil(t, json.Unmarshal(data, &k1))
+	assert.Nil(t, k1.Verify())
+	assert.Equal(t, big.NewInt(100), k1.Data())
+
+	// duplicate registration
+	v := NewOpenVote()
+	assert.Nil(t, v.Register(k))
+	assert.NotNil(t, v.Register(&k1))
+}
+
+func TestOpenVoteInElection(t *testing.T) {
+	election := sha256.Sum256([]byte("e1"))
+	other := sha256.Sum256([]byte("e2"))
+
+	v := NewOpenVote()
+	assert.Nil(t, v.SetElection(election))
+
+	voters := make([]*openVoter, 3)
+	for i := range voters {
+		x, err := randScalar()
+		assert.Nil(t, err)
+		data := new(big.Int).SetBytes(getRandAddr())
+
+		// keys of another election or of none are rejected
+		k, err := NewOpenVoteKey(x, data)
+		assert.Nil(t, err)
+		assert.NotNil(t, v.Register(k))
+		k, err = NewOpenVoteKeyInElection(other, x, data)
+		assert.Nil(t, err)
+		assert.NotNil(t, v.Register(k))
+
+		k, err = NewOpenVoteKeyInElection(election, x, data)
+		assert.Nil(t, err)
+		b, err := json.Marshal(k)
+		assert.Nil(t, err)
+		var k1 OpenVoteKey
+		assert.Nil(t, json.Unmarshal(b, &k1))
+		assert.Nil(t, v.Register(&k1))
+
+		voters[i] = &openVoter{x, data, k}
+	}
+	assert.NotNil(t, v.SetElection(other))
+	assert.Nil(t, v.Close())
+
+	// voter 2 drops out
+	for _, voter := range voters[:2] {
+		b, err := NewOpenVoteBallot(true, voter.x, v.Keys(), voter.data)
+		assert.Nil(t, err)
+		assert.NotNil(t, v.Cast(b, voter.data))
+
+		b, err = NewOpenVoteBallotInElection(election, true, voter.x, v.Keys(), voter.data)
+		assert.Nil(t, err)
+		assert.Nil(t, v.Cast(b, voter.data))
+	}
+
+	for _, voter := range voters[:2] {
+		r, err := NewOpenVoteRecoveryInElection(other, voter.x, voters[2].key, voter.data)
+		assert.Nil(t, err)
+		assert.NotNil(t, v.Recover(r))
+
+		r, err = NewOpenVoteRecoveryInElection(election, voter.x, voters[2].key, voter.data)
+		assert.Nil(t, err)
+		assert.Nil(t, v.Recover(r))
+	}
+
+	res, err := v.Tally()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res)
+}
@@ -0,0 +1,117 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// OptionTallyRes structure - tally result of a vote with several options,
+// each of which is tallied the same way as a binary vote
+type OptionTallyRes struct {
+	res []*BinaryTallyRes // result of each option
+}
+
+// Verify verifies tally result
+func (r *OptionTallyRes) Verify() error {
+	return r.verify()
+}
+
+func (r *OptionTallyRes) verify() error {
+	if len(r.res) == 0 {
+		return errors.New("No options")
+	}
+
+	for j, res := range r.res {
+		if err := res.verify(); err != nil {
+			return fmt.Errorf("Option [%d]: %v", j, err)
+		}
+	}
+
+	// all options are bound to the same election
+	return r.checkContext(r.res[0].context())
+}
+
+// checkContext checks that the proofs of every option are bound to the
+// election ctx
+func (r *OptionTallyRes) checkContext(ctx []byte) error {
+	for j, res := range r.res {
+		if err := res.checkContext(ctx); err != nil {
+			return fmt.Errorf("Option [%d]: %v", j, err)
+		}
+	}
+	return nil
+}
+
+// CheckElection checks that the tally proofs are bound to the election
+// described by m, or to none if m is nil
+func (r *OptionTallyRes) CheckElection(m *Manifest) error {
+	var ctx []byte
+	if m != nil {
+		hash := m.Hash()
+		ctx = hash[:]
+	}
+	return r.checkContext(ctx)
+}
+
+// Counts returns the points received by each option
+func (r *OptionTallyRes) Counts() []int {
+	counts := make([]int, len(r.res))
+	for j, res := range r.res {
+		counts[j] = res.V
+	}
+	return counts
+}
+
+func (r *OptionTallyRes) String() (string, string) {
+	s, p := "", ""
+	for j, res := range r.res {
+		if j > 0 {
+			s, p = s+"; ", p+"; "
+		}
+		s = s + fmt.Sprintf("Option [%d] = %d", j, res.V)
+		p = p + fmt.Sprintf("[%d] %s", j, res.proof.String())
+	}
+	return s, p
+}
+
+// BuildJSONOptionTallyRes builds json object
+func (r *OptionTallyRes) BuildJSONOptionTallyRes() *JSONOptionTallyRes {
+	obj := &JSONOptionTallyRes{
+		Options: make([]*JSONBinaryTallyRes, len(r.res)),
+	}
+	for j, res := range r.res {
+		obj.Options[j] = res.BuildJSONBinaryTallyRes()
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (r *OptionTallyRes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.BuildJSONOptionTallyRes())
+}
+
+// FromJSONOptionTallyRes reconstructs from json object
+func (r *OptionTallyRes) FromJSONOptionTallyRes(obj *JSONOptionTallyRes) error {
+	r.res = make([]*BinaryTallyRes, len(obj.Options))
+	for j, c := range obj.Options {
+		if c == nil || c.Proof == nil {
+			return fmt.Errorf("Option [%d]: empty result", j)
+		}
+		r.res[j] = new(BinaryTallyRes)
+		if err := r.res[j].FromJSONBinaryTallyRes(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (r *OptionTallyRes) UnmarshalJSON(data []byte) error {
+	var obj JSONOptionTallyRes
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	return r.FromJSONOptionTallyRes(&obj)
+}
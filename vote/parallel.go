@@ -0,0 +1,77 @@
+package vote
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+)
+
+// checkedBallot - result of checking a binary ballot under election
+// parameters
+type checkedBallot struct {
+	w    uint64     // weight
+	leaf ballotLeaf // bulletin board entry
+	err  error
+}
+
+// checkBinaryBallots verifies ballots under p in parallel on the given number
+// of goroutines and returns the result for each in the same order
+func checkBinaryBallots(p *ElectionParams, ballots []*BinaryBallot, workers int) []checkedBallot {
+	res := make([]checkedBallot, len(ballots))
+	common.ParallelFor(len(ballots), workers, func(i int) {
+		b := ballots[i]
+		if b == nil {
+			res[i].err = errors.New("Missing ballot")
+			return
+		}
+		if res[i].err = b.VerifyBallot(); res[i].err != nil {
+			return
+		}
+		if res[i].w, res[i].err = p.checkBallot(b); res[i].err != nil {
+			return
+		}
+		res[i].leaf, res[i].err = newBallotLeaf(voterID(b), b)
+	})
+	return res
+}
+
+// sumWeighted returns H = prod_i h_i^w_i and Y = prod_i y_i^w_i of ballots
+// whose weights are given by checked, on the given number of goroutines
+func sumWeighted(ballots []*BinaryBallot, checked []checkedBallot, workers int) (HX, HY, YX, YY *big.Int) {
+	hXs := make([]*big.Int, len(ballots))
+	hYs := make([]*big.Int, len(ballots))
+	yXs := make([]*big.Int, len(ballots))
+	yYs := make([]*big.Int, len(ballots))
+	common.ParallelFor(len(ballots), workers, func(i int) {
+		hXs[i], hYs[i], yXs[i], yYs[i] = ballots[i].weighted(checked[i].w)
+	})
+
+	HX, HY = sumPoints(hXs, hYs, workers)
+	YX, YY = sumPoints(yXs, yYs, workers)
+	return
+}
+
+// sumPoints adds up points by tree reduction, each level of the tree in
+// parallel on the given number of goroutines. The sum of no points is the
+// point at infinity (0, 0).
+func sumPoints(xs, ys []*big.Int, workers int) (*big.Int, *big.Int) {
+	if len(xs) == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	for len(xs) > 1 {
+		m := (len(xs) + 1) / 2
+		nxs := make([]*big.Int, m)
+		nys := make([]*big.Int, m)
+		common.ParallelFor(len(xs)/2, workers, func(i int) {
+			nxs[i], nys[i] = curve.Add(xs[2*i], ys[2*i], xs[2*i+1], ys[2*i+1])
+		})
+		if len(xs)%2 == 1 {
+			nxs[m-1], nys[m-1] = xs[len(xs)-1], ys[len(ys)-1]
+		}
+		xs, ys = nxs, nys
+	}
+
+	return new(big.Int).Set(xs[0]), new(big.Int).Set(ys[0])
+}
@@ -0,0 +1,107 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelTally(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+
+	var ballots []*BinaryBallot
+	for i := 0; i < 21; i++ {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		ballots = append(ballots, genBinaryBallot(i%3 == 0, addr, k.PublicKey.X, k.PublicKey.Y, t))
+	}
+
+	// serial fold
+	HX, HY, YX, YY := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	for _, b := range ballots {
+		HX, HY = curve.Add(HX, HY, b.hX, b.hY)
+		YX, YY = curve.Add(YX, YY, b.yX, b.yY)
+	}
+
+	var tallies []*BinaryTally
+	for _, n := range []int{1, 2, 4, 7} {
+		tal, err := NewBinaryTallyWithParams(p, ballots, n)
+		assert.Nil(t, err)
+		tallies = append(tallies, tal)
+	}
+	for _, tal := range tallies {
+		assert.Equal(t, HX, tal.HX)
+		assert.Equal(t, HY, tal.HY)
+		assert.Equal(t, YX, tal.YX)
+		assert.Equal(t, YY, tal.YY)
+		assert.Equal(t, tallies[0].root, tal.root)
+		assert.Equal(t, len(ballots), tal.weight)
+	}
+
+	res, err := tallies[3].Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, res.V)
+
+	// the first invalid ballot is reported whatever the number of workers
+	other, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	invalid := append([]*BinaryBallot{}, ballots...)
+	invalid[3] = &BinaryBallot{hX: big.NewInt(1), hY: big.NewInt(1), yX: ballots[3].yX, yY: ballots[3].yY, proof: ballots[3].proof}
+	invalid[7] = genBinaryBallot(true, big.NewInt(7), other.PublicKey.X, other.PublicKey.Y, t)
+	for _, n := range []int{1, 4} {
+		_, err := NewBinaryTallyWithParams(p, invalid, n)
+		assert.EqualError(t, err, invalid[3].VerifyBallot().Error())
+	}
+
+	// one worker per CPU
+	tal, err := NewBinaryTallyWithParams(p, ballots, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, HX, tal.HX)
+
+	X, Y := sumPoints(nil, nil, 0)
+	assert.Equal(t, 0, X.Sign())
+	assert.Equal(t, 0, Y.Sign())
+}
+
+// BenchmarkNewBinaryTally measures the verification and aggregation of 100k
+// ballots by number of workers, e.g.,
+//
+//	go test -run NONE -bench NewBinaryTally -benchtime 1x ./vote
+//
+// 1000 distinct ballots are repeated to keep the setup short, every copy is
+// verified.
+func BenchmarkNewBinaryTally(b *testing.B) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+	p, _ := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+
+	distinct := make([]*BinaryBallot, 1000)
+	for i := range distinct {
+		a, _ := randScalar()
+		distinct[i], _ = NewBinaryBallot(i%2 == 0, a, k.PublicKey.X, k.PublicKey.Y, new(big.Int).SetBytes(getRandAddr()))
+	}
+	ballots := make([]*BinaryBallot, 100000)
+	for i := range ballots {
+		ballots[i] = distinct[i%len(distinct)]
+	}
+
+	counts := []int{1, 2, 4}
+	if n := runtime.NumCPU(); n > 4 {
+		counts = append(counts, n)
+	}
+	for _, n := range counts {
+		b.Run(fmt.Sprintf("workers=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := NewBinaryTallyWithParams(p, ballots, n); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
+	"github.com/zzGHzz/zkVote/ring"
+)
+
+// ElectionParams - public parameters of an election
+type ElectionParams struct {
+	gkX, gkY *big.Int // authority public key
+	authData *big.Int // address of authority
+
+	registry *[32]byte  // root of the voter registry, nil if anyone may vote
+	ring     *ring.Ring // keys of the anonymous voters, nil if not anonymous
+	election *[32]byte  // hash of the election manifest bound into proofs
+
+	minVoters, maxVoters int // turnout limits, zero if unlimited
+	passYes              int // minimum number of yes votes to pass
+	passNum, passDen     int // minimum ratio of yes votes to pass, passDen is zero if unset
+}
+
+// NewElectionParams news election parameters
+func NewElectionParams(gkX, gkY, authData *big.Int) (*ElectionParams, error) {
+	if !isOnCurve(gkX, gkY) {
+		return nil, errors.New("Invalid g^k")
+	}
+
+	return &ElectionParams{
+		gkX:      new(big.Int).Set(gkX),
+		gkY:      new(big.Int).Set(gkY),
+		authData: new(big.Int).Set(authData),
+	}, nil
+}
+
+// SetRegistry restricts voting to the voters in the registry with the given
+// root. It can't be combined with a ring of anonymous voters.
+func (p *ElectionParams) SetRegistry(root [32]byte) error {
+	if p.ring != nil {
+		return errors.New("Ring already set")
+	}
+	p.registry = &root
+	return nil
+}
+
+// Registry returns the root of the voter registry and whether it is set
+func (p *ElectionParams) Registry() ([32]byte, bool) {
+	if p.registry == nil {
+		return [32]byte{}, false
+	}
+	return *p.registry, true
+}
+
+// SetRing restricts voting to anonymous voters who sign their ballots with a
+// ring signature by a key in r. It can't be combined with a registry, which
+// identifies voters by their addresses.
+func (p *ElectionParams) SetRing(r *ring.Ring) error {
+	if p.registry != nil {
+		return errors.New("Registry already set")
+	}
+	p.ring = r
+	return nil
+}
+
+// Ring returns the ring of anonymous voters, nil if not set
+func (p *ElectionParams) Ring() *ring.Ring {
+	return p.ring
+}
+
+// uniqueVoters tells whether voters are identified, by the registry or the
+// key images of ring signatures, so that each may vote once
+func (p *ElectionParams) uniqueVoters() bool {
+	return p.registry != nil || p.ring != nil
+}
+
+// SetVoterLimits sets the minimum turnout needed for the quorum and the
+// maximum number of voters. Zero means no limit.
+func (p *ElectionParams) SetVoterLimits(min, max int) error {
+	if min < 0 || max < 0 || (max > 0 && min > max) {
+		return errors.New("Invalid voter number setting")
+	}
+	p.minVoters, p.maxVoters = min, max
+	return nil
+}
+
+// VoterLimits returns the minimum turnout and the maximum number of voters
+func (p *ElectionParams) VoterLimits() (int, int) {
+	return p.minVoters, p.maxVoters
+}
+
+// SetPassRule sets the rule for the result to pass: at least minYes yes
+// votes and a ratio of yes votes to the (weighted) ballots counted of at
+// least num/den. Zero minYes or den disables the corresponding check. If
+// neither is set, a simple majority is required.
+func (p *ElectionParams) SetPassRule(minYes, num, den int) error {
+	if minYes < 0 || num < 0 || den < 0 || num > den || (den == 0 && num != 0) {
+		return errors.New("Invalid pass rule")
+	}
+	p.passYes, p.passNum, p.passDen = minYes, num, den
+	return nil
+}
+
+// PassRule returns the minimum number and ratio of yes votes to pass
+func (p *ElectionParams) PassRule() (int, int, int) {
+	return p.passYes, p.passNum, p.passDen
+}
+
+// checkVoters checks the number of voters against the maximum
+func (p *ElectionParams) checkVoters(n int) error {
+	if p.maxVoters > 0 && n > p.maxVoters {
+		return errors.New("Max number of voters reached")
+	}
+	return nil
+}
+
+// evaluate tells whether n ballots of total weight w with V yes votes meet
+// the quorum and pass
+func (p *ElectionParams) evaluate(n, w, V int) (bool, bool) {
+	quorum := n >= p.minVoters
+
+	passed := quorum
+	if p.passYes == 0 && p.passDen == 0 {
+		passed = passed && 2*V > w
+	}
+	if p.passYes > 0 {
+		passed = passed && V >= p.passYes
+	}
+	if p.passDen > 0 {
+		passed = passed && V*p.passDen >= w*p.passNum
+	}
+
+	return quorum, passed
+}
+
+// SetElection binds ballots and tally proofs to the election with the given
+// manifest hash. Ballots of other elections, or not bound to any, are
+// rejected.
+func (p *ElectionParams) SetElection(hash [32]byte) {
+	p.election = &hash
+}
+
+// Election returns the hash of the election manifest and whether it is set
+func (p *ElectionParams) Election() ([32]byte, bool) {
+	if p.election == nil {
+		return [32]byte{}, false
+	}
+	return *p.election, true
+}
+
+// context returns the context bound into proofs, nil if no election is set
+func (p *ElectionParams) context() []byte {
+	if p.election == nil {
+		return nil
+	}
+	return p.election[:]
+}
+
+// PublicKey returns the authority public key
+func (p *ElectionParams) PublicKey() (*big.Int, *big.Int) {
+	return new(big.Int).Set(p.gkX), new(big.Int).Set(p.gkY)
+}
+
+// AuthData returns the address of the authority
+func (p *ElectionParams) AuthData() *big.Int {
+	return new(big.Int).Set(p.authData)
+}
+
+// checkBallot checks that a binary ballot is encrypted with g^k, bound to the
+// election and carries a valid eligibility proof if a registry is set, or a
+// valid ring signature if a ring is set. It returns the weight of the ballot,
+// which is one if no registry is set.
+func (p *ElectionParams) checkBallot(b *BinaryBallot) (uint64, error) {
+	if err := b.CheckKey(p.gkX, p.gkY); err != nil {
+		return 0, err
+	}
+	if p.ring == nil {
+		return p.checkVoter(b.proof.Context(), b.proof.Data(), b.eligibility)
+	}
+
+	if err := checkContext(p.context(), b.proof.Context()); err != nil {
+		return 0, err
+	}
+	if err := b.VerifySignature(p.ring); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// checkVoter checks that the proof context of a ballot cast by the voter
+// identified by data is the election and, if a registry is set, that e
+// proves the voter eligible. It returns the weight of the voter.
+func (p *ElectionParams) checkVoter(ctx []byte, data *big.Int, e *Eligibility) (uint64, error) {
+	if err := checkContext(p.context(), ctx); err != nil {
+		return 0, err
+	}
+
+	if p.ring != nil {
+		return 0, errors.New("Missing ring signature")
+	}
+	if p.registry == nil {
+		return 1, nil
+	}
+
+	if e == nil {
+		return 0, errors.New("Missing eligibility proof")
+	}
+	if err := e.Verify(*p.registry, data); err != nil {
+		return 0, err
+	}
+
+	return e.weight, nil
+}
+
+// BuildJSONElectionParams builds json object
+func (p *ElectionParams) BuildJSONElectionParams() *JSONElectionParams {
+	obj := &JSONElectionParams{
+		GKX:      common.BigIntToHexStr(p.gkX),
+		GKY:      common.BigIntToHexStr(p.gkY),
+		AuthData: common.BigIntToHexStr(p.authData),
+	}
+	if p.registry != nil {
+		obj.Registry = merkle.HashToHexStr(*p.registry)
+	}
+	if p.ring != nil {
+		obj.Ring = p.ring.BuildJSONRing()
+	}
+	if p.election != nil {
+		obj.Election = merkle.HashToHexStr(*p.election)
+	}
+	obj.MinVoters, obj.MaxVoters = p.minVoters, p.maxVoters
+	obj.PassYes, obj.PassNum, obj.PassDen = p.passYes, p.passNum, p.passDen
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (p *ElectionParams) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONElectionParams())
+}
+
+// FromJSONElectionParams reconstructs from json object
+func (p *ElectionParams) FromJSONElectionParams(obj *JSONElectionParams) error {
+	var (
+		gkX, gkY, authData *big.Int
+		err                error
+	)
+
+	if gkX, err = common.HexStrToBigInt(obj.GKX); err != nil {
+		return err
+	}
+	if gkY, err = common.HexStrToBigInt(obj.GKY); err != nil {
+		return err
+	}
+	if authData, err = common.HexStrToBigInt(obj.AuthData); err != nil {
+		return err
+	}
+
+	p1, err := NewElectionParams(gkX, gkY, authData)
+	if err != nil {
+		return err
+	}
+
+	if obj.Registry != "" {
+		root, err := merkle.HexStrToHash(obj.Registry)
+		if err != nil {
+			return err
+		}
+		if err := p1.SetRegistry(root); err != nil {
+			return err
+		}
+	}
+	if obj.Ring != nil {
+		r := new(ring.Ring)
+		if err := r.FromJSONRing(obj.Ring); err != nil {
+			return err
+		}
+		if err := p1.SetRing(r); err != nil {
+			return err
+		}
+	}
+	if obj.Election != "" {
+		hash, err := merkle.HexStrToHash(obj.Election)
+		if err != nil {
+			return err
+		}
+		p1.SetElection(hash)
+	}
+	if err := p1.SetVoterLimits(obj.MinVoters, obj.MaxVoters); err != nil {
+		return err
+	}
+	if err := p1.SetPassRule(obj.PassYes, obj.PassNum, obj.PassDen); err != nil {
+		return err
+	}
+
+	*p = *p1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *ElectionParams) UnmarshalJSON(data []byte) error {
+	var obj JSONElectionParams
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONElectionParams(&obj)
+}
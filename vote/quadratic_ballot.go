@@ -0,0 +1,438 @@
+package vote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// QuadraticBallot - ballot that casts n_i (possibly negative) votes for each
+// option i at a cost of sum_i n_i^2 <= C voice credits
+//
+// Let R = floor(sqrt(C)). Each n_i is encrypted as
+// (g^{a_i}, g^{a_i*k} * g^{n_i}) and comes with a range proof of
+// n_i + R\in[0, 2^l). n_i^2 is encrypted by re-randomizing the ciphertext
+// of n_i raised to n_i, with a product proof. The unspent credits
+// C - sum_i n_i^2 are encrypted and range-proved, and the sum of squares
+// and unspent credits is proved to be C.
+type QuadraticBallot struct {
+	credits uint64
+	votes   []*zk.RangeProof   // encrypted n_i + R
+	squares []*zk.ProductProof // encrypted n_i^2
+	slack   *zk.RangeProof     // encrypted unspent credits
+	sum     *zk.DLEQProof      // proves that squares and slack add up to credits
+}
+
+// quadraticBound returns R = floor(sqrt(C))
+func quadraticBound(credits uint64) uint64 {
+	return new(big.Int).Sqrt(new(big.Int).SetUint64(credits)).Uint64()
+}
+
+// NewQuadraticBallot generates a quadratic ballot
+//
+// votes contains the signed number of votes for each option.
+// data contains the data (e.g., account address) that identifies the voter.
+func NewQuadraticBallot(votes []int64, credits uint64, gkX, gkY *big.Int, data *big.Int) (*QuadraticBallot, error) {
+	return newQuadraticBallot(votes, credits, gkX, gkY, data, nil)
+}
+
+// NewQuadraticBallotInElection generates a quadratic ballot bound to the
+// election described by m
+func NewQuadraticBallotInElection(m *Manifest, votes []int64, credits uint64, data *big.Int) (*QuadraticBallot, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash := m.Hash()
+	return newQuadraticBallot(votes, credits, m.GKX, m.GKY, data, hash[:])
+}
+
+func newQuadraticBallot(votes []int64, credits uint64, gkX, gkY *big.Int, data *big.Int, ctx []byte) (*QuadraticBallot, error) {
+	if !isOnCurve(gkX, gkY) {
+		return nil, errors.New("Invalid g^k")
+	}
+
+	if len(votes) == 0 {
+		return nil, errors.New("No options")
+	}
+
+	if credits == 0 {
+		return nil, errors.New("Invalid credit budget")
+	}
+
+	R := quadraticBound(credits)
+
+	var spent uint64
+	for _, n := range votes {
+		if n > int64(R) || -n > int64(R) {
+			return nil, errors.New("Credit budget exceeded")
+		}
+		sq := uint64(n * n)
+		if sq > credits-spent {
+			return nil, errors.New("Credit budget exceeded")
+		}
+		spent = spent + sq
+	}
+
+	// Set global ECC var for zk package
+	zk.SetEllipticCurve(curve)
+
+	ballot := &QuadraticBallot{
+		credits: credits,
+		votes:   make([]*zk.RangeProof, len(votes)),
+		squares: make([]*zk.ProductProof, len(votes)),
+	}
+
+	// B = sum_i b_i + a_slack where b_i is the secret of the ciphertext of n_i^2
+	B := big.NewInt(0)
+	N := curve.Params().N
+
+	lv := bits.Len64(2 * R)
+	for i, n := range votes {
+		prover, err := zk.NewRangeProver(big.NewInt(n+int64(R)), lv, nil, gkX, gkY)
+		if err != nil {
+			return nil, err
+		}
+		if ballot.votes[i], err = prover.ProveInContext(ctx, data); err != nil {
+			return nil, err
+		}
+
+		a := prover.A()
+		hX, hY, yX, yY := quadraticCiphertext(ballot.votes[i], R)
+
+		sqProver, err := zk.NewProductProver(big.NewInt(n), a, gkX, gkY, hX, hY, yX, yY)
+		if err != nil {
+			return nil, err
+		}
+		if ballot.squares[i], err = sqProver.ProveInContext(ctx, data); err != nil {
+			return nil, err
+		}
+
+		// b_i = n_i*a_i + t_i
+		b := new(big.Int).Mul(big.NewInt(n), a)
+		b = b.Add(b, sqProver.T())
+		B = B.Add(B, b)
+	}
+
+	prover, err := zk.NewRangeProver(new(big.Int).SetUint64(credits-spent), bits.Len64(credits), nil, gkX, gkY)
+	if err != nil {
+		return nil, err
+	}
+	if ballot.slack, err = prover.ProveInContext(ctx, data); err != nil {
+		return nil, err
+	}
+	B = B.Add(B, prover.A())
+	B = B.Mod(B, N)
+
+	// Prove log_g(prod_i h_i) = log_{g^k}(prod_i y_i / g^C) over squares and slack
+	sumProver, err := zk.NewDLEQProver(B, curve.Params().Gx, curve.Params().Gy, gkX, gkY)
+	if err != nil {
+		return nil, err
+	}
+	if ballot.sum, err = sumProver.ProveInContext(ctx, data); err != nil {
+		return nil, err
+	}
+
+	return ballot, nil
+}
+
+// quadraticCiphertext returns the ciphertext of n given the range proof of
+// n + R
+func quadraticCiphertext(p *zk.RangeProof, R uint64) (hX, hY, yX, yY *big.Int) {
+	hX, hY, yX, yY = p.Ciphertext()
+
+	// y = y / g^R
+	gRX, gRY := curve.ScalarBaseMult(new(big.Int).SetUint64(R).Bytes())
+	gRX, gRY = ecinv(gRX, gRY)
+	yX, yY = curve.Add(yX, yY, gRX, gRY)
+
+	return
+}
+
+// VerifyBallot verifies quadratic ballot
+func (b *QuadraticBallot) VerifyBallot() error {
+	if b.credits == 0 {
+		return errors.New("Invalid credit budget")
+	}
+
+	if len(b.votes) == 0 {
+		return errors.New("No options")
+	}
+
+	if len(b.squares) != len(b.votes) {
+		return errors.New("Missing square proofs")
+	}
+
+	if b.slack == nil || b.sum == nil {
+		return errors.New("Missing credit budget proof")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	R := quadraticBound(b.credits)
+	lv := bits.Len64(2 * R)
+
+	if b.votes[0] == nil {
+		return errors.New("Missing range proof")
+	}
+	gkX, gkY := b.votes[0].PublicKey()
+	data := b.votes[0].Data()
+	ctx := b.votes[0].Context()
+	if gkX == nil || !isOnCurve(gkX, gkY) {
+		return errors.New("Invalid g^k")
+	}
+
+	checkRange := func(p *zk.RangeProof, l int) error {
+		if p == nil || p.Bits() != l {
+			return errors.New("Invalid range")
+		}
+
+		X, Y := p.PublicKey()
+		if X.Cmp(gkX) != 0 || Y.Cmp(gkY) != 0 || p.Data().Cmp(data) != 0 ||
+			!bytes.Equal(p.Context(), ctx) {
+			return errors.New("Inconsistent range proof")
+		}
+
+		res, err := p.Verify()
+		if err != nil {
+			return err
+		}
+		if !res {
+			return errors.New("Failed to verify range proof")
+		}
+
+		return nil
+	}
+
+	// H = prod_i h_i, Y = prod_i y_i over squares and slack
+	HX, HY := new(big.Int), new(big.Int)
+	YX, YY := new(big.Int), new(big.Int)
+
+	for i, p := range b.votes {
+		if err := checkRange(p, lv); err != nil {
+			return fmt.Errorf("Vote [%d]: %v", i, err)
+		}
+		hX, hY, yX, yY := quadraticCiphertext(p, R)
+
+		sq := b.squares[i]
+		if sq == nil {
+			return fmt.Errorf("Missing square proof [%d]", i)
+		}
+
+		X, Y := sq.PublicKey()
+		h1X, h1Y, y1X, y1Y, h2X, h2Y, y2X, y2Y := sq.Factors()
+		if X.Cmp(gkX) != 0 || Y.Cmp(gkY) != 0 || sq.Data().Cmp(data) != 0 ||
+			!bytes.Equal(sq.Context(), ctx) ||
+			h1X.Cmp(hX) != 0 || h1Y.Cmp(hY) != 0 || y1X.Cmp(yX) != 0 || y1Y.Cmp(yY) != 0 ||
+			h2X.Cmp(hX) != 0 || h2Y.Cmp(hY) != 0 || y2X.Cmp(yX) != 0 || y2Y.Cmp(yY) != 0 {
+			return fmt.Errorf("Inconsistent square proof [%d]", i)
+		}
+
+		res, err := sq.Verify()
+		if err != nil {
+			return err
+		}
+		if !res {
+			return fmt.Errorf("Failed to verify square proof [%d]", i)
+		}
+
+		sHX, sHY, sYX, sYY := sq.Product()
+		HX, HY = curve.Add(HX, HY, sHX, sHY)
+		YX, YY = curve.Add(YX, YY, sYX, sYY)
+	}
+
+	if err := checkRange(b.slack, bits.Len64(b.credits)); err != nil {
+		return fmt.Errorf("Unspent credits: %v", err)
+	}
+	hX, hY, yX, yY := b.slack.Ciphertext()
+	HX, HY = curve.Add(HX, HY, hX, hY)
+	YX, YY = curve.Add(YX, YY, yX, yY)
+
+	// Y = Y / g^C
+	gCX, gCY := curve.ScalarBaseMult(new(big.Int).SetUint64(b.credits).Bytes())
+	gCX, gCY = ecinv(gCX, gCY)
+	YX, YY = curve.Add(YX, YY, gCX, gCY)
+
+	g1X, g1Y, g2X, g2Y := b.sum.Bases()
+	y1X, y1Y, y2X, y2Y := b.sum.Values()
+	if g1X.Cmp(curve.Params().Gx) != 0 || g1Y.Cmp(curve.Params().Gy) != 0 ||
+		g2X.Cmp(gkX) != 0 || g2Y.Cmp(gkY) != 0 ||
+		y1X.Cmp(HX) != 0 || y1Y.Cmp(HY) != 0 ||
+		y2X.Cmp(YX) != 0 || y2Y.Cmp(YY) != 0 ||
+		b.sum.Data().Cmp(data) != 0 || !bytes.Equal(b.sum.Context(), ctx) {
+		return errors.New("Credit budget exceeded")
+	}
+
+	res, err := b.sum.Verify()
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Failed to verify credit budget proof")
+	}
+
+	return nil
+}
+
+// checkQuadraticBallot verifies the ballot and checks it against election
+// settings, including the election ctx it must be bound to
+func checkQuadraticBallot(b *QuadraticBallot, gkX, gkY *big.Int, ctx []byte, options int, credits uint64) error {
+	if err := b.VerifyBallot(); err != nil {
+		return err
+	}
+
+	if err := checkContext(ctx, b.votes[0].Context()); err != nil {
+		return err
+	}
+
+	if b.credits != credits {
+		return errors.New("Invalid credit budget")
+	}
+
+	if len(b.votes) != options {
+		return errors.New("Invalid number of options")
+	}
+
+	X, Y := b.votes[0].PublicKey()
+	if X.Cmp(gkX) != 0 || Y.Cmp(gkY) != 0 {
+		return errors.New("g^k doesn't match")
+	}
+
+	return nil
+}
+
+// CheckElection checks that the ballot is bound to the election described
+// by m, or to none if m is nil
+func (b *QuadraticBallot) CheckElection(m *Manifest) error {
+	var ctx []byte
+	if m != nil {
+		hash := m.Hash()
+		ctx = hash[:]
+	}
+	return checkContext(ctx, b.votes[0].Context())
+}
+
+// Credits returns the voice credit budget
+func (b *QuadraticBallot) Credits() uint64 {
+	return b.credits
+}
+
+// Options returns the number of options
+func (b *QuadraticBallot) Options() int {
+	return len(b.votes)
+}
+
+// Kind returns QuadraticKind
+func (b *QuadraticBallot) Kind() BallotKind {
+	return QuadraticKind
+}
+
+// Voter returns the data bound to the ballot that identifies the voter
+func (b *QuadraticBallot) Voter() *big.Int {
+	return b.votes[0].Data()
+}
+
+// PublicKey returns g^k the ballot is encrypted with
+func (b *QuadraticBallot) PublicKey() (*big.Int, *big.Int) {
+	return b.votes[0].PublicKey()
+}
+
+// Ciphertexts returns the encrypted (signed) number of votes for each
+// option
+func (b *QuadraticBallot) Ciphertexts() []*zk.Ciphertext {
+	R := quadraticBound(b.credits)
+	cs := make([]*zk.Ciphertext, len(b.votes))
+	for i, p := range b.votes {
+		e := new(zk.Ciphertext)
+		e.HX, e.HY, e.YX, e.YY = quadraticCiphertext(p, R)
+		cs[i] = e
+	}
+	return cs
+}
+
+func (b *QuadraticBallot) String() (string, string) {
+	R := quadraticBound(b.credits)
+	s := fmt.Sprintf("credits = %d", b.credits)
+	for i, p := range b.votes {
+		hX, hY, yX, yY := quadraticCiphertext(p, R)
+		s = s + fmt.Sprintf("; [%d] h = (%x, %x), y = (%x, %x)", i, hX, hY, yX, yY)
+	}
+	return s, b.sum.String()
+}
+
+// BuildJSONQuadraticBallot builds JSON object
+func (b *QuadraticBallot) BuildJSONQuadraticBallot() *JSONQuadraticBallot {
+	obj := &JSONQuadraticBallot{
+		Credits: b.credits,
+		Votes:   make([]*zk.JSONRangeProof, len(b.votes)),
+		Squares: make([]*zk.JSONProductProof, len(b.squares)),
+		Slack:   b.slack.BuildJSONRangeProof(),
+		Sum:     b.sum.BuildJSONDLEQProof(),
+	}
+
+	for i, p := range b.votes {
+		obj.Votes[i] = p.BuildJSONRangeProof()
+	}
+	for i, p := range b.squares {
+		obj.Squares[i] = p.BuildJSONProductProof()
+	}
+
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (b *QuadraticBallot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.BuildJSONQuadraticBallot())
+}
+
+// FromJSONQuadraticBallot reconstructs from json object
+func (b *QuadraticBallot) FromJSONQuadraticBallot(obj *JSONQuadraticBallot) error {
+	if len(obj.Votes) == 0 {
+		return errors.New("No options")
+	}
+
+	b.credits = obj.Credits
+
+	b.votes = make([]*zk.RangeProof, len(obj.Votes))
+	for i, p := range obj.Votes {
+		b.votes[i] = new(zk.RangeProof)
+		if err := b.votes[i].FromJSONRangeProof(p); err != nil {
+			return err
+		}
+	}
+
+	b.squares = make([]*zk.ProductProof, len(obj.Squares))
+	for i, p := range obj.Squares {
+		b.squares[i] = new(zk.ProductProof)
+		if err := b.squares[i].FromJSONProductProof(p); err != nil {
+			return err
+		}
+	}
+
+	b.slack = new(zk.RangeProof)
+	if err := b.slack.FromJSONRangeProof(obj.Slack); err != nil {
+		return err
+	}
+
+	b.sum = new(zk.DLEQProof)
+	if err := b.sum.FromJSONDLEQProof(obj.Sum); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (b *QuadraticBallot) UnmarshalJSON(data []byte) error {
+	var obj JSONQuadraticBallot
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	return b.FromJSONQuadraticBallot(&obj)
+}
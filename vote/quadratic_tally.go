@@ -0,0 +1,109 @@
+package vote
+
+import (
+	"errors"
+	"math/big"
+)
+
+// QuadraticTally structure
+type QuadraticTally struct {
+	gkX, gkY *big.Int
+	authData *big.Int
+	credits  uint64
+	ctx      []byte // election bound into the ballots and proofs, nil if none
+
+	HX, HY []*big.Int // H_j = prod_i h_ij for option j
+	YX, YY []*big.Int // Y_j = prod_i y_ij for option j
+	n      int        // number of ballots
+}
+
+// NewQuadraticTally creates a new tally
+func NewQuadraticTally(gkX, gkY, authData *big.Int, options int, credits uint64, ballots []*QuadraticBallot) (*QuadraticTally, error) {
+	if !isOnCurve(gkX, gkY) {
+		return nil, errors.New("Invalid authority public key")
+	}
+
+	return newQuadraticTally(gkX, gkY, authData, nil, options, credits, ballots)
+}
+
+// NewQuadraticTallyWithParams creates a new tally of ballots bound to the
+// election of p, if set, within the turnout limits of p. Voter registries
+// and rings are not supported.
+func NewQuadraticTallyWithParams(p *ElectionParams, options int, credits uint64, ballots []*QuadraticBallot) (*QuadraticTally, error) {
+	if p.registry != nil || p.ring != nil {
+		return nil, errors.New("Voter registry not supported")
+	}
+	if err := p.checkVoters(len(ballots)); err != nil {
+		return nil, err
+	}
+
+	return newQuadraticTally(p.gkX, p.gkY, p.authData, p.context(), options, credits, ballots)
+}
+
+func newQuadraticTally(gkX, gkY, authData *big.Int, ctx []byte, options int, credits uint64, ballots []*QuadraticBallot) (*QuadraticTally, error) {
+	if options <= 0 {
+		return nil, errors.New("Invalid number of options")
+	}
+
+	t := &QuadraticTally{
+		gkX:      new(big.Int).Set(gkX),
+		gkY:      new(big.Int).Set(gkY),
+		authData: new(big.Int).Set(authData),
+		ctx:      ctx,
+		credits:  credits,
+		HX:       make([]*big.Int, options),
+		HY:       make([]*big.Int, options),
+		YX:       make([]*big.Int, options),
+		YY:       make([]*big.Int, options),
+		n:        len(ballots),
+	}
+	for j := 0; j < options; j++ {
+		t.HX[j], t.HY[j] = new(big.Int), new(big.Int)
+		t.YX[j], t.YY[j] = new(big.Int), new(big.Int)
+	}
+
+	R := quadraticBound(credits)
+	for _, b := range ballots {
+		if err := checkQuadraticBallot(b, gkX, gkY, ctx, options, credits); err != nil {
+			return nil, err
+		}
+
+		for j, p := range b.votes {
+			hX, hY, yX, yY := quadraticCiphertext(p, R)
+			t.HX[j], t.HY[j] = curve.Add(t.HX[j], t.HY[j], hX, hY)
+			t.YX[j], t.YY[j] = curve.Add(t.YX[j], t.YY[j], yX, yY)
+		}
+	}
+
+	return t, nil
+}
+
+// Tally computes result and zk proof for each option
+func (t *QuadraticTally) Tally(k *big.Int) (*OptionTallyRes, error) {
+	return t.tally(k)
+}
+
+func (t *QuadraticTally) tally(k *big.Int) (*OptionTallyRes, error) {
+	if !isInRange(k) {
+		return nil, errors.New("Invalid k")
+	}
+
+	x, y := curve.ScalarBaseMult(k.Bytes())
+	if x.Cmp(t.gkX) != 0 || y.Cmp(t.gkY) != 0 {
+		return nil, errors.New("k doesn't match saved g^k")
+	}
+
+	// each option receives between -n*R and n*R votes
+	max := t.n * int(quadraticBound(t.credits))
+
+	res := make([]*BinaryTallyRes, len(t.HX))
+	for j := range t.HX {
+		r, err := decrypt(k, t.HX[j], t.HY[j], t.YX[j], t.YY[j], -max, max, t.authData, t.ctx)
+		if err != nil {
+			return nil, err
+		}
+		res[j] = r
+	}
+
+	return &OptionTallyRes{res}, nil
+}
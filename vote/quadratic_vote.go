@@ -0,0 +1,193 @@
+package vote
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// QuadraticVote structure
+type QuadraticVote struct {
+	gkX, gkY *big.Int // authority's public key
+	authData *big.Int // address of authorty
+
+	options int    // number of options
+	credits uint64 // voice credits of each voter
+	ctx     []byte // election bound into the ballots and proofs, nil if none
+
+	ballots map[[32]byte]*QuadraticBallot // quadratic ballots
+	revoteLog
+	lifecycle
+
+	HX, HY []*big.Int // H_j = prod_i h_ij for option j
+	YX, YY []*big.Int // Y_j = prod_i y_ij for option j
+
+	res *OptionTallyRes
+}
+
+// NewQuadraticVote news a vote in which each voter spends at most credits
+// voice credits, casting n votes for an option at a cost of n^2. The vote
+// starts in registration and must be opened before ballots can be cast.
+func NewQuadraticVote(gkX, gkY *big.Int, authData *big.Int, options int, credits uint64) (*QuadraticVote, error) {
+	if !isOnCurve(gkX, gkY) {
+		return nil, errors.New("Invalid g^k")
+	}
+
+	if options <= 0 {
+		return nil, errors.New("Invalid number of options")
+	}
+
+	if credits == 0 {
+		return nil, errors.New("Invalid credit budget")
+	}
+
+	vote := new(QuadraticVote)
+
+	vote.gkX = new(big.Int).Set(gkX)
+	vote.gkY = new(big.Int).Set(gkY)
+
+	vote.authData = new(big.Int).Set(authData)
+
+	vote.options = options
+	vote.credits = credits
+
+	vote.ballots = make(map[[32]byte]*QuadraticBallot)
+	vote.revoteLog = newRevoteLog()
+	vote.lifecycle = newLifecycle()
+
+	vote.HX = make([]*big.Int, options)
+	vote.HY = make([]*big.Int, options)
+	vote.YX = make([]*big.Int, options)
+	vote.YY = make([]*big.Int, options)
+	for j := 0; j < options; j++ {
+		vote.HX[j], vote.HY[j] = big.NewInt(0), big.NewInt(0)
+		vote.YX[j], vote.YY[j] = big.NewInt(0), big.NewInt(0)
+	}
+
+	return vote, nil
+}
+
+// SetElection binds the vote to the election with the given manifest hash.
+// Only ballots bound to the election are accepted and the tally proofs are
+// bound to it. It can only be set during registration.
+func (v *QuadraticVote) SetElection(hash [32]byte) error {
+	if v.phase != Registration {
+		return errors.New("Election can only be set during registration")
+	}
+	v.ctx = hash[:]
+	return nil
+}
+
+// newQuadraticTally creates a tally
+func (v *QuadraticVote) newQuadraticTally() *QuadraticTally {
+	t := &QuadraticTally{
+		gkX:      new(big.Int).Set(v.gkX),
+		gkY:      new(big.Int).Set(v.gkY),
+		authData: new(big.Int).Set(v.authData),
+		ctx:      v.ctx,
+		credits:  v.credits,
+		HX:       make([]*big.Int, v.options),
+		HY:       make([]*big.Int, v.options),
+		YX:       make([]*big.Int, v.options),
+		YY:       make([]*big.Int, v.options),
+		n:        len(v.ballots),
+	}
+	for j := 0; j < v.options; j++ {
+		t.HX[j], t.HY[j] = new(big.Int).Set(v.HX[j]), new(big.Int).Set(v.HY[j])
+		t.YX[j], t.YY[j] = new(big.Int).Set(v.YX[j]), new(big.Int).Set(v.YY[j])
+	}
+	return t
+}
+
+// Cast casts a ballot
+func (v *QuadraticVote) Cast(bt Ballot, data *big.Int) error {
+	b, ok := bt.(*QuadraticBallot)
+	if !ok {
+		return errors.New("Invalid ballot type")
+	}
+
+	if err := v.checkCast(); err != nil {
+		return err
+	}
+
+	if err := checkQuadraticBallot(b, v.gkX, v.gkY, v.ctx, v.options, v.credits); err != nil {
+		return err
+	}
+
+	R := quadraticBound(v.credits)
+
+	id := sha256.Sum256(data.Bytes())
+	if counted, err := v.admit(id, data, b); err != nil || !counted {
+		return err
+	}
+
+	if old, ok := v.ballots[id]; ok {
+		for j, p := range old.votes {
+			hX, hY, yX, yY := quadraticCiphertext(p, R)
+
+			iOldhX, iOldhY := ecinv(hX, hY)
+			v.HX[j], v.HY[j] = curve.Add(v.HX[j], v.HY[j], iOldhX, iOldhY)
+
+			iOldyX, iOldyY := ecinv(yX, yY)
+			v.YX[j], v.YY[j] = curve.Add(v.YX[j], v.YY[j], iOldyX, iOldyY)
+		}
+	}
+
+	for j, p := range b.votes {
+		hX, hY, yX, yY := quadraticCiphertext(p, R)
+		v.HX[j], v.HY[j] = curve.Add(v.HX[j], v.HY[j], hX, hY)
+		v.YX[j], v.YY[j] = curve.Add(v.YX[j], v.YY[j], yX, yY)
+	}
+
+	v.ballots[id] = b
+
+	return nil
+}
+
+// Tally tallies the voting results. The vote must be closed.
+func (v *QuadraticVote) Tally(k *big.Int) error {
+	if err := v.checkTally(); err != nil {
+		return err
+	}
+
+	if !isInRange(k) {
+		return errors.New("Invalid k")
+	}
+
+	t := v.newQuadraticTally()
+
+	res, err := t.tally(k)
+	if err != nil {
+		return err
+	}
+	v.res = res
+	v.phase = Tallied
+
+	return nil
+}
+
+// VerifyTallyRes verifies the tally results
+func (v *QuadraticVote) VerifyTallyRes() error {
+	if v.res == nil {
+		return errors.New("No tally results")
+	}
+
+	if err := v.res.verify(); err != nil {
+		return err
+	}
+	if err := v.res.checkContext(v.ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAuthPublicKey returns authority public key
+func (v *QuadraticVote) GetAuthPublicKey() (*big.Int, *big.Int) {
+	return new(big.Int).Set(v.gkX), new(big.Int).Set(v.gkY)
+}
+
+// GetTallyRes gets the tally result
+func (v *QuadraticVote) GetTallyRes() *OptionTallyRes {
+	return v.res
+}
@@ -0,0 +1,131 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+func TestQuadraticVoteTally(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	qVote, err := NewQuadraticVote(k.PublicKey.X, k.PublicKey.Y, authAddr, 3, 10)
+	assert.Nil(t, err)
+	assert.Nil(t, qVote.Open())
+
+	votes := [][]int64{{3, 0, -1}, {-2, 2, 1}, {0, -3, 0}}
+	expected := []int{1, -1, 0}
+
+	for _, v := range votes {
+		voterAddr := new(big.Int).SetBytes(getRandAddr())
+		b, err := NewQuadraticBallot(v, 10, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+		assert.Nil(t, err)
+		assert.Nil(t, qVote.Cast(b, voterAddr))
+	}
+
+	assert.NotNil(t, qVote.Tally(k.D))
+	assert.Nil(t, qVote.Close())
+	err = qVote.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, qVote.GetTallyRes().Counts())
+	assert.Nil(t, qVote.VerifyTallyRes())
+
+	// no ballots after tally
+	voterAddr := new(big.Int).SetBytes(getRandAddr())
+	b, err := NewQuadraticBallot(votes[0], 10, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, qVote.Cast(b, voterAddr))
+	assert.NotNil(t, qVote.Tally(k.D))
+}
+
+func TestQuadraticVoteInElection(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	m, err := NewManifest("e1", "Credits", []string{"a", "b"}, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	m2, err := NewManifest("e2", "Credits", []string{"a", "b"}, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+
+	qVote, err := NewQuadraticVote(k.PublicKey.X, k.PublicKey.Y, authAddr, 2, 5)
+	assert.Nil(t, err)
+	assert.Nil(t, qVote.SetElection(m.Hash()))
+	assert.Nil(t, qVote.Open())
+
+	var ballots []*QuadraticBallot
+	for _, v := range [][]int64{{2, -1}, {1, 1}} {
+		voterAddr := new(big.Int).SetBytes(getRandAddr())
+		b, err := NewQuadraticBallotInElection(m, v, 5, voterAddr)
+		assert.Nil(t, err)
+		assert.Nil(t, b.CheckElection(m))
+		assert.NotNil(t, b.CheckElection(nil))
+		assert.Nil(t, qVote.Cast(b, voterAddr))
+		ballots = append(ballots, b)
+	}
+
+	// ballots of another election are rejected
+	voterAddr := new(big.Int).SetBytes(getRandAddr())
+	b, err := NewQuadraticBallotInElection(m2, []int64{0, 1}, 5, voterAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, qVote.Cast(b, voterAddr))
+
+	// a square proof moved from a ballot of another election
+	other, err := NewQuadraticBallotInElection(m2, []int64{2, -1}, 5, ballots[0].Voter())
+	assert.Nil(t, err)
+	forged := *ballots[0]
+	forged.squares = []*zk.ProductProof{other.squares[0], ballots[0].squares[1]}
+	assert.NotNil(t, forged.VerifyBallot())
+
+	assert.Nil(t, qVote.Close())
+	assert.Nil(t, qVote.Tally(k.D))
+	assert.Nil(t, qVote.VerifyTallyRes())
+	assert.Equal(t, []int{3, 0}, qVote.GetTallyRes().Counts())
+	assert.Nil(t, qVote.GetTallyRes().CheckElection(m))
+	assert.NotNil(t, qVote.GetTallyRes().CheckElection(m2))
+
+	p, err := m.Params(authAddr)
+	assert.Nil(t, err)
+	tal, err := NewQuadraticTallyWithParams(p, 2, 5, ballots)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Nil(t, res.CheckElection(m))
+	_, err = NewQuadraticTally(k.PublicKey.X, k.PublicKey.Y, authAddr, 2, 5, ballots)
+	assert.NotNil(t, err)
+}
+
+func TestQuadraticBallot(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	voterAddr := new(big.Int).SetBytes(getRandAddr())
+
+	// 3^2 + 2^2 > 10
+	_, err := NewQuadraticBallot([]int64{3, -2}, 10, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.NotNil(t, err)
+
+	b, err := NewQuadraticBallot([]int64{-3, 1}, 10, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, b.VerifyBallot())
+
+	data, err := json.Marshal(b)
+	assert.Nil(t, err)
+	var reconstruct QuadraticBallot
+	assert.Nil(t, json.Unmarshal(data, &reconstruct))
+	assert.Nil(t, reconstruct.VerifyBallot())
+
+	// a ballot without votes is rejected
+	obj := b.BuildJSONQuadraticBallot()
+	obj.Votes = nil
+	assert.NotNil(t, new(QuadraticBallot).FromJSONQuadraticBallot(obj))
+
+	// replacing the unspent credits breaks the budget proof
+	other, err := NewQuadraticBallot([]int64{0, 0}, 10, k.PublicKey.X, k.PublicKey.Y, voterAddr)
+	assert.Nil(t, err)
+	reconstruct.slack = other.slack
+	assert.NotNil(t, reconstruct.VerifyBallot())
+}
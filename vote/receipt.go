@@ -0,0 +1,305 @@
+package vote
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
+)
+
+// ballotLeaf - entry of the bulletin board, the ballot counted for a voter
+type ballotLeaf struct {
+	voter  [32]byte // voter id
+	ballot [32]byte // hash of the ballot
+}
+
+func (l ballotLeaf) hash() [32]byte {
+	return merkle.HashLeaf(append(append([]byte(nil), l.voter[:]...), l.ballot[:]...))
+}
+
+func newBallotLeaf(voter [32]byte, b Ballot) (ballotLeaf, error) {
+	h, err := ballotHash(b)
+	if err != nil {
+		return ballotLeaf{}, err
+	}
+	return ballotLeaf{voter, h}, nil
+}
+
+// compareLeaves orders leaves by voter id, then by ballot hash
+func compareLeaves(a, b *ballotLeaf) int {
+	if c := bytes.Compare(a.voter[:], b.voter[:]); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ballot[:], b.ballot[:])
+}
+
+func sortLeaves(leaves []ballotLeaf) {
+	sort.Slice(leaves, func(i, j int) bool {
+		return compareLeaves(&leaves[i], &leaves[j]) < 0
+	})
+}
+
+// ballotsRoot returns the root of the bulletin board over leaves, which
+// commits to the set of ballots counted, zero if there are none
+func ballotsRoot(leaves []ballotLeaf) [32]byte {
+	if len(leaves) == 0 {
+		return [32]byte{}
+	}
+	return newBoard(leaves).Root()
+}
+
+// Board - bulletin board of the counted ballots
+//
+// It is a Merkle tree whose leaves bind each voter id to the hash of the
+// ballot counted for the voter, sorted by voter id. Its root is the
+// commitment to the ballots in the tally result.
+type Board struct {
+	leaves []ballotLeaf
+	tree   *merkle.Tree
+}
+
+func newBoard(leaves []ballotLeaf) *Board {
+	sorted := append([]ballotLeaf(nil), leaves...)
+	sortLeaves(sorted)
+
+	hashes := make([][32]byte, len(sorted))
+	for i, l := range sorted {
+		hashes[i] = l.hash()
+	}
+	t, _ := merkle.NewTree(hashes)
+
+	return &Board{sorted, t}
+}
+
+// NewBinaryBoard builds the bulletin board of binary ballots
+func NewBinaryBoard(ballots []*BinaryBallot) (*Board, error) {
+	if len(ballots) == 0 {
+		return nil, errors.New("No ballots")
+	}
+
+	leaves := make([]ballotLeaf, len(ballots))
+	for i, b := range ballots {
+		var err error
+		if leaves[i], err = newBallotLeaf(voterID(b), b); err != nil {
+			return nil, err
+		}
+	}
+	return newBoard(leaves), nil
+}
+
+// NewTernaryBoard builds the bulletin board of ternary ballots
+func NewTernaryBoard(ballots []*TernaryBallot) (*Board, error) {
+	if len(ballots) == 0 {
+		return nil, errors.New("No ballots")
+	}
+
+	leaves := make([]ballotLeaf, len(ballots))
+	for i, b := range ballots {
+		var err error
+		if leaves[i], err = newBallotLeaf(voterID(b.yes), b); err != nil {
+			return nil, err
+		}
+	}
+	return newBoard(leaves), nil
+}
+
+// Root returns the root of the board
+func (bd *Board) Root() [32]byte {
+	return bd.tree.Root()
+}
+
+// Size returns the number of ballots on the board
+func (bd *Board) Size() int {
+	return len(bd.leaves)
+}
+
+// prove returns the index and inclusion proof of the leaf
+func (bd *Board) prove(l ballotLeaf) (int, *merkle.Proof, error) {
+	i := sort.Search(len(bd.leaves), func(i int) bool {
+		c := bytes.Compare(bd.leaves[i].voter[:], l.voter[:])
+		return c > 0 || c == 0 && bytes.Compare(bd.leaves[i].ballot[:], l.ballot[:]) >= 0
+	})
+	if i == len(bd.leaves) || bd.leaves[i] != l {
+		return 0, nil, errors.New("Ballot not on board")
+	}
+
+	p, err := bd.tree.Prove(i)
+	if err != nil {
+		return 0, nil, err
+	}
+	return i, p, nil
+}
+
+// Receipt - receipt of a counted ballot signed by the vote operator
+//
+// It proves that the ballot was appended to the cast log of the vote, see
+// BinaryVote.CastLog, at the given index. The log only grows, so the entry
+// keeps its index and VerifyLog checks it against the final root. Whether
+// the ballot is counted in the tally, i.e., not replaced later, is checked
+// by VerifyInclusion against the root committed in the tally result.
+type Receipt struct {
+	Voter  [32]byte // voter id
+	Ballot [32]byte // hash of the ballot
+	Index  int      // index of the entry in the cast log
+	Size   int      // size of the cast log after the cast
+	Root   [32]byte // root of the cast log after the cast
+
+	proof *merkle.Proof // inclusion proof of the leaf in Root
+	r, s  *big.Int      // signature of the operator
+}
+
+// newReceipt issues and signs the receipt of leaf l, the i-th entry of the
+// cast log
+func newReceipt(log *merkle.Tree, i int, l ballotLeaf, key *ecdsa.PrivateKey) (*Receipt, error) {
+	if log.Leaf(i) != l.hash() {
+		return nil, errors.New("Ballot not in cast log")
+	}
+	p, err := log.Prove(i)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &Receipt{
+		Voter:  l.voter,
+		Ballot: l.ballot,
+		Index:  i,
+		Size:   log.Size(),
+		Root:   log.Root(),
+		proof:  p,
+	}
+	d := rc.digest()
+	if rc.r, rc.s, err = ecdsa.Sign(rand.Reader, key, d[:]); err != nil {
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+// digest returns the hash signed by the operator
+func (rc *Receipt) digest() [32]byte {
+	var buf bytes.Buffer
+	buf.Write(rc.Voter[:])
+	buf.Write(rc.Ballot[:])
+	binary.Write(&buf, binary.BigEndian, int64(rc.Index))
+	binary.Write(&buf, binary.BigEndian, int64(rc.Size))
+	buf.Write(rc.Root[:])
+	return sha256.Sum256(buf.Bytes())
+}
+
+// Verify checks the signature of the operator with public key (X, Y) and
+// the inclusion proof of the ballot in Root
+func (rc *Receipt) Verify(X, Y *big.Int) error {
+	if rc.proof == nil || rc.r == nil || rc.s == nil {
+		return errors.New("Missing proof or signature")
+	}
+	if !isOnCurve(X, Y) {
+		return errors.New("Invalid public key")
+	}
+
+	d := rc.digest()
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: curve, X: X, Y: Y}, d[:], rc.r, rc.s) {
+		return errors.New("Invalid receipt signature")
+	}
+	if !rc.proof.VerifyAt(rc.Root, ballotLeaf{rc.Voter, rc.Ballot}.hash(), rc.Index, rc.Size) {
+		return errors.New("Ballot not included in root")
+	}
+	return nil
+}
+
+// VerifyLog checks that the ballot is still in the cast log of the given
+// root and size, e.g., the final ones, given the inclusion proof of entry
+// Index, see BinaryVote.ProveCast
+func (rc *Receipt) VerifyLog(root [32]byte, size int, p *merkle.Proof) error {
+	if size < rc.Size || (size == rc.Size && root != rc.Root) {
+		return errors.New("Cast log doesn't extend receipt")
+	}
+	if p == nil || !p.VerifyAt(root, ballotLeaf{rc.Voter, rc.Ballot}.hash(), rc.Index, size) {
+		return errors.New("Ballot not included in cast log")
+	}
+	return nil
+}
+
+// VerifyInclusion checks that the ballot is on bd and that bd has the
+// given root, e.g., the commitment to the ballots in a tally result
+func (rc *Receipt) VerifyInclusion(root [32]byte, bd *Board) error {
+	if bd.Root() != root {
+		return errors.New("Board doesn't match root")
+	}
+
+	l := ballotLeaf{rc.Voter, rc.Ballot}
+	_, p, err := bd.prove(l)
+	if err != nil {
+		return err
+	}
+	if !p.Verify(root, l.hash()) {
+		return errors.New("Ballot not included in root")
+	}
+	return nil
+}
+
+// BuildJSONReceipt builds json object
+func (rc *Receipt) BuildJSONReceipt() *JSONReceipt {
+	return &JSONReceipt{
+		Voter:  merkle.HashToHexStr(rc.Voter),
+		Ballot: merkle.HashToHexStr(rc.Ballot),
+		Index:  rc.Index,
+		Size:   rc.Size,
+		Root:   merkle.HashToHexStr(rc.Root),
+		Proof:  rc.proof.BuildJSONProof(),
+		R:      common.BigIntToHexStr(rc.r),
+		S:      common.BigIntToHexStr(rc.s),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (rc *Receipt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rc.BuildJSONReceipt())
+}
+
+// FromJSONReceipt reconstructs from json object
+func (rc *Receipt) FromJSONReceipt(obj *JSONReceipt) error {
+	var err error
+
+	rc1 := &Receipt{Index: obj.Index, Size: obj.Size, proof: new(merkle.Proof)}
+	hashes := []struct {
+		dst *[32]byte
+		src string
+	}{
+		{&rc1.Voter, obj.Voter}, {&rc1.Ballot, obj.Ballot}, {&rc1.Root, obj.Root},
+	}
+	for _, h := range hashes {
+		if *h.dst, err = merkle.HexStrToHash(h.src); err != nil {
+			return err
+		}
+	}
+	if err = rc1.proof.FromJSONProof(obj.Proof); err != nil {
+		return err
+	}
+	if rc1.r, err = common.HexStrToBigInt(obj.R); err != nil {
+		return err
+	}
+	if rc1.s, err = common.HexStrToBigInt(obj.S); err != nil {
+		return err
+	}
+
+	*rc = *rc1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (rc *Receipt) UnmarshalJSON(data []byte) error {
+	var obj JSONReceipt
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return rc.FromJSONReceipt(&obj)
+}
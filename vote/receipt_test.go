@@ -0,0 +1,111 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceipt(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	op, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	v, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, v.Open())
+
+	addrs := make([]*big.Int, 4)
+	for i := range addrs {
+		addrs[i] = new(big.Int).SetBytes(getRandAddr())
+	}
+	b := genBinaryBallot(true, addrs[0], k.PublicKey.X, k.PublicKey.Y, t)
+	_, err = v.CastWithReceipt(b, addrs[0])
+	assert.NotNil(t, err)
+	assert.Nil(t, v.SetReceiptKey(op))
+
+	var (
+		receipts []*Receipt
+		ballots  []*BinaryBallot
+	)
+	for i, value := range []bool{true, false, true, true} {
+		b := genBinaryBallot(value, addrs[i], k.PublicKey.X, k.PublicKey.Y, t)
+		rc, err := v.CastWithReceipt(b, addrs[i])
+		assert.Nil(t, err)
+		assert.Equal(t, i, rc.Index)
+		assert.Equal(t, i+1, rc.Size)
+		assert.Nil(t, rc.Verify(op.PublicKey.X, op.PublicKey.Y))
+		assert.NotNil(t, rc.Verify(k.PublicKey.X, k.PublicKey.Y))
+
+		data, err := json.Marshal(rc)
+		assert.Nil(t, err)
+		rc1 := new(Receipt)
+		assert.Nil(t, json.Unmarshal(data, rc1))
+		assert.Nil(t, rc1.Verify(op.PublicKey.X, op.PublicKey.Y))
+
+		receipts = append(receipts, rc1)
+		ballots = append(ballots, b)
+	}
+
+	// the last voter casts again and the first ballot is replaced
+	b = genBinaryBallot(false, addrs[3], k.PublicKey.X, k.PublicKey.Y, t)
+	rc, err := v.CastWithReceipt(b, addrs[3])
+	assert.Nil(t, err)
+	assert.Equal(t, 4, rc.Index)
+	ballots[3] = b
+
+	// receipts keep their index in the final cast log, replaced ballots
+	// included
+	root, size := v.CastLog()
+	assert.Equal(t, 5, size)
+	assert.Equal(t, rc.Root, root)
+	for _, rc := range receipts {
+		p, err := v.ProveCast(rc.Index)
+		assert.Nil(t, err)
+		assert.Nil(t, rc.VerifyLog(root, size, p))
+	}
+	p, err := v.ProveCast(1)
+	assert.Nil(t, err)
+	assert.NotNil(t, receipts[0].VerifyLog(root, size, p))
+	p, err = v.ProveCast(0)
+	assert.Nil(t, err)
+	assert.NotNil(t, receipts[0].VerifyLog(receipts[0].Root, size, p))
+
+	// the cast log survives a json round trip
+	data, err := json.Marshal(v)
+	assert.Nil(t, err)
+	v1 := new(BinaryVote)
+	assert.Nil(t, json.Unmarshal(data, v1))
+	root1, size1 := v1.CastLog()
+	assert.Equal(t, root, root1)
+	assert.Equal(t, size, size1)
+
+	assert.Nil(t, v.Close())
+	assert.Nil(t, v.Tally(k.D))
+	root, err = v.GetTallyRes().BallotsRoot()
+	assert.Nil(t, err)
+
+	bd, err := v.Board()
+	assert.Nil(t, err)
+	assert.Equal(t, root, bd.Root())
+
+	bd, err = NewBinaryBoard(ballots)
+	assert.Nil(t, err)
+	for _, rc := range receipts[:3] {
+		assert.Nil(t, rc.VerifyInclusion(root, bd))
+	}
+	assert.NotNil(t, receipts[3].VerifyInclusion(root, bd))
+
+	// board not matching the tally
+	bd, err = NewBinaryBoard(ballots[:3])
+	assert.Nil(t, err)
+	assert.NotNil(t, receipts[0].VerifyInclusion(root, bd))
+
+	// tampered receipt
+	receipts[0].Index = 2
+	assert.NotNil(t, receipts[0].Verify(op.PublicKey.X, op.PublicKey.Y))
+}
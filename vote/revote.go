@@ -0,0 +1,181 @@
+package vote
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
+)
+
+// RevotePolicy decides which ballot counts when a voter casts more than once
+type RevotePolicy int
+
+// re-vote policies
+const (
+	LastWins  RevotePolicy = iota // a later ballot replaces the earlier one
+	FirstWins                     // later ballots are ignored
+	Reject                        // later ballots are rejected with ErrRevote
+)
+
+// ErrRevote is returned by Cast when a voter casts again under Reject
+var ErrRevote = errors.New("Voter has already cast")
+
+func (p RevotePolicy) String() string {
+	switch p {
+	case LastWins:
+		return "last-wins"
+	case FirstWins:
+		return "first-wins"
+	case Reject:
+		return "reject"
+	}
+	return "unknown"
+}
+
+// Replacement - record of a voter casting again
+type Replacement struct {
+	Seq      int          // sequence number of the cast among all valid casts
+	Data     *big.Int     // data that identifies the voter
+	Old, New [32]byte     // hashes of the counted ballot and the new ballot
+	Policy   RevotePolicy // policy applied
+	Replaced bool         // whether the new ballot replaced the counted one
+}
+
+// revoteLog applies the re-vote policy and keeps the replacement history.
+// It is embedded by the vote types.
+type revoteLog struct {
+	policy  RevotePolicy
+	seq     int
+	counted map[[32]byte][32]byte // voter id => hash of the counted ballot
+	history []*Replacement
+}
+
+func newRevoteLog() revoteLog {
+	return revoteLog{counted: make(map[[32]byte][32]byte)}
+}
+
+// SetRevotePolicy sets the re-vote policy, LastWins by default. It can only
+// be changed before any ballot is cast.
+func (l *revoteLog) SetRevotePolicy(p RevotePolicy) error {
+	if p < LastWins || p > Reject {
+		return errors.New("Invalid re-vote policy")
+	}
+	if l.seq > 0 {
+		return errors.New("Ballots already cast")
+	}
+	l.policy = p
+	return nil
+}
+
+// RevotePolicy returns the re-vote policy
+func (l *revoteLog) RevotePolicy() RevotePolicy {
+	return l.policy
+}
+
+// History returns the casts made by voters who had already cast
+func (l *revoteLog) History() []*Replacement {
+	return append([]*Replacement(nil), l.history...)
+}
+
+// admit applies the policy to a verified ballot cast by voter id. It
+// returns whether the ballot should be counted, replacing the earlier one
+// if any.
+func (l *revoteLog) admit(id [32]byte, data *big.Int, b Ballot) (bool, error) {
+	h, err := ballotHash(b)
+	if err != nil {
+		return false, err
+	}
+
+	l.seq = l.seq + 1
+
+	old, ok := l.counted[id]
+	if !ok {
+		l.counted[id] = h
+		return true, nil
+	}
+
+	r := &Replacement{
+		Seq: l.seq, Data: new(big.Int).Set(data), Old: old, New: h, Policy: l.policy,
+	}
+	if l.policy == LastWins {
+		r.Replaced = true
+		l.counted[id] = h
+	}
+	l.history = append(l.history, r)
+
+	if l.policy == Reject {
+		return false, ErrRevote
+	}
+
+	return r.Replaced, nil
+}
+
+// ballotHash returns the hash of the json encoding of a ballot
+func ballotHash(b Ballot) ([32]byte, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// parseRevotePolicy converts the string of a policy back
+func parseRevotePolicy(s string) (RevotePolicy, error) {
+	for p := LastWins; p <= Reject; p++ {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return 0, errors.New("Invalid re-vote policy")
+}
+
+// BuildJSONReplacement builds json object
+func (r *Replacement) BuildJSONReplacement() *JSONReplacement {
+	return &JSONReplacement{
+		Seq:      r.Seq,
+		Data:     common.BigIntToHexStr(r.Data),
+		Old:      merkle.HashToHexStr(r.Old),
+		New:      merkle.HashToHexStr(r.New),
+		Policy:   r.Policy.String(),
+		Replaced: r.Replaced,
+	}
+}
+
+// MarshalJSON implements json marshal
+func (r *Replacement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.BuildJSONReplacement())
+}
+
+// FromJSONReplacement reconstructs from json object
+func (r *Replacement) FromJSONReplacement(obj *JSONReplacement) error {
+	var err error
+
+	r.Seq = obj.Seq
+	r.Replaced = obj.Replaced
+	if r.Data, err = common.HexStrToBigInt(obj.Data); err != nil {
+		return err
+	}
+	if r.Old, err = merkle.HexStrToHash(obj.Old); err != nil {
+		return err
+	}
+	if r.New, err = merkle.HexStrToHash(obj.New); err != nil {
+		return err
+	}
+	if r.Policy, err = parseRevotePolicy(obj.Policy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (r *Replacement) UnmarshalJSON(data []byte) error {
+	var obj JSONReplacement
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return r.FromJSONReplacement(&obj)
+}
@@ -0,0 +1,228 @@
+package vote
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// BallotSheet - answers of a voter to several yes/no questions
+//
+// The sheet holds one binary ballot per question of the election, all cast
+// by the same voter. The ballot answering question i is bound to the
+// context of that question so that answers can't be moved between
+// questions.
+type BallotSheet struct {
+	ballots []*BinaryBallot // ballots[i] answers question i
+
+	eligibility *Eligibility // inclusion proof in the voter registry, optional
+}
+
+// NewBallotSheet generates a ballot sheet answering the questions of the
+// election described by m
+//
+// data contains the data (e.g., account address) that identifies the voter.
+func NewBallotSheet(m *Manifest, values []bool, data *big.Int) (*BallotSheet, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	if len(m.Questions) == 0 {
+		return nil, errors.New("No questions")
+	}
+	if len(values) != len(m.Questions) {
+		return nil, errors.New("Answers don't match questions")
+	}
+
+	hash := m.Hash()
+	s := &BallotSheet{ballots: make([]*BinaryBallot, len(values))}
+	for i, v := range values {
+		a, err := randScalar()
+		if err != nil {
+			return nil, err
+		}
+		ctx := questionContext(hash, i)
+		if s.ballots[i], err = newBinaryBallot(v, a, m.GKX, m.GKY, data, ctx[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// questionContext returns the context bound into the ballots and tally
+// proofs of question i of the election with the given manifest hash
+func questionContext(election [32]byte, i int) [32]byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(i))
+	return sha256.Sum256(append(append(election[:], "question"...), buf[:]...))
+}
+
+// VerifyBallot verifies ballot sheet
+func (s *BallotSheet) VerifyBallot() error {
+	if len(s.ballots) == 0 {
+		return errors.New("Empty ballot sheet")
+	}
+
+	for i, b := range s.ballots {
+		if b == nil {
+			return fmt.Errorf("Question [%d]: missing ballot", i)
+		}
+		if err := b.VerifyBallot(); err != nil {
+			return fmt.Errorf("Question [%d]: %v", i, err)
+		}
+	}
+
+	// all ballots are cast by the same voter with the same key
+	gkX, gkY := s.ballots[0].proof.PublicKey()
+	data := s.ballots[0].proof.Data()
+	for _, b := range s.ballots[1:] {
+		if err := b.CheckKey(gkX, gkY); err != nil {
+			return err
+		}
+		if b.proof.Data().Cmp(data) != 0 {
+			return errors.New("Ballots cast by different voters")
+		}
+	}
+
+	return nil
+}
+
+// Size returns the number of questions answered
+func (s *BallotSheet) Size() int {
+	return len(s.ballots)
+}
+
+// Ballot returns the ballot answering question i
+func (s *BallotSheet) Ballot(i int) *BinaryBallot {
+	return s.ballots[i]
+}
+
+// Kind returns SheetKind
+func (s *BallotSheet) Kind() BallotKind {
+	return SheetKind
+}
+
+// Voter returns the data bound to the ballots that identifies the voter
+func (s *BallotSheet) Voter() *big.Int {
+	return s.ballots[0].Voter()
+}
+
+// PublicKey returns g^k the ballots are encrypted with
+func (s *BallotSheet) PublicKey() (*big.Int, *big.Int) {
+	return s.ballots[0].PublicKey()
+}
+
+// Ciphertexts returns the encrypted answer to each question
+func (s *BallotSheet) Ciphertexts() []*zk.Ciphertext {
+	cs := make([]*zk.Ciphertext, len(s.ballots))
+	for i, b := range s.ballots {
+		cs[i] = b.ciphertext()
+	}
+	return cs
+}
+
+// CheckKey checks that the sheet is encrypted with the authority public
+// key g^k
+func (s *BallotSheet) CheckKey(gkX, gkY *big.Int) error {
+	for _, b := range s.ballots {
+		if err := b.CheckKey(gkX, gkY); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckElection checks that the sheet answers all questions of the election
+// described by m, each ballot bound to its question
+func (s *BallotSheet) CheckElection(m *Manifest) error {
+	if len(s.ballots) != len(m.Questions) {
+		return errors.New("Answers don't match questions")
+	}
+
+	hash := m.Hash()
+	for i, b := range s.ballots {
+		ctx := questionContext(hash, i)
+		if err := checkContext(ctx[:], b.proof.Context()); err != nil {
+			return fmt.Errorf("Question [%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// SetEligibility attaches the inclusion proof of the voter in the registry
+func (s *BallotSheet) SetEligibility(e *Eligibility) {
+	s.eligibility = e
+}
+
+// Eligibility returns the attached inclusion proof, nil if none
+func (s *BallotSheet) Eligibility() *Eligibility {
+	return s.eligibility
+}
+
+// VerifyEligibility checks the attached inclusion proof of the voter
+// against the registry root
+func (s *BallotSheet) VerifyEligibility(root [32]byte) error {
+	if s.eligibility == nil {
+		return errors.New("Missing eligibility proof")
+	}
+	return s.eligibility.Verify(root, s.ballots[0].proof.Data())
+}
+
+// BuildJSONBallotSheet builds json object
+func (s *BallotSheet) BuildJSONBallotSheet() *JSONBallotSheet {
+	obj := &JSONBallotSheet{Ballots: make([]*JSONBinaryBallot, len(s.ballots))}
+	for i, b := range s.ballots {
+		obj.Ballots[i] = b.BuildJSONBinaryBallot()
+	}
+	if s.eligibility != nil {
+		obj.Eligibility = s.eligibility.BuildJSONEligibility()
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (s *BallotSheet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.BuildJSONBallotSheet())
+}
+
+// FromJSONBallotSheet reconstructs from json object
+func (s *BallotSheet) FromJSONBallotSheet(obj *JSONBallotSheet) error {
+	if len(obj.Ballots) == 0 {
+		return errors.New("Empty ballot sheet")
+	}
+
+	s1 := &BallotSheet{ballots: make([]*BinaryBallot, len(obj.Ballots))}
+	for i, b := range obj.Ballots {
+		if b == nil {
+			return fmt.Errorf("Question [%d]: missing ballot", i)
+		}
+		s1.ballots[i] = new(BinaryBallot)
+		if err := s1.ballots[i].FromJSONBinaryBallot(b); err != nil {
+			return err
+		}
+	}
+	if obj.Eligibility != nil {
+		s1.eligibility = new(Eligibility)
+		if err := s1.eligibility.FromJSONEligibility(obj.Eligibility); err != nil {
+			return err
+		}
+	}
+
+	*s = *s1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (s *BallotSheet) UnmarshalJSON(data []byte) error {
+	var obj JSONBallotSheet
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return s.FromJSONBallotSheet(&obj)
+}
@@ -0,0 +1,276 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// SheetTally - tally of ballot sheets, one binary tally per question
+type SheetTally struct {
+	questions []*BinaryTally
+
+	params *ElectionParams
+}
+
+// SheetTallyRes - results of the questions of ballot sheets, each decrypted
+// with zk proofs and evaluated by the turnout and pass rules of the election
+type SheetTallyRes struct {
+	questions []*BinaryTallyRes
+}
+
+// question returns the parameters of question i of a ballot sheet, whose
+// ballots and tally proofs are bound to the context of the question
+func (p *ElectionParams) question(i int) (*ElectionParams, error) {
+	if p.election == nil {
+		return nil, errors.New("Missing election")
+	}
+
+	q := *p
+	ctx := questionContext(*p.election, i)
+	q.election = &ctx
+	return &q, nil
+}
+
+// NewSheetTally creates a new tally of ballot sheets answering n questions
+// of the election set in p
+//
+// Sheets are checked as in NewBinaryTallyWithParams, the eligibility proof
+// of the sheet standing for all of its ballots.
+func NewSheetTally(p *ElectionParams, n int, sheets []*BallotSheet) (*SheetTally, error) {
+	if n <= 0 {
+		return nil, errors.New("Invalid number of questions")
+	}
+	if err := p.checkVoters(len(sheets)); err != nil {
+		return nil, err
+	}
+
+	t := &SheetTally{questions: make([]*BinaryTally, n), params: p}
+	params := make([]*ElectionParams, n)
+	for i := range t.questions {
+		var err error
+		if params[i], err = p.question(i); err != nil {
+			return nil, err
+		}
+		t.questions[i] = &BinaryTally{
+			gkX:      new(big.Int).Set(p.gkX),
+			gkY:      new(big.Int).Set(p.gkY),
+			authData: new(big.Int).Set(p.authData),
+			HX:       new(big.Int),
+			HY:       new(big.Int),
+			YX:       new(big.Int),
+			YY:       new(big.Int),
+			n:        len(sheets),
+			params:   params[i],
+		}
+	}
+
+	registry := p.uniqueVoters()
+	voters := make(map[[32]byte]bool)
+
+	var (
+		total  uint64
+		leaves []ballotLeaf
+	)
+	for _, s := range sheets {
+		if s == nil {
+			return nil, errors.New("Missing ballot")
+		}
+		if err := s.VerifyBallot(); err != nil {
+			return nil, err
+		}
+		if err := s.CheckKey(p.gkX, p.gkY); err != nil {
+			return nil, err
+		}
+		if s.Size() != n {
+			return nil, errors.New("Answers don't match questions")
+		}
+
+		var w uint64
+		for i, b := range s.ballots {
+			var err error
+			if w, err = params[i].checkVoter(b.proof.Context(), b.proof.Data(), s.eligibility); err != nil {
+				return nil, fmt.Errorf("Question [%d]: %v", i, err)
+			}
+		}
+		id := voterID(s.ballots[0])
+		if registry {
+			if voters[id] {
+				return nil, errors.New("Duplicate voter")
+			}
+			voters[id] = true
+		}
+		l, err := newBallotLeaf(id, s)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, l)
+		total = total + w
+
+		for i, b := range s.ballots {
+			q := t.questions[i]
+			hX, hY, yX, yY := b.weighted(w)
+			q.HX, q.HY = curve.Add(q.HX, q.HY, hX, hY)
+			q.YX, q.YY = curve.Add(q.YX, q.YY, yX, yY)
+		}
+	}
+
+	root := ballotsRoot(leaves)
+	for _, q := range t.questions {
+		q.weight = int(total)
+		q.root = root
+		if registry {
+			q.bound = int(total)
+		}
+	}
+
+	return t, nil
+}
+
+// Size returns the number of questions
+func (t *SheetTally) Size() int {
+	return len(t.questions)
+}
+
+// SetBound sets the upper bound of the yes votes of each question, see
+// BinaryTally.SetBound
+func (t *SheetTally) SetBound(max int) error {
+	for _, q := range t.questions {
+		if err := q.SetBound(max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tally computes the result of each question and zk proofs
+func (t *SheetTally) Tally(k *big.Int) (*SheetTallyRes, error) {
+	r := &SheetTallyRes{questions: make([]*BinaryTallyRes, len(t.questions))}
+	for i, q := range t.questions {
+		var err error
+		if r.questions[i], err = q.tally(k); err != nil {
+			return nil, fmt.Errorf("Question [%d]: %v", i, err)
+		}
+	}
+	return r, nil
+}
+
+// Size returns the number of questions
+func (r *SheetTallyRes) Size() int {
+	return len(r.questions)
+}
+
+// Question returns the result of question i
+func (r *SheetTallyRes) Question(i int) *BinaryTallyRes {
+	return r.questions[i]
+}
+
+// Verify verifies the proofs of the results of all questions, which must
+// commit to the same ballot sheets
+func (r *SheetTallyRes) Verify() error {
+	if len(r.questions) == 0 {
+		return errors.New("No questions")
+	}
+
+	root, err := r.questions[0].BallotsRoot()
+	if err != nil {
+		return err
+	}
+	for i, q := range r.questions {
+		if err := q.verify(); err != nil {
+			return fmt.Errorf("Question [%d]: %v", i, err)
+		}
+		if qRoot, err := q.BallotsRoot(); err != nil || qRoot != root {
+			return fmt.Errorf("Question [%d]: ballots don't match commitment", i)
+		}
+	}
+	return nil
+}
+
+// VerifyBallots fully verifies the result against the ballot sheets it is
+// claimed to be computed from, as BinaryTallyRes.VerifyBallots does for
+// each question
+func (r *SheetTallyRes) VerifyBallots(p *ElectionParams, sheets []*BallotSheet) error {
+	if err := r.Verify(); err != nil {
+		return err
+	}
+
+	t, err := NewSheetTally(p, len(r.questions), sheets)
+	if err != nil {
+		return err
+	}
+	for i, q := range r.questions {
+		if err := q.verifyTally(t.questions[i]); err != nil {
+			return fmt.Errorf("Question [%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// CheckElection checks that the result answers all questions of the
+// election described by m, each bound to its question
+func (r *SheetTallyRes) CheckElection(m *Manifest) error {
+	if len(r.questions) != len(m.Questions) {
+		return errors.New("Results don't match questions")
+	}
+
+	hash := m.Hash()
+	for i, q := range r.questions {
+		ctx := questionContext(hash, i)
+		if err := q.checkContext(ctx[:]); err != nil {
+			return fmt.Errorf("Question [%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// BallotsRoot returns the committed root of the bulletin board of the
+// ballot sheets counted
+func (r *SheetTallyRes) BallotsRoot() ([32]byte, error) {
+	if len(r.questions) == 0 {
+		return [32]byte{}, errors.New("No questions")
+	}
+	return r.questions[0].BallotsRoot()
+}
+
+// BuildJSONSheetTallyRes builds json object
+func (r *SheetTallyRes) BuildJSONSheetTallyRes() *JSONSheetTallyRes {
+	obj := &JSONSheetTallyRes{Questions: make([]*JSONBinaryTallyRes, len(r.questions))}
+	for i, q := range r.questions {
+		obj.Questions[i] = q.BuildJSONBinaryTallyRes()
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (r *SheetTallyRes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.BuildJSONSheetTallyRes())
+}
+
+// FromJSONSheetTallyRes reconstructs from json object
+func (r *SheetTallyRes) FromJSONSheetTallyRes(obj *JSONSheetTallyRes) error {
+	r1 := &SheetTallyRes{questions: make([]*BinaryTallyRes, len(obj.Questions))}
+	for i, q := range obj.Questions {
+		if q == nil {
+			return fmt.Errorf("Question [%d]: missing result", i)
+		}
+		r1.questions[i] = new(BinaryTallyRes)
+		if err := r1.questions[i].FromJSONBinaryTallyRes(q); err != nil {
+			return err
+		}
+	}
+
+	*r = *r1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (r *SheetTallyRes) UnmarshalJSON(data []byte) error {
+	var obj JSONSheetTallyRes
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return r.FromJSONSheetTallyRes(&obj)
+}
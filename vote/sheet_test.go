@@ -0,0 +1,103 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBallotSheet(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	m, err := NewManifest("e1", "Referendum", nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	m.Questions = []string{"Q1", "Q2", "Q3"}
+	p, err := m.Params(authAddr)
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(m)
+	assert.Nil(t, err)
+	m1 := new(Manifest)
+	assert.Nil(t, json.Unmarshal(data, m1))
+	assert.Equal(t, m.Hash(), m1.Hash())
+
+	answers := [][]bool{
+		{true, false, true},
+		{true, true, false},
+		{false, false, true},
+		{true, false, true},
+	}
+	var sheets []*BallotSheet
+	for _, values := range answers {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		s, err := NewBallotSheet(m, values, addr)
+		assert.Nil(t, err)
+		assert.Nil(t, s.CheckElection(m))
+
+		data, err := json.Marshal(s)
+		assert.Nil(t, err)
+		s1 := new(BallotSheet)
+		assert.Nil(t, json.Unmarshal(data, s1))
+		assert.Equal(t, s, s1)
+		sheets = append(sheets, s1)
+	}
+
+	_, err = NewBallotSheet(m, []bool{true}, authAddr)
+	assert.NotNil(t, err)
+
+	tal, err := NewSheetTally(p, len(m.Questions), sheets)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.Size())
+	for i, V := range []int{3, 1, 3} {
+		assert.Equal(t, V, res.Question(i).V)
+		assert.Equal(t, 4-V, res.Question(i).No)
+	}
+	assert.True(t, res.Question(0).Passed)
+	assert.False(t, res.Question(1).Passed)
+
+	data, err = json.Marshal(res)
+	assert.Nil(t, err)
+	res1 := new(SheetTallyRes)
+	assert.Nil(t, json.Unmarshal(data, res1))
+	assert.Nil(t, res1.Verify())
+	assert.Nil(t, res1.CheckElection(m))
+	assert.Nil(t, res1.VerifyBallots(p, sheets))
+	assert.NotNil(t, res1.VerifyBallots(p, sheets[1:]))
+
+	// answers swapped between questions
+	s := sheets[0]
+	s.ballots[0], s.ballots[1] = s.ballots[1], s.ballots[0]
+	assert.Nil(t, s.VerifyBallot())
+	assert.NotNil(t, s.CheckElection(m))
+	_, err = NewSheetTally(p, len(m.Questions), sheets)
+	assert.NotNil(t, err)
+	s.ballots[0], s.ballots[1] = s.ballots[1], s.ballots[0]
+
+	// answers of different voters
+	s = &BallotSheet{ballots: []*BinaryBallot{sheets[0].ballots[0], sheets[1].ballots[1], sheets[0].ballots[2]}}
+	assert.NotNil(t, s.VerifyBallot())
+
+	// wrong number of answers
+	s = &BallotSheet{ballots: sheets[0].ballots[:2]}
+	_, err = NewSheetTally(p, len(m.Questions), []*BallotSheet{s})
+	assert.NotNil(t, err)
+
+	// missing and empty sheets
+	_, err = NewSheetTally(p, len(m.Questions), []*BallotSheet{sheets[0], nil})
+	assert.NotNil(t, err)
+	_, err = NewSheetTally(p, len(m.Questions), []*BallotSheet{{}})
+	assert.NotNil(t, err)
+
+	// result of another election
+	m2, err := NewManifest("e2", "Referendum", nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	m2.Questions = m.Questions
+	assert.NotNil(t, res1.CheckElection(m2))
+}
@@ -0,0 +1,211 @@
+package vote
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/merkle"
+)
+
+// BallotStore - append-only log of cast and spoiled ballots
+//
+// Entries are hash-chained by the vote appending them, so a store only has
+// to keep them in order. Load returns all entries appended so far.
+type BallotStore interface {
+	Append(e *StoreEntry) error
+	Load() ([]*StoreEntry, error)
+}
+
+// StoreEntry - entry of a ballot store, either a cast ballot or a spoiled
+// one
+type StoreEntry struct {
+	Seq     int            // position in the log, starting from 1
+	Prev    [32]byte       // hash of the previous entry, zero for the first
+	Data    *big.Int       // data that identifies the voter, nil if spoiled
+	Ballot  Ballot         // ballot of any registered kind, nil if spoiled
+	Spoiled *SpoiledBallot // spoiled ballot, nil if cast
+}
+
+// StoreHead - position and hash of the last entry of a store. Kept or
+// published outside the store, it allows detecting truncation.
+type StoreHead struct {
+	Size int
+	Hash [32]byte
+}
+
+// Hash returns the hash of the entry, i.e., sha256 of its json encoding
+func (e *StoreEntry) Hash() [32]byte {
+	data, _ := json.Marshal(e)
+	return sha256.Sum256(data)
+}
+
+// verifyChain checks that entries are chained from the first one and, if
+// head is given, that they extend head
+func verifyChain(entries []*StoreEntry, head *StoreHead) error {
+	var prev [32]byte
+	for i, e := range entries {
+		if e == nil || (e.Spoiled == nil) == (e.Ballot == nil || e.Data == nil) {
+			return errors.New("Invalid store entry")
+		}
+		if e.Seq != i+1 || e.Prev != prev {
+			return errors.New("Ballot store modified")
+		}
+		prev = e.Hash()
+
+		if head != nil && head.Size == i+1 && head.Hash != prev {
+			return errors.New("Ballot store modified")
+		}
+	}
+
+	if head != nil && len(entries) < head.Size {
+		return errors.New("Ballot store truncated")
+	}
+
+	return nil
+}
+
+// BuildJSONStoreEntry builds json object. The kind of the ballot is
+// omitted for binary ballots, so that entries of binary ballots keep their
+// hashes.
+func (e *StoreEntry) BuildJSONStoreEntry() (*JSONStoreEntry, error) {
+	if e.Spoiled != nil {
+		return &JSONStoreEntry{
+			Seq:     e.Seq,
+			Prev:    merkle.HashToHexStr(e.Prev),
+			Spoiled: e.Spoiled.BuildJSONSpoiledBallot(),
+		}, nil
+	}
+
+	data, err := json.Marshal(e.Ballot)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &JSONStoreEntry{
+		Seq:    e.Seq,
+		Prev:   merkle.HashToHexStr(e.Prev),
+		Data:   common.BigIntToHexStr(e.Data),
+		Ballot: data,
+	}
+	if kind := e.Ballot.Kind(); kind != BinaryKind {
+		obj.Type = string(kind)
+	}
+	return obj, nil
+}
+
+// MarshalJSON implements json marshal
+func (e *StoreEntry) MarshalJSON() ([]byte, error) {
+	obj, err := e.BuildJSONStoreEntry()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+// FromJSONStoreEntry reconstructs from json object
+func (e *StoreEntry) FromJSONStoreEntry(obj *JSONStoreEntry) error {
+	var err error
+
+	e.Seq = obj.Seq
+	if e.Prev, err = merkle.HexStrToHash(obj.Prev); err != nil {
+		return err
+	}
+	if obj.Spoiled != nil {
+		e.Spoiled = new(SpoiledBallot)
+		return e.Spoiled.FromJSONSpoiledBallot(obj.Spoiled)
+	}
+
+	if obj.Ballot == nil || string(obj.Ballot) == "null" {
+		return errors.New("Missing ballot")
+	}
+	if e.Data, err = common.HexStrToBigInt(obj.Data); err != nil {
+		return err
+	}
+	kind := BinaryKind
+	if obj.Type != "" {
+		kind = BallotKind(obj.Type)
+	}
+	if e.Ballot, err = newBallot(kind); err != nil {
+		return err
+	}
+	return json.Unmarshal(obj.Ballot, e.Ballot)
+}
+
+// UnmarshalJSON implements json unmarshal
+func (e *StoreEntry) UnmarshalJSON(data []byte) error {
+	var obj JSONStoreEntry
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return e.FromJSONStoreEntry(&obj)
+}
+
+// FileBallotStore - ballot store appending entries to a file, one json
+// object per line
+type FileBallotStore struct {
+	f *os.File
+}
+
+// OpenFileBallotStore opens the store in file, which is created if it
+// doesn't exist
+func OpenFileBallotStore(file string) (*FileBallotStore, error) {
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileBallotStore{f}, nil
+}
+
+// Append writes the entry and syncs the file
+func (s *FileBallotStore) Append(e *StoreEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+// Load reads all entries. An incomplete last line, e.g., left by a crash
+// while appending, is reported as an error.
+func (s *FileBallotStore) Load() ([]*StoreEntry, error) {
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var entries []*StoreEntry
+	r := bufio.NewReader(s.f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				return nil, errors.New("Ballot store truncated")
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		e := new(StoreEntry)
+		if err := json.Unmarshal(line, e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Close closes the file
+func (s *FileBallotStore) Close() error {
+	return s.f.Close()
+}
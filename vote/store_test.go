@@ -0,0 +1,128 @@
+package vote
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileBallotStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zkvote")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "ballots.log")
+
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	newVote := func() *BinaryVote {
+		v, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+		assert.Nil(t, err)
+		assert.Nil(t, v.SetRevotePolicy(Reject))
+		assert.Nil(t, v.Open())
+		return v
+	}
+
+	s, err := OpenFileBallotStore(file)
+	assert.Nil(t, err)
+	v := newVote()
+	assert.Nil(t, v.AttachStore(s, nil))
+
+	var voters []*big.Int
+	for i := 0; i < 4; i++ {
+		addr := new(big.Int).SetBytes(getRandAddr())
+		voters = append(voters, addr)
+		assert.Nil(t, v.Cast(genBinaryBallot(i%2 == 0, addr, k.PublicKey.X, k.PublicKey.Y, t), addr))
+	}
+	// rejected re-vote is logged as well
+	assert.Equal(t, ErrRevote, v.Cast(genBinaryBallot(true, voters[1], k.PublicKey.X, k.PublicKey.Y, t), voters[1]))
+
+	// and so are spoiled ballots
+	spoiler := new(big.Int).SetBytes(getRandAddr())
+	a, _ := randScalar()
+	spoiled, err := NewBinaryBallot(true, a, k.PublicKey.X, k.PublicKey.Y, spoiler)
+	assert.Nil(t, err)
+	sb, err := NewSpoiledBallot(spoiled, a, true)
+	assert.Nil(t, err)
+	assert.Nil(t, v.Spoil(sb))
+
+	head := v.Head()
+	assert.Equal(t, 6, head.Size)
+	assert.Nil(t, s.Close())
+
+	// reopen after a crash
+	s, err = OpenFileBallotStore(file)
+	assert.Nil(t, err)
+	v1 := newVote()
+	assert.Nil(t, v1.AttachStore(s, &head))
+	assert.Equal(t, v.HX, v1.HX)
+	assert.Equal(t, v.HY, v1.HY)
+	assert.Equal(t, v.YX, v1.YX)
+	assert.Equal(t, v.YY, v1.YY)
+	assert.Equal(t, v.History(), v1.History())
+	assert.Equal(t, head, v1.Head())
+	assert.Equal(t, ErrSpoiled, v1.Cast(spoiled, spoiler))
+	assert.NotNil(t, v1.AttachStore(s, nil))
+
+	addr := new(big.Int).SetBytes(getRandAddr())
+	assert.Nil(t, v1.Cast(genBinaryBallot(true, addr, k.PublicKey.X, k.PublicKey.Y, t), addr))
+	assert.Nil(t, s.Close())
+
+	assert.Nil(t, v1.Close())
+	assert.Nil(t, v1.Tally(k.D))
+	assert.Equal(t, 3, v1.GetTallyRes().V)
+
+	data, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+
+	reopen := func(data []byte, head *StoreHead) error {
+		assert.Nil(t, ioutil.WriteFile(file, data, 0600))
+		s, err := OpenFileBallotStore(file)
+		assert.Nil(t, err)
+		defer s.Close()
+		return newVote().AttachStore(s, head)
+	}
+
+	// the grown store extends the old head
+	assert.Nil(t, reopen(data, &head))
+
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	assert.Equal(t, 7, len(lines))
+
+	// truncated
+	assert.NotNil(t, reopen(joinLines(lines[:4]), &head))
+	assert.NotNil(t, reopen(data[:len(data)-10], nil))
+
+	// entry removed
+	assert.NotNil(t, reopen(joinLines(append(append([][]byte(nil), lines[:2]...), lines[3:]...)), nil))
+
+	// entry modified
+	modified := append([][]byte(nil), lines...)
+	modified[2] = lines[3]
+	assert.NotNil(t, reopen(joinLines(modified), nil))
+
+	// rewritten with a consistent chain but a different head
+	s, err = OpenFileBallotStore(filepath.Join(dir, "other.log"))
+	assert.Nil(t, err)
+	v2 := newVote()
+	assert.Nil(t, v2.AttachStore(s, nil))
+	for _, addr := range voters {
+		assert.Nil(t, v2.Cast(genBinaryBallot(true, addr, k.PublicKey.X, k.PublicKey.Y, t), addr))
+	}
+	assert.Nil(t, s.Close())
+	other, err := ioutil.ReadFile(filepath.Join(dir, "other.log"))
+	assert.Nil(t, err)
+	assert.Nil(t, reopen(other, nil))
+	assert.NotNil(t, reopen(other, &head))
+}
+
+func joinLines(lines [][]byte) []byte {
+	return append(bytes.Join(lines, []byte("\n")), '\n')
+}
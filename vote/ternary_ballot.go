@@ -0,0 +1,254 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// Choice - choice on a ternary ballot
+type Choice int
+
+// ternary choices
+const (
+	No Choice = iota
+	Yes
+	Abstain
+)
+
+func (c Choice) String() string {
+	switch c {
+	case No:
+		return "no"
+	case Yes:
+		return "yes"
+	case Abstain:
+		return "abstain"
+	}
+	return "unknown"
+}
+
+// TernaryBallot - yes/no/abstain ballot
+//
+// The choice is encoded by two encrypted bits, yes and abstain, each with a
+// binary proof. Their product encrypts yes + abstain, which is proved binary
+// as well so that at most one bit is set. No sets neither.
+type TernaryBallot struct {
+	yes     *BinaryBallot // encrypts 1 if yes
+	abstain *BinaryBallot // encrypts 1 if abstain
+	either  *BinaryBallot // product of yes and abstain, encrypts 1 unless no
+
+	eligibility *Eligibility // inclusion proof in the voter registry, optional
+}
+
+// NewTernaryBallot generates a ternary ballot
+//
+// data contains the data (e.g., account address) that identifies the voter.
+func NewTernaryBallot(c Choice, gkX, gkY *big.Int, data *big.Int) (*TernaryBallot, error) {
+	return newTernaryBallot(c, gkX, gkY, data, nil)
+}
+
+// NewTernaryBallotInElection generates a ternary ballot bound to the
+// election described by m
+func NewTernaryBallotInElection(m *Manifest, c Choice, data *big.Int) (*TernaryBallot, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash := m.Hash()
+	return newTernaryBallot(c, m.GKX, m.GKY, data, hash[:])
+}
+
+func newTernaryBallot(c Choice, gkX, gkY *big.Int, data *big.Int, ctx []byte) (*TernaryBallot, error) {
+	if c != No && c != Yes && c != Abstain {
+		return nil, errors.New("Invalid choice")
+	}
+
+	// a1, a2 and a1 + a2 all in [1, N-1]
+	var a1, a2, a *big.Int
+	for a == nil || !isInRange(a) {
+		var err error
+		if a1, err = randScalar(); err != nil {
+			return nil, err
+		}
+		if a2, err = randScalar(); err != nil {
+			return nil, err
+		}
+		a = new(big.Int).Add(a1, a2)
+		a = a.Mod(a, curve.Params().N)
+	}
+
+	yes, err := newBinaryBallot(c == Yes, a1, gkX, gkY, data, ctx)
+	if err != nil {
+		return nil, err
+	}
+	abstain, err := newBinaryBallot(c == Abstain, a2, gkX, gkY, data, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// (g^{a1+a2}, g^{(a1+a2)k} * g^{v1+v2}) is the product of both bits
+	either, err := newBinaryBallot(c != No, a, gkX, gkY, data, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TernaryBallot{yes: yes, abstain: abstain, either: either}, nil
+}
+
+// VerifyBallot verifies ternary ballot
+func (b *TernaryBallot) VerifyBallot() error {
+	if b.yes == nil || b.abstain == nil || b.either == nil {
+		return errors.New("Missing encrypted bit")
+	}
+
+	for i, bit := range []*BinaryBallot{b.yes, b.abstain, b.either} {
+		if err := bit.VerifyBallot(); err != nil {
+			return fmt.Errorf("Bit [%d]: %v", i, err)
+		}
+	}
+
+	// all bits are cast by the same voter in the same election
+	gkX, gkY := b.yes.proof.PublicKey()
+	data := b.yes.proof.Data()
+	for _, bit := range []*BinaryBallot{b.abstain, b.either} {
+		if err := bit.CheckKey(gkX, gkY); err != nil {
+			return err
+		}
+		if bit.proof.Data().Cmp(data) != 0 {
+			return errors.New("Bits cast by different voters")
+		}
+		if err := checkContext(b.yes.proof.Context(), bit.proof.Context()); err != nil {
+			return err
+		}
+	}
+
+	// either = yes * abstain
+	hX, hY := curve.Add(b.yes.hX, b.yes.hY, b.abstain.hX, b.abstain.hY)
+	yX, yY := curve.Add(b.yes.yX, b.yes.yY, b.abstain.yX, b.abstain.yY)
+	if hX.Cmp(b.either.hX) != 0 || hY.Cmp(b.either.hY) != 0 ||
+		yX.Cmp(b.either.yX) != 0 || yY.Cmp(b.either.yY) != 0 {
+		return errors.New("More than one bit set")
+	}
+
+	return nil
+}
+
+// CheckKey checks that the ballot is encrypted with the authority public
+// key g^k
+func (b *TernaryBallot) CheckKey(gkX, gkY *big.Int) error {
+	return b.yes.CheckKey(gkX, gkY)
+}
+
+// CheckElection checks that the ballot is bound to the election described
+// by m, or to none if m is nil
+func (b *TernaryBallot) CheckElection(m *Manifest) error {
+	return b.yes.CheckElection(m)
+}
+
+// SetEligibility attaches the inclusion proof of the voter in the registry
+func (b *TernaryBallot) SetEligibility(e *Eligibility) {
+	b.eligibility = e
+}
+
+// Eligibility returns the attached inclusion proof, nil if none
+func (b *TernaryBallot) Eligibility() *Eligibility {
+	return b.eligibility
+}
+
+// VerifyEligibility checks the attached inclusion proof of the voter
+// against the registry root
+func (b *TernaryBallot) VerifyEligibility(root [32]byte) error {
+	if b.eligibility == nil {
+		return errors.New("Missing eligibility proof")
+	}
+	return b.eligibility.Verify(root, b.yes.proof.Data())
+}
+
+// Kind returns TernaryKind
+func (b *TernaryBallot) Kind() BallotKind {
+	return TernaryKind
+}
+
+// Voter returns the data bound to the ballot that identifies the voter
+func (b *TernaryBallot) Voter() *big.Int {
+	return b.yes.Voter()
+}
+
+// PublicKey returns g^k the ballot is encrypted with
+func (b *TernaryBallot) PublicKey() (*big.Int, *big.Int) {
+	return b.yes.PublicKey()
+}
+
+// Ciphertexts returns the encrypted yes and abstain bits
+func (b *TernaryBallot) Ciphertexts() []*zk.Ciphertext {
+	return []*zk.Ciphertext{b.yes.ciphertext(), b.abstain.ciphertext()}
+}
+
+func (b *TernaryBallot) String() (string, string) {
+	s1, p1 := b.yes.String()
+	s2, p2 := b.abstain.String()
+	_, p3 := b.either.String()
+	return fmt.Sprintf("yes: %s; abstain: %s", s1, s2), fmt.Sprintf("yes: %s; abstain: %s; either: %s", p1, p2, p3)
+}
+
+// BuildJSONTernaryBallot builds json object
+func (b *TernaryBallot) BuildJSONTernaryBallot() *JSONTernaryBallot {
+	obj := &JSONTernaryBallot{
+		Yes:     b.yes.BuildJSONBinaryBallot(),
+		Abstain: b.abstain.BuildJSONBinaryBallot(),
+		Either:  b.either.BuildJSONBinaryBallot(),
+	}
+	if b.eligibility != nil {
+		obj.Eligibility = b.eligibility.BuildJSONEligibility()
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (b *TernaryBallot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.BuildJSONTernaryBallot())
+}
+
+// FromJSONTernaryBallot reconstructs from json object
+func (b *TernaryBallot) FromJSONTernaryBallot(obj *JSONTernaryBallot) error {
+	if obj.Yes == nil || obj.Abstain == nil || obj.Either == nil {
+		return errors.New("Missing encrypted bit")
+	}
+
+	b1 := new(TernaryBallot)
+	bits := []struct {
+		dst **BinaryBallot
+		src *JSONBinaryBallot
+	}{
+		{&b1.yes, obj.Yes}, {&b1.abstain, obj.Abstain}, {&b1.either, obj.Either},
+	}
+	for _, bit := range bits {
+		*bit.dst = new(BinaryBallot)
+		if err := (*bit.dst).FromJSONBinaryBallot(bit.src); err != nil {
+			return err
+		}
+	}
+	if obj.Eligibility != nil {
+		b1.eligibility = new(Eligibility)
+		if err := b1.eligibility.FromJSONEligibility(obj.Eligibility); err != nil {
+			return err
+		}
+	}
+
+	*b = *b1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (b *TernaryBallot) UnmarshalJSON(data []byte) error {
+	var obj JSONTernaryBallot
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return b.FromJSONTernaryBallot(&obj)
+}
@@ -0,0 +1,271 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// TernaryTally structure
+//
+// The yes and abstain bits of the ballots are aggregated and tallied
+// separately, each the same way as a binary vote. Abstentions count toward
+// the quorum but not toward the outcome.
+type TernaryTally struct {
+	yes, abstain *BinaryTally
+
+	params *ElectionParams
+}
+
+// NewTernaryTally creates a new tally of ternary ballots
+func NewTernaryTally(p *ElectionParams, ballots []*TernaryBallot) (*TernaryTally, error) {
+	if err := p.checkVoters(len(ballots)); err != nil {
+		return nil, err
+	}
+
+	registry := p.uniqueVoters()
+	voters := make(map[[32]byte]bool)
+
+	newBit := func() *BinaryTally {
+		return &BinaryTally{
+			gkX:      new(big.Int).Set(p.gkX),
+			gkY:      new(big.Int).Set(p.gkY),
+			authData: new(big.Int).Set(p.authData),
+			HX:       new(big.Int),
+			HY:       new(big.Int),
+			YX:       new(big.Int),
+			YY:       new(big.Int),
+			n:        len(ballots),
+			params:   p,
+		}
+	}
+	t := &TernaryTally{yes: newBit(), abstain: newBit(), params: p}
+
+	var (
+		total  uint64
+		leaves []ballotLeaf
+	)
+	for _, b := range ballots {
+		if err := b.VerifyBallot(); err != nil {
+			return nil, err
+		}
+		if err := b.CheckKey(p.gkX, p.gkY); err != nil {
+			return nil, err
+		}
+
+		w, err := p.checkVoter(b.yes.proof.Context(), b.yes.proof.Data(), b.eligibility)
+		if err != nil {
+			return nil, err
+		}
+		id := voterID(b.yes)
+		if registry {
+			if voters[id] {
+				return nil, errors.New("Duplicate voter")
+			}
+			voters[id] = true
+		}
+		l, err := newBallotLeaf(id, b)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, l)
+		total = total + w
+
+		for _, bit := range []struct {
+			t *BinaryTally
+			b *BinaryBallot
+		}{{t.yes, b.yes}, {t.abstain, b.abstain}} {
+			hX, hY, yX, yY := bit.b.weighted(w)
+			bit.t.HX, bit.t.HY = curve.Add(bit.t.HX, bit.t.HY, hX, hY)
+			bit.t.YX, bit.t.YY = curve.Add(bit.t.YX, bit.t.YY, yX, yY)
+		}
+	}
+
+	root := ballotsRoot(leaves)
+	for _, bit := range []*BinaryTally{t.yes, t.abstain} {
+		bit.weight = int(total)
+		bit.root = root
+		if registry {
+			bit.bound = int(total)
+		}
+	}
+
+	return t, nil
+}
+
+// Tally computes the yes, no and abstain counts and zk proofs
+func (t *TernaryTally) Tally(k *big.Int) (*TernaryTallyRes, error) {
+	yes, err := t.yes.decryptV(k)
+	if err != nil {
+		return nil, err
+	}
+	abstain, err := t.abstain.decryptV(k)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &TernaryTallyRes{
+		Yes:     yes.V,
+		Abstain: abstain.V,
+		No:      t.yes.weight - yes.V - abstain.V,
+		yes:     yes,
+		abstain: abstain,
+	}
+	if r.No < 0 {
+		return nil, errors.New("Tally failed")
+	}
+	r.Turnout, r.QuorumMet, r.Passed = t.evaluate(r)
+
+	return r, nil
+}
+
+// evaluate returns the turnout and whether the result meets the quorum and
+// passes, abstentions left out of the outcome
+func (t *TernaryTally) evaluate(r *TernaryTallyRes) (int, bool, bool) {
+	quorum, passed := t.params.evaluate(t.yes.n, r.Yes+r.No, r.Yes)
+	return t.yes.n, quorum, passed
+}
+
+// TernaryTallyRes structure - yes, no and abstain counts, weighted if a
+// registry with weights is used
+type TernaryTallyRes struct {
+	Yes, No, Abstain int
+
+	Turnout   int  // number of ballots counted
+	QuorumMet bool // whether the minimum turnout is reached
+	Passed    bool // whether the quorum is met and the yes votes pass
+
+	yes, abstain *BinaryTallyRes // decryptions of the yes and abstain counts
+}
+
+// Verify verifies the proofs of the yes and abstain counts
+func (r *TernaryTallyRes) Verify() error {
+	return r.verify()
+}
+
+func (r *TernaryTallyRes) verify() error {
+	if r.yes == nil || r.abstain == nil {
+		return errors.New("Missing zkp")
+	}
+	if err := r.yes.verify(); err != nil {
+		return fmt.Errorf("Yes: %v", err)
+	}
+	if err := r.abstain.verify(); err != nil {
+		return fmt.Errorf("Abstain: %v", err)
+	}
+	if err := r.abstain.checkContext(r.yes.context()); err != nil {
+		return err
+	}
+	if r.Yes != r.yes.V || r.Abstain != r.abstain.V || r.No < 0 {
+		return errors.New("Counts don't match zkp")
+	}
+	return nil
+}
+
+// VerifyBallots fully verifies the result against the ballots it is claimed
+// to be computed from, as BinaryTallyRes.VerifyBallots does
+func (r *TernaryTallyRes) VerifyBallots(p *ElectionParams, ballots []*TernaryBallot) error {
+	t, err := NewTernaryTally(p, ballots)
+	if err != nil {
+		return err
+	}
+
+	if err := r.verify(); err != nil {
+		return err
+	}
+	if err := r.yes.verifyDecryption(t.yes); err != nil {
+		return fmt.Errorf("Yes: %v", err)
+	}
+	if err := r.abstain.verifyDecryption(t.abstain); err != nil {
+		return fmt.Errorf("Abstain: %v", err)
+	}
+
+	if r.Yes+r.No+r.Abstain != t.yes.weight {
+		return errors.New("Counts don't match ballots")
+	}
+	turnout, quorum, passed := t.evaluate(r)
+	if r.Turnout != turnout || r.QuorumMet != quorum || r.Passed != passed {
+		return errors.New("Invalid quorum or pass result")
+	}
+
+	return nil
+}
+
+// CheckElection checks that the tally proofs are bound to the election
+// described by m, or to none if m is nil
+func (r *TernaryTallyRes) CheckElection(m *Manifest) error {
+	if err := r.yes.CheckElection(m); err != nil {
+		return err
+	}
+	return r.abstain.CheckElection(m)
+}
+
+// BallotsRoot returns the committed root of the bulletin board of the
+// ballots counted
+func (r *TernaryTallyRes) BallotsRoot() ([32]byte, error) {
+	return r.yes.BallotsRoot()
+}
+
+func (r *TernaryTallyRes) String() (string, string) {
+	_, p1 := r.yes.String()
+	_, p2 := r.abstain.String()
+	return fmt.Sprintf("No. YES = %d; No. NO = %d; No. ABSTAIN = %d", r.Yes, r.No, r.Abstain),
+		fmt.Sprintf("yes: %s; abstain: %s", p1, p2)
+}
+
+// BuildJSONTernaryTallyRes builds json object
+func (r *TernaryTallyRes) BuildJSONTernaryTallyRes() *JSONTernaryTallyRes {
+	return &JSONTernaryTallyRes{
+		Yes:        r.Yes,
+		No:         r.No,
+		Abstain:    r.Abstain,
+		Turnout:    r.Turnout,
+		QuorumMet:  r.QuorumMet,
+		Passed:     r.Passed,
+		YesRes:     r.yes.BuildJSONBinaryTallyRes(),
+		AbstainRes: r.abstain.BuildJSONBinaryTallyRes(),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (r *TernaryTallyRes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.BuildJSONTernaryTallyRes())
+}
+
+// FromJSONTernaryTallyRes reconstructs from json object
+func (r *TernaryTallyRes) FromJSONTernaryTallyRes(obj *JSONTernaryTallyRes) error {
+	if obj.YesRes == nil || obj.AbstainRes == nil {
+		return errors.New("Missing zkp")
+	}
+
+	r1 := &TernaryTallyRes{
+		Yes:       obj.Yes,
+		No:        obj.No,
+		Abstain:   obj.Abstain,
+		Turnout:   obj.Turnout,
+		QuorumMet: obj.QuorumMet,
+		Passed:    obj.Passed,
+		yes:       new(BinaryTallyRes),
+		abstain:   new(BinaryTallyRes),
+	}
+	if err := r1.yes.FromJSONBinaryTallyRes(obj.YesRes); err != nil {
+		return err
+	}
+	if err := r1.abstain.FromJSONBinaryTallyRes(obj.AbstainRes); err != nil {
+		return err
+	}
+
+	*r = *r1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (r *TernaryTallyRes) UnmarshalJSON(data []byte) error {
+	var obj JSONTernaryTallyRes
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return r.FromJSONTernaryTallyRes(&obj)
+}
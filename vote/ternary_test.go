@@ -0,0 +1,89 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTernaryVote(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, p.SetVoterLimits(5, 0))
+
+	_, err = NewTernaryBallot(Choice(3), k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.NotNil(t, err)
+
+	var ballots []*TernaryBallot
+	for _, c := range []Choice{Yes, No, Abstain, Yes, Abstain} {
+		b, err := NewTernaryBallot(c, k.PublicKey.X, k.PublicKey.Y, new(big.Int).SetBytes(getRandAddr()))
+		assert.Nil(t, err)
+		assert.Nil(t, b.VerifyBallot())
+
+		data, err := json.Marshal(b)
+		assert.Nil(t, err)
+		b1 := new(TernaryBallot)
+		assert.Nil(t, json.Unmarshal(data, b1))
+		assert.Nil(t, b1.VerifyBallot())
+		ballots = append(ballots, b1)
+	}
+
+	tal, err := NewTernaryTally(p, ballots)
+	assert.Nil(t, err)
+	res, err := tal.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res.Yes)
+	assert.Equal(t, 1, res.No)
+	assert.Equal(t, 2, res.Abstain)
+
+	// abstentions count toward the quorum but not the outcome
+	assert.Equal(t, 5, res.Turnout)
+	assert.True(t, res.QuorumMet)
+	assert.True(t, res.Passed)
+
+	data, err := json.Marshal(res)
+	assert.Nil(t, err)
+	res1 := new(TernaryTallyRes)
+	assert.Nil(t, json.Unmarshal(data, res1))
+	assert.Nil(t, res1.Verify())
+	assert.Nil(t, res1.VerifyBallots(p, ballots))
+	assert.NotNil(t, res1.VerifyBallots(p, ballots[1:]))
+
+	res1.No, res1.Abstain = 2, 1
+	assert.NotNil(t, res1.Verify())
+}
+
+func TestTernaryBallotBothBits(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	data := new(big.Int).SetBytes(getRandAddr())
+
+	// yes and abstain both set
+	a1, _ := randScalar()
+	a2, _ := randScalar()
+	a := new(big.Int).Add(a1, a2)
+	a = a.Mod(a, curve.Params().N)
+
+	yes, err := newBinaryBallot(true, a1, k.PublicKey.X, k.PublicKey.Y, data, nil)
+	assert.Nil(t, err)
+	abstain, err := newBinaryBallot(true, a2, k.PublicKey.X, k.PublicKey.Y, data, nil)
+	assert.Nil(t, err)
+	either, err := newBinaryBallot(true, a, k.PublicKey.X, k.PublicKey.Y, data, nil)
+	assert.Nil(t, err)
+
+	b := &TernaryBallot{yes: yes, abstain: abstain, either: either}
+	assert.NotNil(t, b.VerifyBallot())
+
+	// bits of different voters
+	b, err = NewTernaryBallot(Yes, k.PublicKey.X, k.PublicKey.Y, data)
+	assert.Nil(t, err)
+	b.abstain, err = newBinaryBallot(false, a2, k.PublicKey.X, k.PublicKey.Y, new(big.Int).SetBytes(getRandAddr()), nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, b.VerifyBallot())
+}
@@ -0,0 +1,432 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
+// ThresholdKey - public part of an authority key k shared among n trustees
+// such that any t of them can decrypt
+//
+// k = f(0) for a random polynomial f(x) = sum_j a_j*x^j of degree t-1, and
+// trustee i holds k_i = f(i). The Feldman commitments C_j = g^{a_j} allow
+// anyone to compute g^{k_i} = prod_j C_j^{i^j}. C_0 = g^k is the authority
+// public key.
+type ThresholdKey struct {
+	threshold int
+	cX, cY    []*big.Int // C_j = g^{a_j}
+}
+
+// KeyShare - share k_i = f(i) of the authority key held by trustee i
+type KeyShare struct {
+	index int
+	k     *big.Int
+	pub   *ThresholdKey
+}
+
+// PartialDecryption - partial decryption D_i = H^{k_i} published by trustee
+// i with a proof of log_g(g^{k_i}) = log_H(D_i)
+type PartialDecryption struct {
+	index  int
+	dX, dY *big.Int
+	proof  *zk.DLEQProof
+}
+
+// NewThresholdKey creates the public key from Feldman commitments
+func NewThresholdKey(threshold int, cX, cY []*big.Int) (*ThresholdKey, error) {
+	if threshold <= 0 || threshold != len(cX) || len(cX) != len(cY) {
+		return nil, errors.New("Invalid threshold")
+	}
+
+	for j := range cX {
+		if !isOnCurve(cX[j], cY[j]) {
+			return nil, errors.New("Invalid commitment")
+		}
+	}
+
+	p := &ThresholdKey{threshold, make([]*big.Int, threshold), make([]*big.Int, threshold)}
+	for j := range cX {
+		p.cX[j], p.cY[j] = new(big.Int).Set(cX[j]), new(big.Int).Set(cY[j])
+	}
+
+	return p, nil
+}
+
+// SplitKey splits k into n shares any t of which can decrypt
+func SplitKey(k *big.Int, t, n int) (*ThresholdKey, []*KeyShare, error) {
+	if !isInRange(k) {
+		return nil, nil, errors.New("Invalid k")
+	}
+
+	if t <= 0 || t > n {
+		return nil, nil, errors.New("Invalid threshold")
+	}
+
+	// f(x) = k + a_1*x + ... + a_{t-1}*x^{t-1}
+	coeffs := make([]*big.Int, t)
+	coeffs[0] = new(big.Int).Set(k)
+	for j := 1; j < t; j++ {
+		a, err := randScalar()
+		if err != nil {
+			return nil, nil, err
+		}
+		coeffs[j] = a
+	}
+
+	pub := &ThresholdKey{t, make([]*big.Int, t), make([]*big.Int, t)}
+	for j, a := range coeffs {
+		pub.cX[j], pub.cY[j] = curve.ScalarBaseMult(a.Bytes())
+	}
+
+	shares := make([]*KeyShare, n)
+	for i := 1; i <= n; i++ {
+		shares[i-1] = &KeyShare{i, evalPoly(coeffs, i), pub}
+	}
+
+	return pub, shares, nil
+}
+
+// evalPoly computes f(x) mod N
+func evalPoly(coeffs []*big.Int, x int) *big.Int {
+	N := curve.Params().N
+	X := big.NewInt(int64(x))
+
+	// Horner's rule
+	r := big.NewInt(0)
+	for j := len(coeffs) - 1; j >= 0; j-- {
+		r = r.Mul(r, X)
+		r = r.Add(r, coeffs[j])
+		r = r.Mod(r, N)
+	}
+
+	return r
+}
+
+// NewKeyShare creates the key share k_i of trustee i and checks it against
+// the public commitments
+func NewKeyShare(index int, k *big.Int, pub *ThresholdKey) (*KeyShare, error) {
+	if k == nil {
+		return nil, errors.New("Invalid key share")
+	}
+
+	s := &KeyShare{index, new(big.Int).Set(k), pub}
+	if err := s.Verify(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Threshold returns the number of shares needed to decrypt
+func (p *ThresholdKey) Threshold() int {
+	return p.threshold
+}
+
+// PublicKey returns g^k
+func (p *ThresholdKey) PublicKey() (*big.Int, *big.Int) {
+	return new(big.Int).Set(p.cX[0]), new(big.Int).Set(p.cY[0])
+}
+
+// Commitment returns C_j = g^{a_j}
+func (p *ThresholdKey) Commitment(j int) (*big.Int, *big.Int) {
+	return new(big.Int).Set(p.cX[j]), new(big.Int).Set(p.cY[j])
+}
+
+// ShareKey returns g^{k_i} = prod_j C_j^{i^j}
+func (p *ThresholdKey) ShareKey(i int) (*big.Int, *big.Int) {
+	N := curve.Params().N
+	I := big.NewInt(int64(i))
+
+	X, Y := new(big.Int), new(big.Int)
+	e := big.NewInt(1)
+	for j := range p.cX {
+		X1, Y1 := curve.ScalarMult(p.cX[j], p.cY[j], e.Bytes())
+		X, Y = curve.Add(X, Y, X1, Y1)
+		e = e.Mul(e, I)
+		e = e.Mod(e, N)
+	}
+
+	return X, Y
+}
+
+// lagrange computes the Lagrange coefficient of index i at x = 0 for the set
+// of indices, i.e., prod_{j != i} j/(j - i) mod N
+func lagrange(i int, indices []int) *big.Int {
+	N := curve.Params().N
+
+	num, den := big.NewInt(1), big.NewInt(1)
+	for _, j := range indices {
+		if j == i {
+			continue
+		}
+		num = num.Mul(num, big.NewInt(int64(j)))
+		num = num.Mod(num, N)
+		den = den.Mul(den, big.NewInt(int64(j-i)))
+		den = den.Mod(den, N)
+	}
+
+	return num.Mul(num, den.ModInverse(den, N)).Mod(num, N)
+}
+
+// Verify checks k_i against the public commitments
+func (s *KeyShare) Verify() error {
+	if s.index <= 0 || !isInRange(s.k) || s.pub == nil {
+		return errors.New("Invalid key share")
+	}
+
+	X, Y := curve.ScalarBaseMult(s.k.Bytes())
+	sX, sY := s.pub.ShareKey(s.index)
+	if X.Cmp(sX) != 0 || Y.Cmp(sY) != 0 {
+		return errors.New("Key share doesn't match commitments")
+	}
+
+	return nil
+}
+
+// Index returns the index of the trustee
+func (s *KeyShare) Index() int {
+	return s.index
+}
+
+// ThresholdKey returns the public commitments
+func (s *KeyShare) ThresholdKey() *ThresholdKey {
+	return s.pub
+}
+
+// PartialDecrypt computes D_i = H^{k_i} and proves its correctness
+//
+// data contains the data (e.g., account address) that identifies the trustee.
+func (s *KeyShare) PartialDecrypt(HX, HY *big.Int, data *big.Int) (*PartialDecryption, error) {
+	return s.PartialDecryptInElection(nil, HX, HY, data)
+}
+
+// PartialDecryptInElection computes D_i = H^{k_i} with the proof bound to
+// the election described by m, or to none if m is nil
+func (s *KeyShare) PartialDecryptInElection(m *Manifest, HX, HY *big.Int, data *big.Int) (*PartialDecryption, error) {
+	var ctx []byte
+	if m != nil {
+		hash := m.Hash()
+		ctx = hash[:]
+	}
+
+	if !isOnCurve(HX, HY) {
+		return nil, errors.New("Invalid H")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	prover, err := zk.NewDLEQProver(s.k, curve.Params().Gx, curve.Params().Gy, HX, HY)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := prover.ProveInContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, dX, dY := proof.Values()
+
+	return &PartialDecryption{s.index, dX, dY, proof}, nil
+}
+
+// Index returns the index of the trustee
+func (d *PartialDecryption) Index() int {
+	return d.index
+}
+
+// Verify verifies D_i = H^{k_i} against the public commitments
+func (d *PartialDecryption) Verify(pub *ThresholdKey, HX, HY *big.Int) error {
+	if d.index <= 0 || d.proof == nil {
+		return errors.New("Invalid partial decryption")
+	}
+
+	zk.SetEllipticCurve(curve)
+
+	sX, sY := pub.ShareKey(d.index)
+	g1X, g1Y, g2X, g2Y := d.proof.Bases()
+	y1X, y1Y, y2X, y2Y := d.proof.Values()
+	if g1X.Cmp(curve.Params().Gx) != 0 || g1Y.Cmp(curve.Params().Gy) != 0 ||
+		g2X.Cmp(HX) != 0 || g2Y.Cmp(HY) != 0 ||
+		y1X.Cmp(sX) != 0 || y1Y.Cmp(sY) != 0 ||
+		y2X.Cmp(d.dX) != 0 || y2Y.Cmp(d.dY) != 0 {
+		return errors.New("Inconsistent partial decryption")
+	}
+
+	res, err := d.proof.Verify()
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Failed to verify partial decryption")
+	}
+
+	return nil
+}
+
+// combinePartials verifies partial decryptions of H and combines t of them
+// into X = H^k = prod_i D_i^{lambda_i}
+func combinePartials(pub *ThresholdKey, HX, HY *big.Int, partials []*PartialDecryption) (*big.Int, *big.Int, []*PartialDecryption, error) {
+	var (
+		used    []*PartialDecryption
+		indices []int
+		seen    = make(map[int]bool)
+	)
+
+	for _, d := range partials {
+		if len(used) == pub.threshold {
+			break
+		}
+		if d == nil || seen[d.index] {
+			continue
+		}
+		if err := d.Verify(pub, HX, HY); err != nil {
+			continue
+		}
+		seen[d.index] = true
+		used = append(used, d)
+		indices = append(indices, d.index)
+	}
+
+	if len(used) < pub.threshold {
+		return nil, nil, nil, fmt.Errorf("Need %d valid partial decryptions, got %d", pub.threshold, len(used))
+	}
+
+	X, Y := new(big.Int), new(big.Int)
+	for _, d := range used {
+		X1, Y1 := curve.ScalarMult(d.dX, d.dY, lagrange(d.index, indices).Bytes())
+		X, Y = curve.Add(X, Y, X1, Y1)
+	}
+
+	return X, Y, used, nil
+}
+
+// BuildJSONThresholdKey builds json object
+func (p *ThresholdKey) BuildJSONThresholdKey() *JSONThresholdKey {
+	obj := &JSONThresholdKey{Threshold: p.threshold}
+	for j := range p.cX {
+		obj.Commitments = append(obj.Commitments, newJSONPoint(p.cX[j], p.cY[j]))
+	}
+	return obj
+}
+
+// MarshalJSON implements json marshal
+func (p *ThresholdKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONThresholdKey())
+}
+
+// FromJSONThresholdKey reconstructs from json object
+func (p *ThresholdKey) FromJSONThresholdKey(obj *JSONThresholdKey) error {
+	cX := make([]*big.Int, len(obj.Commitments))
+	cY := make([]*big.Int, len(obj.Commitments))
+	for j, c := range obj.Commitments {
+		var err error
+		if cX[j], cY[j], err = c.toPoint(); err != nil {
+			return err
+		}
+	}
+
+	pub, err := NewThresholdKey(obj.Threshold, cX, cY)
+	if err != nil {
+		return err
+	}
+	*p = *pub
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *ThresholdKey) UnmarshalJSON(data []byte) error {
+	var obj JSONThresholdKey
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONThresholdKey(&obj)
+}
+
+// BuildJSONKeyShare builds json object
+func (s *KeyShare) BuildJSONKeyShare() *JSONKeyShare {
+	return &JSONKeyShare{
+		Index:  s.index,
+		K:      common.BigIntToHexStr(s.k),
+		PubKey: s.pub.BuildJSONThresholdKey(),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (s *KeyShare) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.BuildJSONKeyShare())
+}
+
+// FromJSONKeyShare reconstructs from json object
+func (s *KeyShare) FromJSONKeyShare(obj *JSONKeyShare) error {
+	var err error
+
+	s.index = obj.Index
+	if s.k, err = common.HexStrToBigInt(obj.K); err != nil {
+		return err
+	}
+
+	if obj.PubKey == nil {
+		return errors.New("Missing threshold key")
+	}
+	s.pub = new(ThresholdKey)
+	return s.pub.FromJSONThresholdKey(obj.PubKey)
+}
+
+// UnmarshalJSON implements json unmarshal
+func (s *KeyShare) UnmarshalJSON(data []byte) error {
+	var obj JSONKeyShare
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return s.FromJSONKeyShare(&obj)
+}
+
+// BuildJSONPartialDecryption builds json object
+func (d *PartialDecryption) BuildJSONPartialDecryption() *JSONPartialDecryption {
+	return &JSONPartialDecryption{
+		Index: d.index,
+		DX:    common.BigIntToHexStr(d.dX),
+		DY:    common.BigIntToHexStr(d.dY),
+		Proof: d.proof.BuildJSONDLEQProof(),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (d *PartialDecryption) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.BuildJSONPartialDecryption())
+}
+
+// FromJSONPartialDecryption reconstructs from json object
+func (d *PartialDecryption) FromJSONPartialDecryption(obj *JSONPartialDecryption) error {
+	var err error
+
+	if obj.Proof == nil {
+		return errors.New("Missing zkp")
+	}
+
+	d.index = obj.Index
+	if d.dX, err = common.HexStrToBigInt(obj.DX); err != nil {
+		return err
+	}
+	if d.dY, err = common.HexStrToBigInt(obj.DY); err != nil {
+		return err
+	}
+
+	d.proof = new(zk.DLEQProof)
+	return d.proof.FromJSONDLEQProof(obj.Proof)
+}
+
+// UnmarshalJSON implements json unmarshal
+func (d *PartialDecryption) UnmarshalJSON(data []byte) error {
+	var obj JSONPartialDecryption
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return d.FromJSONPartialDecryption(&obj)
+}
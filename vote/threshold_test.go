@@ -0,0 +1,132 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitKey(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+
+	pub, shares, err := SplitKey(k.D, 3, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(shares))
+
+	gkX, gkY := pub.PublicKey()
+	assert.Equal(t, k.PublicKey.X, gkX)
+	assert.Equal(t, k.PublicKey.Y, gkY)
+
+	for _, s := range shares {
+		assert.Nil(t, s.Verify())
+
+		data, err := json.Marshal(s)
+		assert.Nil(t, err)
+		var s1 KeyShare
+		assert.Nil(t, json.Unmarshal(data, &s1))
+		assert.Nil(t, s1.Verify())
+		assert.Equal(t, s.k, s1.k)
+	}
+
+	// any three shares recover k
+	N := curve.Params().N
+	for _, set := range [][]int{{1, 2, 3}, {2, 4, 5}, {5, 1, 3}} {
+		r := big.NewInt(0)
+		for _, i := range set {
+			l := lagrange(i, set)
+			r = r.Add(r, l.Mul(l, shares[i-1].k))
+		}
+		assert.Equal(t, k.D, r.Mod(r, N))
+	}
+
+	// tampered share
+	shares[0].k = new(big.Int).Add(shares[0].k, big.NewInt(1))
+	assert.NotNil(t, shares[0].Verify())
+
+	_, _, err = SplitKey(k.D, 6, 5)
+	assert.NotNil(t, err)
+}
+
+func TestThresholdTally(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	authAddr := new(big.Int).SetBytes(getRandAddr())
+
+	pub, shares, err := SplitKey(k.D, 2, 3)
+	assert.Nil(t, err)
+
+	binaryVote, err := NewBinaryVote(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, binaryVote.Open())
+	V := castRandBallots(binaryVote, 10, t)
+
+	var ballots []*BinaryBallot
+	for _, b := range binaryVote.ballots {
+		ballots = append(ballots, b)
+	}
+	tally, err := NewBinaryTally(k.PublicKey.X, k.PublicKey.Y, authAddr, ballots)
+	assert.Nil(t, err)
+
+	var partials []*PartialDecryption
+	for _, s := range shares {
+		d, err := s.PartialDecrypt(tally.HX, tally.HY, new(big.Int).SetBytes(getRandAddr()))
+		assert.Nil(t, err)
+		assert.Nil(t, d.Verify(pub, tally.HX, tally.HY))
+		partials = append(partials, d)
+	}
+
+	// a single partial decryption is not enough
+	_, err = tally.Combine(pub, partials[:1])
+	assert.NotNil(t, err)
+
+	// invalid partial decryptions are skipped
+	bad, err := shares[0].PartialDecrypt(ballots[0].hX, ballots[0].hY, authAddr)
+	assert.Nil(t, err)
+	assert.NotNil(t, bad.Verify(pub, tally.HX, tally.HY))
+
+	res, err := tally.Combine(pub, []*PartialDecryption{bad, partials[2], partials[1]})
+	assert.Nil(t, err)
+	assert.Equal(t, V, res.V)
+	p, err := NewElectionParams(k.PublicKey.X, k.PublicKey.Y, authAddr)
+	assert.Nil(t, err)
+	assert.Nil(t, res.VerifyBallots(p, ballots, 0))
+
+	// counts and commitments aren't bound to the partial decryptions
+	assert.NotNil(t, res.Verify())
+	forged := *res
+	forged.Turnout, forged.No = res.Turnout+1, res.No+1
+	assert.NotNil(t, forged.VerifyBallots(p, ballots, 0))
+
+	// same X as the one computed with k
+	res1, err := tally.Tally(k.D)
+	assert.Nil(t, err)
+	assert.Equal(t, res1.XX, res.XX)
+	assert.Equal(t, res1.XY, res.XY)
+
+	data, err := json.Marshal(res)
+	assert.Nil(t, err)
+	var res2 BinaryTallyRes
+	assert.Nil(t, json.Unmarshal(data, &res2))
+	assert.Equal(t, V, res2.V)
+	assert.Nil(t, res2.VerifyBallots(p, ballots, 0))
+	assert.NotNil(t, res2.VerifyBallots(p, ballots[1:], 0))
+
+	// missing partial decryptions
+	var obj JSONBinaryTallyRes
+	assert.Nil(t, json.Unmarshal(data, &obj))
+	obj.Partials = []*JSONPartialDecryption{nil}
+	assert.NotNil(t, new(BinaryTallyRes).FromJSONBinaryTallyRes(&obj))
+	obj.Partials = []*JSONPartialDecryption{{Index: 1, DX: "0x01", DY: "0x01"}}
+	assert.NotNil(t, new(BinaryTallyRes).FromJSONBinaryTallyRes(&obj))
+	obj.Partials = nil
+	assert.NotNil(t, new(BinaryTallyRes).FromJSONBinaryTallyRes(&obj))
+
+	// wrong threshold key
+	pub1, _, err := SplitKey(k.D, 2, 3)
+	assert.Nil(t, err)
+	res2.pub = pub1
+	assert.NotNil(t, res2.VerifyBallots(p, ballots, 0))
+}
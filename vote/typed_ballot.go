@@ -0,0 +1,113 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ballotKinds maps each registered kind to a constructor of an empty ballot
+// of that kind, which decodes itself from json
+var ballotKinds = struct {
+	sync.RWMutex
+	m map[BallotKind]func() Ballot
+}{
+	m: map[BallotKind]func() Ballot{
+		BinaryKind:     func() Ballot { return new(BinaryBallot) },
+		TernaryKind:    func() Ballot { return new(TernaryBallot) },
+		CumulativeKind: func() Ballot { return new(CumulativeBallot) },
+		QuadraticKind:  func() Ballot { return new(QuadraticBallot) },
+		SheetKind:      func() Ballot { return new(BallotSheet) },
+	},
+}
+
+// RegisterBallotKind registers a ballot kind so that DecodeBallot can
+// decode it. newBallot returns an empty ballot of the kind, which must
+// implement json.Unmarshaler.
+func RegisterBallotKind(kind BallotKind, newBallot func() Ballot) error {
+	if kind == "" || newBallot == nil {
+		return errors.New("Invalid ballot kind")
+	}
+	if _, ok := newBallot().(json.Unmarshaler); !ok {
+		return errors.New("Ballot can't be decoded from json")
+	}
+
+	ballotKinds.Lock()
+	defer ballotKinds.Unlock()
+	if _, ok := ballotKinds.m[kind]; ok {
+		return fmt.Errorf("Ballot kind [%s] already registered", kind)
+	}
+	ballotKinds.m[kind] = newBallot
+	return nil
+}
+
+// newBallot returns an empty ballot of kind
+func newBallot(kind BallotKind) (Ballot, error) {
+	ballotKinds.RLock()
+	defer ballotKinds.RUnlock()
+	f, ok := ballotKinds.m[kind]
+	if !ok {
+		return nil, fmt.Errorf("Unknown ballot kind [%s]", kind)
+	}
+	return f(), nil
+}
+
+// TypedBallot - ballot of any registered kind, encoded in json together
+// with its kind so that it can be decoded without knowing the kind in
+// advance
+type TypedBallot struct {
+	Ballot
+}
+
+// EncodeBallot encodes b in json tagged with its kind
+func EncodeBallot(b Ballot) ([]byte, error) {
+	return json.Marshal(&TypedBallot{b})
+}
+
+// DecodeBallot decodes a ballot encoded by EncodeBallot
+func DecodeBallot(data []byte) (Ballot, error) {
+	var t TypedBallot
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return t.Ballot, nil
+}
+
+// MarshalJSON implements json marshal
+func (t *TypedBallot) MarshalJSON() ([]byte, error) {
+	if t.Ballot == nil {
+		return nil, errors.New("Missing ballot")
+	}
+	data, err := json.Marshal(t.Ballot)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&JSONTypedBallot{Type: string(t.Kind()), Ballot: data})
+}
+
+// UnmarshalJSON implements json unmarshal
+func (t *TypedBallot) UnmarshalJSON(data []byte) error {
+	var obj JSONTypedBallot
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	if obj.Ballot == nil || string(obj.Ballot) == "null" {
+		return errors.New("Missing ballot")
+	}
+
+	b, err := newBallot(BallotKind(obj.Type))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(obj.Ballot, b); err != nil {
+		return err
+	}
+	if b.Kind() != BallotKind(obj.Type) {
+		return errors.New("Ballot kind doesn't match")
+	}
+
+	t.Ballot = b
+
+	return nil
+}
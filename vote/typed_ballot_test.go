@@ -0,0 +1,109 @@
+package vote
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedBallot(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	gkX, gkY := k.PublicKey.X, k.PublicKey.Y
+	addr := new(big.Int).SetBytes(getRandAddr())
+
+	m, err := NewManifest("e1", "Q", nil, gkX, gkY)
+	assert.Nil(t, err)
+	m.Questions = []string{"Q1", "Q2"}
+
+	binary := genBinaryBallot(true, addr, gkX, gkY, t)
+	ternary, err := NewTernaryBallot(Abstain, gkX, gkY, addr)
+	assert.Nil(t, err)
+	cumulative, err := NewCumulativeBallot([]uint64{1, 2}, 3, true, gkX, gkY, addr)
+	assert.Nil(t, err)
+	quadratic, err := NewQuadraticBallot([]int64{-1, 2}, 5, gkX, gkY, addr)
+	assert.Nil(t, err)
+	sheet, err := NewBallotSheet(m, []bool{true, false}, addr)
+	assert.Nil(t, err)
+
+	ballots := []*TypedBallot{{binary}, {ternary}, {cumulative}, {quadratic}, {sheet}}
+	kinds := []BallotKind{BinaryKind, TernaryKind, CumulativeKind, QuadraticKind, SheetKind}
+	sizes := []int{1, 2, 2, 2, 2}
+
+	data, err := json.Marshal(ballots)
+	assert.Nil(t, err)
+	var ballots1 []*TypedBallot
+	assert.Nil(t, json.Unmarshal(data, &ballots1))
+	assert.Equal(t, len(ballots), len(ballots1))
+	for i, b := range ballots1 {
+		assert.Equal(t, ballots[i].Ballot, b.Ballot)
+		assert.Equal(t, kinds[i], b.Kind())
+		assert.Nil(t, b.VerifyBallot())
+		assert.Equal(t, 0, addr.Cmp(b.Voter()))
+		X, Y := b.PublicKey()
+		assert.True(t, X.Cmp(gkX) == 0 && Y.Cmp(gkY) == 0)
+		assert.Equal(t, sizes[i], len(b.Ciphertexts()))
+	}
+
+	// the ciphertexts decrypt to the values, y / h^k = g^v
+	decrypt := func(b Ballot) []int64 {
+		var vs []int64
+		for _, e := range b.Ciphertexts() {
+			X, Y := curve.ScalarMult(e.HX, e.HY, k.D.Bytes())
+			X, Y = ecinv(X, Y)
+			X, Y = curve.Add(e.YX, e.YY, X, Y)
+			v := int64(-3)
+			for ; v <= 3; v++ {
+				gX, gY := curve.ScalarBaseMult(new(big.Int).Abs(big.NewInt(v)).Bytes())
+				if v < 0 {
+					gX, gY = ecinv(gX, gY)
+				}
+				if v == 0 && X.Sign() == 0 && Y.Sign() == 0 || v != 0 && X.Cmp(gX) == 0 && Y.Cmp(gY) == 0 {
+					break
+				}
+			}
+			vs = append(vs, v)
+		}
+		return vs
+	}
+	assert.Equal(t, []int64{1}, decrypt(binary))
+	assert.Equal(t, []int64{0, 1}, decrypt(ternary))
+	assert.Equal(t, []int64{1, 2}, decrypt(cumulative))
+	assert.Equal(t, []int64{-1, 2}, decrypt(quadratic))
+	assert.Equal(t, []int64{1, 0}, decrypt(sheet))
+
+	data, err = EncodeBallot(ternary)
+	assert.Nil(t, err)
+	b, err := DecodeBallot(data)
+	assert.Nil(t, err)
+	assert.Equal(t, ternary, b)
+
+	// unknown or mismatched kind
+	_, err = DecodeBallot([]byte(strings.Replace(string(data), `"ternary"`, `"unknown"`, 1)))
+	assert.NotNil(t, err)
+	_, err = DecodeBallot([]byte(strings.Replace(string(data), `"ternary"`, `"sheet"`, 1)))
+	assert.NotNil(t, err)
+	_, err = DecodeBallot([]byte(`{"type":"binary","ballot":null}`))
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, RegisterBallotKind(BinaryKind, func() Ballot { return new(BinaryBallot) }))
+	assert.Nil(t, RegisterBallotKind("binary-copy", func() Ballot { return new(BinaryBallot) }))
+
+	// store entries of binary ballots aren't tagged
+	e := &StoreEntry{Seq: 1, Data: addr, Ballot: binary}
+	data, err = json.Marshal(e)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), `"type"`))
+
+	e = &StoreEntry{Seq: 1, Data: addr, Ballot: sheet}
+	data, err = json.Marshal(e)
+	assert.Nil(t, err)
+	e1 := new(StoreEntry)
+	assert.Nil(t, json.Unmarshal(data, e1))
+	assert.Equal(t, e.Hash(), e1.Hash())
+	assert.Equal(t, SheetKind, e1.Ballot.Kind())
+}
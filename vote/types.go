@@ -1,5 +1,14 @@
 package vote
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/zzGHzz/zkVote/merkle"
+	"github.com/zzGHzz/zkVote/ring"
+	"github.com/zzGHzz/zkVote/zk"
+)
+
 // JSONBinaryBallot ...
 type JSONBinaryBallot struct {
 	HX    string                     `json:"hx"`
@@ -7,6 +16,9 @@ type JSONBinaryBallot struct {
 	YX    string                     `json:"yx"`
 	YY    string                     `json:"yy"`
 	Proof *JSONCompressedBinaryProof `json:"proof"`
+
+	Eligibility *JSONEligibility    `json:"eligibility,omitempty"`
+	Signature   *ring.JSONSignature `json:"ring,omitempty"`
 }
 
 // JSONCompressedBinaryProof ...
@@ -26,6 +38,7 @@ type JSONCompressedBinaryProof struct {
 	A2Y  string `json:"a2y"`
 	B2X  string `json:"b2x"`
 	B2Y  string `json:"b2y"`
+	Ctx  string `json:"election,omitempty"`
 }
 
 // JSONCompressedECFSProof ...
@@ -36,14 +49,295 @@ type JSONCompressedECFSProof struct {
 	TX   string `json:"tx"`
 	TY   string `json:"ty"`
 	R    string `json:"r"`
+	Ctx  string `json:"election,omitempty"`
 }
 
 // JSONBinaryTallyRes defines json object
 type JSONBinaryTallyRes struct {
 	V     int                      `json:"v"`
+	No    int                      `json:"no,omitempty"`
+	HX    string                   `json:"hx,omitempty"`
+	HY    string                   `json:"hy,omitempty"`
 	XX    string                   `json:"xx"`
 	XY    string                   `json:"xy"`
 	YX    string                   `json:"yx"`
 	YY    string                   `json:"yy"`
-	Proof *JSONCompressedECFSProof `json:"proof"`
+	Proof *JSONCompressedECFSProof `json:"proof,omitempty"`
+	Link  *zk.JSONDLEQProof        `json:"link,omitempty"`
+
+	Turnout   int  `json:"turnout,omitempty"`
+	QuorumMet bool `json:"quorum"`
+	Passed    bool `json:"passed"`
+
+	// commitment to the ballots counted, empty in legacy results
+	Ballots string `json:"ballots,omitempty"`
+	// commitment to the ballots excluded, empty unless invalid ballots are
+	// excluded instead of failing the tally
+	Excluded string `json:"excluded,omitempty"`
+
+	// threshold decryption
+	PubKey   *JSONThresholdKey        `json:"tkey,omitempty"`
+	Partials []*JSONPartialDecryption `json:"partials,omitempty"`
+}
+
+// JSONExcludedBallot defines json object
+type JSONExcludedBallot struct {
+	Index  int    `json:"index"`
+	Hash   string `json:"hash"`
+	Reason string `json:"reason"`
+}
+
+// JSONCumulativeBallot defines json object
+type JSONCumulativeBallot struct {
+	Budget uint64               `json:"budget"`
+	Allocs []*zk.JSONRangeProof `json:"allocs"`
+	Slack  *zk.JSONRangeProof   `json:"slack,omitempty"`
+	Sum    *zk.JSONDLEQProof    `json:"sum"`
+}
+
+// JSONOptionTallyRes defines json object
+type JSONOptionTallyRes struct {
+	Options []*JSONBinaryTallyRes `json:"options"`
+}
+
+// JSONQuadraticBallot defines json object
+type JSONQuadraticBallot struct {
+	Credits uint64                 `json:"credits"`
+	Votes   []*zk.JSONRangeProof   `json:"votes"`
+	Squares []*zk.JSONProductProof `json:"squares"`
+	Slack   *zk.JSONRangeProof     `json:"slack"`
+	Sum     *zk.JSONDLEQProof      `json:"sum"`
+}
+
+// JSONPoint defines json object of an EC point
+type JSONPoint struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+// JSONThresholdKey defines json object
+type JSONThresholdKey struct {
+	Threshold   int          `json:"threshold"`
+	Commitments []*JSONPoint `json:"commitments"`
+}
+
+// JSONKeyShare defines json object
+type JSONKeyShare struct {
+	Index  int               `json:"index"`
+	K      string            `json:"k"`
+	PubKey *JSONThresholdKey `json:"tkey"`
+}
+
+// JSONPartialDecryption defines json object
+type JSONPartialDecryption struct {
+	Index int               `json:"index"`
+	DX    string            `json:"dx"`
+	DY    string            `json:"dy"`
+	Proof *zk.JSONDLEQProof `json:"proof"`
+}
+
+// JSONOpenVoteKey defines json object
+type JSONOpenVoteKey struct {
+	GXX  string `json:"gxx"`
+	GXY  string `json:"gxy"`
+	Data string `json:"data"`
+	TX   string `json:"tx"`
+	TY   string `json:"ty"`
+	R    string `json:"r"`
+	Ctx  string `json:"ctx,omitempty"`
+}
+
+// JSONReplacement defines json object
+type JSONReplacement struct {
+	Seq      int    `json:"seq"`
+	Data     string `json:"data"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+	Policy   string `json:"policy"`
+	Replaced bool   `json:"replaced"`
+}
+
+// JSONEligibility defines json object
+type JSONEligibility struct {
+	Weight uint64             `json:"weight"`
+	Proof  []*merkle.JSONNode `json:"proof"`
+}
+
+// JSONRegistryEntry defines json object
+type JSONRegistryEntry struct {
+	Address string `json:"address"`
+	JSONEligibility
+}
+
+// JSONRegistry defines json object
+type JSONRegistry struct {
+	Root   string               `json:"root"`
+	Voters []*JSONRegistryEntry `json:"voters"`
+}
+
+// JSONElectionParams defines json object
+type JSONElectionParams struct {
+	GKX      string         `json:"gkx"`
+	GKY      string         `json:"gky"`
+	AuthData string         `json:"auth"`
+	Registry string         `json:"registry,omitempty"`
+	Ring     *ring.JSONRing `json:"ring,omitempty"`
+	Election string         `json:"election,omitempty"`
+
+	MinVoters int `json:"minvoters,omitempty"`
+	MaxVoters int `json:"maxvoters,omitempty"`
+	PassYes   int `json:"passyes,omitempty"`
+	PassNum   int `json:"passnum,omitempty"`
+	PassDen   int `json:"passden,omitempty"`
+}
+
+// JSONBinaryVote defines json object
+type JSONBinaryVote struct {
+	Params  *JSONElectionParams `json:"params"`
+	Phase   string              `json:"phase"`
+	Start   *time.Time          `json:"start,omitempty"`
+	End     *time.Time          `json:"end,omitempty"`
+	Policy  string              `json:"policy"`
+	Seq     int                 `json:"seq"`
+	Ballots []*JSONCastBallot   `json:"ballots"`
+	History []*JSONReplacement  `json:"history,omitempty"`
+	Spoiled []string            `json:"spoiled,omitempty"`
+	Log     []*JSONCastEntry    `json:"log,omitempty"`
+	Res     *JSONBinaryTallyRes `json:"res,omitempty"`
+}
+
+// JSONCastBallot defines json object
+type JSONCastBallot struct {
+	Data   string            `json:"data"`
+	Ballot *JSONBinaryBallot `json:"ballot"`
+}
+
+// JSONCastEntry defines json object
+type JSONCastEntry struct {
+	Voter  string `json:"voter"`
+	Ballot string `json:"ballot"`
+}
+
+// JSONManifest defines json object
+type JSONManifest struct {
+	ID        string   `json:"id"`
+	Question  string   `json:"question"`
+	Questions []string `json:"questions,omitempty"`
+	Options   []string `json:"options"`
+	GKX       string   `json:"gkx"`
+	GKY       string   `json:"gky"`
+	Curve     string   `json:"curve"`
+	Registry  string   `json:"registry,omitempty"`
+	Start     int64    `json:"start,omitempty"`
+	End       int64    `json:"end,omitempty"`
+}
+
+// JSONStoreEntry defines json object
+type JSONStoreEntry struct {
+	Seq     int                `json:"seq"`
+	Prev    string             `json:"prev"`
+	Data    string             `json:"data,omitempty"`
+	Type    string             `json:"type,omitempty"`
+	Ballot  json.RawMessage    `json:"ballot,omitempty"`
+	Spoiled *JSONSpoiledBallot `json:"spoiled,omitempty"`
+}
+
+// JSONAggregate defines json object
+type JSONAggregate struct {
+	GKX      string   `json:"gkx"`
+	GKY      string   `json:"gky"`
+	Election string   `json:"election,omitempty"`
+	Registry string   `json:"registry,omitempty"`
+	N        int      `json:"n"`
+	Weight   uint64   `json:"weight"`
+	Repeats  bool     `json:"repeats,omitempty"`
+	HX       string   `json:"hx"`
+	HY       string   `json:"hy"`
+	YX       string   `json:"yx"`
+	YY       string   `json:"yy"`
+	Root     string   `json:"root"`
+	Voters   []string `json:"voters"`
+	Ballots  []string `json:"ballots"`
+}
+
+// JSONTernaryBallot defines json object
+type JSONTernaryBallot struct {
+	Yes     *JSONBinaryBallot `json:"yes"`
+	Abstain *JSONBinaryBallot `json:"abstain"`
+	Either  *JSONBinaryBallot `json:"either"`
+
+	Eligibility *JSONEligibility `json:"eligibility,omitempty"`
+}
+
+// JSONTernaryTallyRes defines json object
+type JSONTernaryTallyRes struct {
+	Yes     int `json:"yes"`
+	No      int `json:"no"`
+	Abstain int `json:"abstain"`
+
+	Turnout   int  `json:"turnout"`
+	QuorumMet bool `json:"quorum"`
+	Passed    bool `json:"passed"`
+
+	YesRes     *JSONBinaryTallyRes `json:"yesres"`
+	AbstainRes *JSONBinaryTallyRes `json:"abstainres"`
+}
+
+// JSONReceipt defines json object
+type JSONReceipt struct {
+	Voter  string             `json:"voter"`
+	Ballot string             `json:"ballot"`
+	Index  int                `json:"index"`
+	Size   int                `json:"size"`
+	Root   string             `json:"root"`
+	Proof  []*merkle.JSONNode `json:"proof"`
+	R      string             `json:"r"`
+	S      string             `json:"s"`
+}
+
+// JSONSpoiledBallot defines json object
+type JSONSpoiledBallot struct {
+	Ballot *JSONBinaryBallot `json:"ballot"`
+	A      string            `json:"a"`
+	V      uint              `json:"v"`
+}
+
+// JSONCiphertext defines json object
+type JSONCiphertext struct {
+	H *JSONPoint `json:"h"`
+	Y *JSONPoint `json:"y"`
+}
+
+// JSONMix defines json object
+type JSONMix struct {
+	GKX      string               `json:"gkx"`
+	GKY      string               `json:"gky"`
+	Election string               `json:"election,omitempty"`
+	Input    []*JSONCiphertext    `json:"input"`
+	Output   []*JSONCiphertext    `json:"output"`
+	Proof    *zk.JSONShuffleProof `json:"proof"`
+}
+
+// JSONMixDecryption defines json object
+type JSONMixDecryption struct {
+	Values []int               `json:"values"`
+	Proofs []*zk.JSONDLEQProof `json:"proofs"`
+}
+
+// JSONBallotSheet defines json object
+type JSONBallotSheet struct {
+	Ballots []*JSONBinaryBallot `json:"ballots"`
+
+	Eligibility *JSONEligibility `json:"eligibility,omitempty"`
+}
+
+// JSONSheetTallyRes defines json object
+type JSONSheetTallyRes struct {
+	Questions []*JSONBinaryTallyRes `json:"questions"`
+}
+
+// JSONTypedBallot defines json object
+type JSONTypedBallot struct {
+	Type   string          `json:"type"`
+	Ballot json.RawMessage `json:"ballot"`
 }
@@ -1,13 +1,23 @@
 package vote
 
 import (
+	"crypto/rand"
+	"errors"
 	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
 )
 
 func isOnCurve(X, Y *big.Int) bool {
 	return curve.IsOnCurve(X, Y)
 }
 
+// isSumPoint checks that (X, Y) is on the curve or the point at infinity
+// (0, 0), e.g., the sum of no ciphertexts
+func isSumPoint(X, Y *big.Int) bool {
+	return (X.Sign() == 0 && Y.Sign() == 0) || isOnCurve(X, Y)
+}
+
 func isInRange(x *big.Int) bool {
 	return x.Cmp(big.NewInt(0)) > 0 && x.Cmp(curve.Params().N) < 0
 }
@@ -16,6 +26,40 @@ func ecinv(X, Y *big.Int) (*big.Int, *big.Int) {
 	X1 := new(big.Int).Mod(X, curve.Params().P)
 	Y1 := new(big.Int).Mod(Y, curve.Params().P)
 	Y1 = Y1.Sub(curve.Params().P, Y1)
+	Y1 = Y1.Mod(Y1, curve.Params().P) // keeps (0, 0) as the point at infinity
 
 	return X1, Y1
 }
+
+// randScalar returns a random number in [1, N-1]
+func randScalar() (*big.Int, error) {
+	r, err := rand.Int(rand.Reader, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return r.Add(r, big.NewInt(1)), nil
+}
+
+func newJSONPoint(X, Y *big.Int) *JSONPoint {
+	return &JSONPoint{
+		X: common.BigIntToHexStr(X),
+		Y: common.BigIntToHexStr(Y),
+	}
+}
+
+func (p *JSONPoint) toPoint() (*big.Int, *big.Int, error) {
+	if p == nil {
+		return nil, nil, errors.New("Missing point")
+	}
+
+	X, err := common.HexStrToBigInt(p.X)
+	if err != nil {
+		return nil, nil, err
+	}
+	Y, err := common.HexStrToBigInt(p.Y)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return X, Y, nil
+}
@@ -3,28 +3,86 @@ package vote
 import (
 	"crypto/elliptic"
 	"math/big"
+
+	"github.com/zzGHzz/zkVote/dlog"
+	"github.com/zzGHzz/zkVote/zk"
 )
 
 // var
 var (
-	curve elliptic.Curve = elliptic.P256()
+	curve      elliptic.Curve = elliptic.P256()
+	dlogSolver dlog.Solver    = dlog.NewSolver(curve)
 )
 
 // SetEllipticCurve sets elliptic curve
+//
+// It also resets the discrete-log solver to the default one for c.
 func SetEllipticCurve(c elliptic.Curve) {
 	curve = c
+	dlogSolver = dlog.NewSolver(c)
+}
+
+// SetDLogSolver sets the solver used to recover tally results from g^V
+func SetDLogSolver(s dlog.Solver) {
+	dlogSolver = s
 }
 
+// BallotKind - type of a ballot, which tags it in json, see TypedBallot
+type BallotKind string
+
+// ballot kinds
+const (
+	BinaryKind     BallotKind = "binary"
+	TernaryKind    BallotKind = "ternary"
+	CumulativeKind BallotKind = "cumulative"
+	QuadraticKind  BallotKind = "quadratic"
+	SheetKind      BallotKind = "sheet"
+)
+
 // Ballot interface
 type Ballot interface {
 	VerifyBallot() error
+
+	// Kind returns the type of the ballot
+	Kind() BallotKind
+	// Voter returns the data that identifies the voter
+	Voter() *big.Int
+	// PublicKey returns the authority public key g^k the ballot is
+	// encrypted with
+	PublicKey() (*big.Int, *big.Int)
+	// Ciphertexts returns the encrypted values of the ballot in order
+	Ciphertexts() []*zk.Ciphertext
 }
 
-// Vote interface
+// Vote interface - casting, common to all election types
 type Vote interface {
-	Cast(b Ballot, addr []byte) error
+	// Cast casts a ballot of the voter identified by data
+	Cast(b Ballot, data *big.Int) error
+
+	SetRevotePolicy(p RevotePolicy) error
+	RevotePolicy() RevotePolicy
+	History() []*Replacement
+}
+
+// AuthVote interface - elections tallied by an authority holding k
+type AuthVote interface {
+	Vote
+
 	Tally(k *big.Int) error
 	VerifyTallyRes() error
 
 	GetAuthPublicKey() (*big.Int, *big.Int)
 }
+
+var (
+	_ AuthVote = (*BinaryVote)(nil)
+	_ AuthVote = (*CumulativeVote)(nil)
+	_ AuthVote = (*QuadraticVote)(nil)
+	_ Vote     = (*OpenVote)(nil)
+
+	_ Ballot = (*BinaryBallot)(nil)
+	_ Ballot = (*CumulativeBallot)(nil)
+	_ Ballot = (*QuadraticBallot)(nil)
+	_ Ballot = (*TernaryBallot)(nil)
+	_ Ballot = (*BallotSheet)(nil)
+)
@@ -25,6 +25,8 @@ type ECFSProof struct {
 	yX, yY *big.Int
 	tX, tY *big.Int
 	r      *big.Int
+
+	ctx []byte // context the proof is bound to
 }
 
 // NewECFSProver news a prover
@@ -48,6 +50,11 @@ func NewECFSProver(x, hX, hY *big.Int) (*ECFSProver, error) {
 
 // Prove generates ECFSProof
 func (p *ECFSProver) Prove(data *big.Int) (*ECFSProof, error) {
+	return p.ProveInContext(nil, data)
+}
+
+// ProveInContext generates ECFSProof bound to ctx
+func (p *ECFSProver) ProveInContext(ctx []byte, data *big.Int) (*ECFSProof, error) {
 	// v <--r-- Z_q^*
 	v, err := ecrand()
 	if err != nil {
@@ -57,8 +64,9 @@ func (p *ECFSProver) Prove(data *big.Int) (*ECFSProof, error) {
 	// t = g^v
 	tX, tY := curve.ScalarMult(p.hX, p.hY, v.Bytes())
 
-	// c = H(data, g, y, t)
+	// c = H(ctx, data, g, y, t)
 	c := sha256.Sum256(common.ConcatBytes(
+		challengeContext(ecfsTag, ctx),
 		data.Bytes(),
 		p.hX.Bytes(), p.hY.Bytes(),
 		p.yX.Bytes(), p.yY.Bytes(),
@@ -77,6 +85,7 @@ func (p *ECFSProver) Prove(data *big.Int) (*ECFSProof, error) {
 		new(big.Int).Set(p.hX), new(big.Int).Set(p.hY),
 		new(big.Int).Set(p.yX), new(big.Int).Set(p.yY),
 		tX, tY, r,
+		append([]byte(nil), ctx...),
 	}, nil
 }
 
@@ -102,8 +111,9 @@ func (p *ECFSProof) Verify() (bool, error) {
 		return false, ErrOutOfRange
 	}
 
-	// c = hash(data, h, y, t)
+	// c = hash(ctx, data, h, y, t)
 	c := sha256.Sum256(common.ConcatBytes(
+		challengeContext(ecfsTag, p.ctx),
 		p.data.Bytes(),
 		p.hX.Bytes(), p.hY.Bytes(),
 		p.yX.Bytes(), p.yY.Bytes(),
@@ -120,6 +130,26 @@ func (p *ECFSProof) Verify() (bool, error) {
 	return true, nil
 }
 
+// Base returns h
+func (p *ECFSProof) Base() (hX, hY *big.Int) {
+	return new(big.Int).Set(p.hX), new(big.Int).Set(p.hY)
+}
+
+// Value returns y = h^x
+func (p *ECFSProof) Value() (yX, yY *big.Int) {
+	return new(big.Int).Set(p.yX), new(big.Int).Set(p.yY)
+}
+
+// Data returns the data bound to the proof
+func (p *ECFSProof) Data() *big.Int {
+	return new(big.Int).Set(p.data)
+}
+
+// Context returns the context bound to the proof, nil if none
+func (p *ECFSProof) Context() []byte {
+	return append([]byte(nil), p.ctx...)
+}
+
 func (p *ECFSProof) String() string {
 	return fmt.Sprintf("h = (%x, %x); y = (%x, %x); t = (%x, %x); r = %x",
 		p.hX, p.hY, p.yX, p.yY, p.tX, p.tY, p.r)
@@ -136,6 +166,7 @@ func (p *ECFSProof) BuildJSONJSONECFSProof() *JSONECFSProof {
 		TX:   common.BigIntToHexStr(p.tX),
 		TY:   common.BigIntToHexStr(p.tY),
 		R:    common.BigIntToHexStr(p.r),
+		Ctx:  ctxToHexStr(p.ctx),
 	}
 }
 
@@ -146,6 +177,9 @@ func (p *ECFSProof) FromJSONECFSProof(obj *JSONECFSProof) error {
 	if p.data, err = common.HexStrToBigInt(obj.Data); err != nil {
 		return err
 	}
+	if p.ctx, err = hexStrToCtx(obj.Ctx); err != nil {
+		return err
+	}
 
 	if p.hX, err = common.HexStrToBigInt(obj.HX); err != nil {
 		return err
@@ -4,8 +4,11 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
 )
 
 // zk related errors
@@ -20,8 +23,21 @@ var (
 	ErrNotOnCurve    = errors.New("Not on curve")
 )
 
-// SetEllipticCurve sets elliptic curve
+// domain tags of the proofs bound to a context
+const (
+	binaryTag  = "zkvote/binary"
+	ecfsTag    = "zkvote/ecfs"
+	dleqTag    = "zkvote/dleq"
+	productTag = "zkvote/product"
+	shuffleTag = "zkvote/shuffle"
+)
+
+// SetEllipticCurve sets elliptic curve. Setting the curve already in use
+// is a no-op so that proofs can be verified concurrently.
 func SetEllipticCurve(c elliptic.Curve) {
+	if c == curve {
+		return
+	}
 	curve = c
 	N = new(big.Int).Set(curve.Params().N)
 	Gx = new(big.Int).Set(curve.Params().Gx)
@@ -63,3 +79,59 @@ func isOnCurve(X, Y *big.Int) bool {
 func isInRange(x *big.Int) bool {
 	return x.Cmp(big.NewInt(0)) > 0 && x.Cmp(N) < 0
 }
+
+// challengeContext returns the encoding of a proof context hashed into a
+// challenge: a zero byte, the domain tag of the proof, the length of ctx and
+// ctx. No data or point starts with a zero byte, so a proof bound to a
+// context can't be taken for a proof of other data without one, and the tag
+// keeps the proofs of different kinds apart. It is empty if there is no
+// context so that unbound proofs hash as before.
+func challengeContext(tag string, ctx []byte) []byte {
+	if len(ctx) == 0 {
+		return nil
+	}
+
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(ctx)))
+	return common.ConcatBytes([]byte{0}, []byte(tag), l[:], ctx)
+}
+
+// ctxToHexStr encodes a proof context, empty if there is none
+func ctxToHexStr(ctx []byte) string {
+	if len(ctx) == 0 {
+		return ""
+	}
+	return common.BytesToHexStr(ctx)
+}
+
+// hexStrToCtx decodes a proof context, nil if s is empty
+func hexStrToCtx(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return common.HexStrToBytes(s)
+}
+
+func newJSONPoint(X, Y *big.Int) *JSONPoint {
+	return &JSONPoint{
+		X: common.BigIntToHexStr(X),
+		Y: common.BigIntToHexStr(Y),
+	}
+}
+
+func (p *JSONPoint) toPoint() (*big.Int, *big.Int, error) {
+	if p == nil {
+		return nil, nil, errors.New("Missing point")
+	}
+
+	X, err := common.HexStrToBigInt(p.X)
+	if err != nil {
+		return nil, nil, err
+	}
+	Y, err := common.HexStrToBigInt(p.Y)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return X, Y, nil
+}
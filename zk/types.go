@@ -21,6 +21,7 @@ type JSONBinaryProof struct {
 	A2Y  string `json:"a2y"`
 	B2X  string `json:"b2x"`
 	B2Y  string `json:"b2y"`
+	Ctx  string `json:"ctx,omitempty"`
 }
 
 // JSONECFSProof defines json object
@@ -33,4 +34,90 @@ type JSONECFSProof struct {
 	TX   string `json:"tx"`
 	TY   string `json:"ty"`
 	R    string `json:"r"`
+	Ctx  string `json:"ctx,omitempty"`
+}
+
+// JSONDLEQProof defines json object
+type JSONDLEQProof struct {
+	Data string `json:"data"`
+	G1X  string `json:"g1x"`
+	G1Y  string `json:"g1y"`
+	G2X  string `json:"g2x"`
+	G2Y  string `json:"g2y"`
+	Y1X  string `json:"y1x"`
+	Y1Y  string `json:"y1y"`
+	Y2X  string `json:"y2x"`
+	Y2Y  string `json:"y2y"`
+	T1X  string `json:"t1x"`
+	T1Y  string `json:"t1y"`
+	T2X  string `json:"t2x"`
+	T2Y  string `json:"t2y"`
+	R    string `json:"r"`
+	Ctx  string `json:"ctx,omitempty"`
+}
+
+// JSONRangeProof defines json object
+type JSONRangeProof struct {
+	GAX  string             `json:"gax"`
+	GAY  string             `json:"gay"`
+	YX   string             `json:"yx"`
+	YY   string             `json:"yy"`
+	Bits []*JSONBinaryProof `json:"bits"`
+}
+
+// JSONProductProof defines json object
+type JSONProductProof struct {
+	Data string `json:"data"`
+	Ctx  string `json:"ctx,omitempty"`
+	GKX  string `json:"gkx"`
+	GKY  string `json:"gky"`
+	H1X  string `json:"h1x"`
+	H1Y  string `json:"h1y"`
+	Y1X  string `json:"y1x"`
+	Y1Y  string `json:"y1y"`
+	H2X  string `json:"h2x"`
+	H2Y  string `json:"h2y"`
+	Y2X  string `json:"y2x"`
+	Y2Y  string `json:"y2y"`
+	SHX  string `json:"shx"`
+	SHY  string `json:"shy"`
+	SYX  string `json:"syx"`
+	SYY  string `json:"syy"`
+	T1X  string `json:"t1x"`
+	T1Y  string `json:"t1y"`
+	T2X  string `json:"t2x"`
+	T2Y  string `json:"t2y"`
+	T3X  string `json:"t3x"`
+	T3Y  string `json:"t3y"`
+	T4X  string `json:"t4x"`
+	T4Y  string `json:"t4y"`
+	ZX   string `json:"zx"`
+	ZA   string `json:"za"`
+	ZT   string `json:"zt"`
+}
+
+// JSONPoint defines json object of an EC point
+type JSONPoint struct {
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+// JSONShuffleProof defines json object
+type JSONShuffleProof struct {
+	PK  *JSONPoint   `json:"pk"`
+	C   []*JSONPoint `json:"c"`
+	CH  []*JSONPoint `json:"ch"`
+	T1  *JSONPoint   `json:"t1"`
+	T2  *JSONPoint   `json:"t2"`
+	T3  *JSONPoint   `json:"t3"`
+	T4H *JSONPoint   `json:"t4h"`
+	T4Y *JSONPoint   `json:"t4y"`
+	TH  []*JSONPoint `json:"th"`
+	S1  string       `json:"s1"`
+	S2  string       `json:"s2"`
+	S3  string       `json:"s3"`
+	S4  string       `json:"s4"`
+	SH  []string     `json:"sh"`
+	SP  []string     `json:"sp"`
+	Ctx string       `json:"ctx,omitempty"`
 }
@@ -31,6 +31,8 @@ type BinaryProof struct {
 	d2, r2             *big.Int
 	a1X, a1Y, b1X, b1Y *big.Int
 	a2X, a2Y, b2X, b2Y *big.Int
+
+	ctx []byte // context the proof is bound to, e.g., election id
 }
 
 // NewBinaryProver - new Prover
@@ -62,6 +64,12 @@ func NewBinaryProver(value bool, a, gaX, gaY, gkX, gkY *big.Int) (*BinaryProver,
 //
 // data - used to identify the prover, e.g., his/her account address
 func (p *BinaryProver) Prove(data *big.Int) (*BinaryProof, error) {
+	return p.ProveInContext(nil, data)
+}
+
+// ProveInContext generates the zk proof bound to ctx, e.g., the hash of an
+// election, so that it is invalid in any other context
+func (p *BinaryProver) ProveInContext(ctx []byte, data *big.Int) (*BinaryProof, error) {
 	var w, r1, r2, d1, d2 *big.Int
 	var yX, yY *big.Int
 	var a1X, a1Y, b1X, b1Y, a2X, a2Y, b2X, b2Y *big.Int
@@ -106,8 +114,9 @@ func (p *BinaryProver) Prove(data *big.Int) (*BinaryProof, error) {
 		b2X, b2Y = curve.Add(X1, Y1, X2, Y2)
 		b2X, b2Y = curve.Add(b2X, b2Y, X3, Y3)
 
-		// c = hash(data, g^a, y, a1, b1, a2, b2)
+		// c = hash(ctx, data, g^a, y, a1, b1, a2, b2)
 		c := sha256.Sum256(common.ConcatBytes(
+			challengeContext(binaryTag, ctx),
 			data.Bytes(),
 			p.gaX.Bytes(), p.gaY.Bytes(),
 			yX.Bytes(), yY.Bytes(),
@@ -157,8 +166,9 @@ func (p *BinaryProver) Prove(data *big.Int) (*BinaryProof, error) {
 		// b1 = g^{d1*k*a + k*r1 + d1} = y^d1 g^{k*r1}
 		b1X, b1Y = curve.Add(X1, Y1, X2, Y2)
 
-		// c = hash(data, g^a, y, a1, b1, a2, b2)
+		// c = hash(ctx, data, g^a, y, a1, b1, a2, b2)
 		c := sha256.Sum256(common.ConcatBytes(
+			challengeContext(binaryTag, ctx),
 			data.Bytes(),
 			p.gaX.Bytes(), p.gaY.Bytes(),
 			yX.Bytes(), yY.Bytes(),
@@ -186,7 +196,8 @@ func (p *BinaryProver) Prove(data *big.Int) (*BinaryProof, error) {
 		yX, yY,
 		d1, r1, d2, r2,
 		a1X, a1Y, b1X, b1Y,
-		a2X, a2Y, b2X, b2Y}, nil
+		a2X, a2Y, b2X, b2Y,
+		append([]byte(nil), ctx...)}, nil
 }
 
 // Validate checks the validity of the zk proof
@@ -217,6 +228,7 @@ func (p *BinaryProof) Verify() (bool, error) {
 
 	// d1 + d2 == c mod N
 	c := sha256.Sum256(common.ConcatBytes(
+		challengeContext(binaryTag, p.ctx),
 		p.data.Bytes(),
 		p.gaX.Bytes(), p.gaY.Bytes(),
 		p.yX.Bytes(), p.yY.Bytes(),
@@ -272,6 +284,21 @@ func (p *BinaryProof) Verify() (bool, error) {
 	return true, nil
 }
 
+// PublicKey returns g^k
+func (p *BinaryProof) PublicKey() (gkX, gkY *big.Int) {
+	return new(big.Int).Set(p.gkX), new(big.Int).Set(p.gkY)
+}
+
+// Data returns the data bound to the proof
+func (p *BinaryProof) Data() *big.Int {
+	return new(big.Int).Set(p.data)
+}
+
+// Context returns the context bound to the proof, nil if none
+func (p *BinaryProof) Context() []byte {
+	return append([]byte(nil), p.ctx...)
+}
+
 func (p *BinaryProof) String() string {
 	return fmt.Sprintf("a1 = (%x, %x); b1 = (%x, %x); (d1, r1) = (%x, %x); a2 = (%x, %x); b2 = (%x, %x); (d2, r2) = (%x, %x)",
 		p.a1X, p.a1Y, p.b1X, p.b1Y, p.d1, p.r1, p.a2X, p.a2Y, p.b2X, p.b2Y, p.d2, p.r2)
@@ -304,6 +331,7 @@ func (p *BinaryProof) BuildJSONBinaryProof() *JSONBinaryProof {
 		R2:   common.BigIntToHexStr(p.r2),
 		D1:   common.BigIntToHexStr(p.d1),
 		D2:   common.BigIntToHexStr(p.d2),
+		Ctx:  ctxToHexStr(p.ctx),
 	}
 }
 
@@ -325,6 +353,9 @@ func (p *BinaryProof) FromJSONBinaryProof(jsonproof *JSONBinaryProof) error {
 	if p.data, err = common.HexStrToBigInt(jsonproof.Data); err != nil {
 		return err
 	}
+	if p.ctx, err = hexStrToCtx(jsonproof.Ctx); err != nil {
+		return err
+	}
 
 	if p.gaX, err = common.HexStrToBigInt(jsonproof.GAX); err != nil {
 		return err
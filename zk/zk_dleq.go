@@ -0,0 +1,242 @@
+// Prove the knowledge of secret x where y1 = g1^x and y2 = g2^x
+// (Chaum-Pedersen equality of discrete logs)
+
+package zk
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+)
+
+// DLEQProver - prover structure
+type DLEQProver struct {
+	x        *big.Int // secret
+	g1X, g1Y *big.Int // first base
+	g2X, g2Y *big.Int // second base
+	y1X, y1Y *big.Int // y1 = g1^x
+	y2X, y2Y *big.Int // y2 = g2^x
+}
+
+// DLEQProof - proof structure
+type DLEQProof struct {
+	data     *big.Int
+	g1X, g1Y *big.Int
+	g2X, g2Y *big.Int
+	y1X, y1Y *big.Int
+	y2X, y2Y *big.Int
+	t1X, t1Y *big.Int
+	t2X, t2Y *big.Int
+	r        *big.Int
+
+	ctx []byte // context the proof is bound to
+}
+
+// NewDLEQProver news a prover
+func NewDLEQProver(x, g1X, g1Y, g2X, g2Y *big.Int) (*DLEQProver, error) {
+	if !isInRange(x) {
+		return nil, ErrOutOfRange
+	}
+
+	if !isOnCurve(g1X, g1Y) || !isOnCurve(g2X, g2Y) {
+		return nil, ErrNotOnCurve
+	}
+
+	// y1 = g1^x, y2 = g2^x
+	y1X, y1Y := curve.ScalarMult(g1X, g1Y, x.Bytes())
+	y2X, y2Y := curve.ScalarMult(g2X, g2Y, x.Bytes())
+
+	return &DLEQProver{
+		new(big.Int).Set(x),
+		new(big.Int).Set(g1X), new(big.Int).Set(g1Y),
+		new(big.Int).Set(g2X), new(big.Int).Set(g2Y),
+		y1X, y1Y,
+		y2X, y2Y,
+	}, nil
+}
+
+// Prove generates DLEQProof
+func (p *DLEQProver) Prove(data *big.Int) (*DLEQProof, error) {
+	return p.ProveInContext(nil, data)
+}
+
+// ProveInContext generates DLEQProof bound to ctx
+func (p *DLEQProver) ProveInContext(ctx []byte, data *big.Int) (*DLEQProof, error) {
+	// v <--r-- Z_q^*
+	v, err := ecrand()
+	if err != nil {
+		return nil, err
+	}
+
+	// t1 = g1^v, t2 = g2^v
+	t1X, t1Y := curve.ScalarMult(p.g1X, p.g1Y, v.Bytes())
+	t2X, t2Y := curve.ScalarMult(p.g2X, p.g2Y, v.Bytes())
+
+	c := dleqChallenge(ctx, data,
+		p.g1X, p.g1Y, p.y1X, p.y1Y,
+		p.g2X, p.g2Y, p.y2X, p.y2Y,
+		t1X, t1Y, t2X, t2Y,
+	)
+
+	// r = v - c*x
+	r := new(big.Int).Mul(c, p.x)
+	r = r.Sub(v, r)
+	r = r.Mod(r, N)
+
+	return &DLEQProof{
+		data,
+		new(big.Int).Set(p.g1X), new(big.Int).Set(p.g1Y),
+		new(big.Int).Set(p.g2X), new(big.Int).Set(p.g2Y),
+		new(big.Int).Set(p.y1X), new(big.Int).Set(p.y1Y),
+		new(big.Int).Set(p.y2X), new(big.Int).Set(p.y2Y),
+		t1X, t1Y, t2X, t2Y, r,
+		append([]byte(nil), ctx...),
+	}, nil
+}
+
+// c = hash(ctx, data, g1, y1, g2, y2, t1, t2)
+func dleqChallenge(ctx []byte, data *big.Int, points ...*big.Int) *big.Int {
+	bs := [][]byte{challengeContext(dleqTag, ctx), data.Bytes()}
+	for _, p := range points {
+		bs = append(bs, p.Bytes())
+	}
+	c := sha256.Sum256(common.ConcatBytes(bs...))
+
+	return new(big.Int).SetBytes(c[:])
+}
+
+// Verify verifies DLEQProof
+func (p *DLEQProof) Verify() (bool, error) {
+	if !isOnCurve(p.g1X, p.g1Y) || !isOnCurve(p.g2X, p.g2Y) ||
+		!isOnCurve(p.y1X, p.y1Y) || !isOnCurve(p.y2X, p.y2Y) ||
+		!isOnCurve(p.t1X, p.t1Y) || !isOnCurve(p.t2X, p.t2Y) {
+		return false, ErrNotOnCurve
+	}
+
+	if !isInRange(p.r) {
+		return false, ErrOutOfRange
+	}
+
+	c := dleqChallenge(p.ctx, p.data,
+		p.g1X, p.g1Y, p.y1X, p.y1Y,
+		p.g2X, p.g2Y, p.y2X, p.y2Y,
+		p.t1X, p.t1Y, p.t2X, p.t2Y,
+	)
+
+	// check t1 = (g1^r)(y1^c)
+	X1, Y1 := curve.ScalarMult(p.g1X, p.g1Y, p.r.Bytes())
+	X2, Y2 := curve.ScalarMult(p.y1X, p.y1Y, c.Bytes())
+	X1, Y1 = curve.Add(X1, Y1, X2, Y2)
+	if X1.Cmp(p.t1X) != 0 || Y1.Cmp(p.t1Y) != 0 {
+		return false, nil
+	}
+
+	// check t2 = (g2^r)(y2^c)
+	X1, Y1 = curve.ScalarMult(p.g2X, p.g2Y, p.r.Bytes())
+	X2, Y2 = curve.ScalarMult(p.y2X, p.y2Y, c.Bytes())
+	X1, Y1 = curve.Add(X1, Y1, X2, Y2)
+	if X1.Cmp(p.t2X) != 0 || Y1.Cmp(p.t2Y) != 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Bases returns g1 and g2
+func (p *DLEQProof) Bases() (g1X, g1Y, g2X, g2Y *big.Int) {
+	return new(big.Int).Set(p.g1X), new(big.Int).Set(p.g1Y),
+		new(big.Int).Set(p.g2X), new(big.Int).Set(p.g2Y)
+}
+
+// Values returns y1 = g1^x and y2 = g2^x
+func (p *DLEQProof) Values() (y1X, y1Y, y2X, y2Y *big.Int) {
+	return new(big.Int).Set(p.y1X), new(big.Int).Set(p.y1Y),
+		new(big.Int).Set(p.y2X), new(big.Int).Set(p.y2Y)
+}
+
+// Data returns the data bound into the proof
+func (p *DLEQProof) Data() *big.Int {
+	return new(big.Int).Set(p.data)
+}
+
+// Context returns the context bound into the proof, nil if none
+func (p *DLEQProof) Context() []byte {
+	return append([]byte(nil), p.ctx...)
+}
+
+func (p *DLEQProof) String() string {
+	return fmt.Sprintf("g1 = (%x, %x); y1 = (%x, %x); g2 = (%x, %x); y2 = (%x, %x); t1 = (%x, %x); t2 = (%x, %x); r = %x",
+		p.g1X, p.g1Y, p.y1X, p.y1Y, p.g2X, p.g2Y, p.y2X, p.y2Y, p.t1X, p.t1Y, p.t2X, p.t2Y, p.r)
+}
+
+// BuildJSONDLEQProof returns json object
+func (p *DLEQProof) BuildJSONDLEQProof() *JSONDLEQProof {
+	return &JSONDLEQProof{
+		Data: common.BigIntToHexStr(p.data),
+		G1X:  common.BigIntToHexStr(p.g1X),
+		G1Y:  common.BigIntToHexStr(p.g1Y),
+		G2X:  common.BigIntToHexStr(p.g2X),
+		G2Y:  common.BigIntToHexStr(p.g2Y),
+		Y1X:  common.BigIntToHexStr(p.y1X),
+		Y1Y:  common.BigIntToHexStr(p.y1Y),
+		Y2X:  common.BigIntToHexStr(p.y2X),
+		Y2Y:  common.BigIntToHexStr(p.y2Y),
+		T1X:  common.BigIntToHexStr(p.t1X),
+		T1Y:  common.BigIntToHexStr(p.t1Y),
+		T2X:  common.BigIntToHexStr(p.t2X),
+		T2Y:  common.BigIntToHexStr(p.t2Y),
+		R:    common.BigIntToHexStr(p.r),
+		Ctx:  ctxToHexStr(p.ctx),
+	}
+}
+
+// FromJSONDLEQProof reconstructs proof from json object
+func (p *DLEQProof) FromJSONDLEQProof(obj *JSONDLEQProof) error {
+	if obj == nil {
+		return errors.New("Empty DLEQ proof")
+	}
+
+	var err error
+
+	fields := []struct {
+		dst **big.Int
+		src string
+	}{
+		{&p.data, obj.Data},
+		{&p.g1X, obj.G1X}, {&p.g1Y, obj.G1Y},
+		{&p.g2X, obj.G2X}, {&p.g2Y, obj.G2Y},
+		{&p.y1X, obj.Y1X}, {&p.y1Y, obj.Y1Y},
+		{&p.y2X, obj.Y2X}, {&p.y2Y, obj.Y2Y},
+		{&p.t1X, obj.T1X}, {&p.t1Y, obj.T1Y},
+		{&p.t2X, obj.T2X}, {&p.t2Y, obj.T2Y},
+		{&p.r, obj.R},
+	}
+	for _, f := range fields {
+		if *f.dst, err = common.HexStrToBigInt(f.src); err != nil {
+			return err
+		}
+	}
+	if p.ctx, err = hexStrToCtx(obj.Ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json marshal
+func (p *DLEQProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONDLEQProof())
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *DLEQProof) UnmarshalJSON(data []byte) error {
+	var obj JSONDLEQProof
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONDLEQProof(&obj)
+}
@@ -0,0 +1,339 @@
+// Prove S encrypts x*v2 given
+//	E1 = (g^{a1}, ((g^k)^{a1})(g^x))	- ciphertext of x with known secret a1
+//	E2 = (h2, y2)						- ciphertext of some v2
+//	S  = ((h2^x)(g^t), (y2^x)((g^k)^t))	- re-randomized E2^x
+//
+// The prover knows (x, a1, t). Setting E1 = E2 proves S encrypts x^2.
+
+package zk
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+)
+
+// ProductProver - structure
+type ProductProver struct {
+	x, a1, t           *big.Int
+	gkX, gkY           *big.Int // public key shared by authority
+	h1X, h1Y, y1X, y1Y *big.Int // E1
+	h2X, h2Y, y2X, y2Y *big.Int // E2
+	sHX, sHY, sYX, sYY *big.Int // S
+}
+
+// ProductProof - structure
+type ProductProof struct {
+	data               *big.Int
+	ctx                []byte // context the proof is bound to
+	gkX, gkY           *big.Int
+	h1X, h1Y, y1X, y1Y *big.Int
+	h2X, h2Y, y2X, y2Y *big.Int
+	sHX, sHY, sYX, sYY *big.Int
+	t1X, t1Y, t2X, t2Y *big.Int
+	t3X, t3Y, t4X, t4Y *big.Int
+	zx, za, zt         *big.Int
+}
+
+// NewProductProver - new prover
+//
+// x is the value encrypted by E1 = (g^{a1}, ((g^k)^{a1})(g^x)) and
+// (h2, y2) is E2. A random t is generated to re-randomize E2^x.
+func NewProductProver(x, a1, gkX, gkY, h2X, h2Y, y2X, y2Y *big.Int) (*ProductProver, error) {
+	if !isOnCurve(gkX, gkY) || !isOnCurve(h2X, h2Y) || !isOnCurve(y2X, y2Y) {
+		return nil, ErrNotOnCurve
+	}
+
+	if a1 == nil || !isInRange(a1) {
+		return nil, ErrOutOfRange
+	}
+
+	if x == nil {
+		return nil, errors.New("x cannot be nil")
+	}
+	x = new(big.Int).Mod(x, N)
+
+	t, err := ecrand()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ProductProver{
+		x: x, a1: new(big.Int).Set(a1), t: t,
+		gkX: new(big.Int).Set(gkX), gkY: new(big.Int).Set(gkY),
+		h2X: new(big.Int).Set(h2X), h2Y: new(big.Int).Set(h2Y),
+		y2X: new(big.Int).Set(y2X), y2Y: new(big.Int).Set(y2Y),
+	}
+
+	// E1 = (g^{a1}, ((g^k)^{a1})(g^x))
+	p.h1X, p.h1Y = curve.ScalarBaseMult(a1.Bytes())
+	p.y1X, p.y1Y = linearComb(gkX, gkY, a1, Gx, Gy, x)
+
+	// S = ((h2^x)(g^t), (y2^x)((g^k)^t))
+	p.sHX, p.sHY = linearComb(h2X, h2Y, x, Gx, Gy, t)
+	p.sYX, p.sYY = linearComb(y2X, y2Y, x, gkX, gkY, t)
+
+	return p, nil
+}
+
+// T returns the secret used to re-randomize E2^x
+func (p *ProductProver) T() *big.Int {
+	return new(big.Int).Set(p.t)
+}
+
+// Product returns S
+func (p *ProductProver) Product() (sHX, sHY, sYX, sYY *big.Int) {
+	return new(big.Int).Set(p.sHX), new(big.Int).Set(p.sHY),
+		new(big.Int).Set(p.sYX), new(big.Int).Set(p.sYY)
+}
+
+// Prove generates the zk proof
+func (p *ProductProver) Prove(data *big.Int) (*ProductProof, error) {
+	return p.ProveInContext(nil, data)
+}
+
+// ProveInContext generates the zk proof bound to ctx
+func (p *ProductProver) ProveInContext(ctx []byte, data *big.Int) (*ProductProof, error) {
+	var rx, ra, rt *big.Int
+	var err error
+
+	if rx, err = ecrand(); err != nil {
+		return nil, err
+	}
+	if ra, err = ecrand(); err != nil {
+		return nil, err
+	}
+	if rt, err = ecrand(); err != nil {
+		return nil, err
+	}
+
+	proof := &ProductProof{
+		data: data,
+		ctx:  append([]byte(nil), ctx...),
+		gkX:  p.gkX, gkY: p.gkY,
+		h1X: p.h1X, h1Y: p.h1Y, y1X: p.y1X, y1Y: p.y1Y,
+		h2X: p.h2X, h2Y: p.h2Y, y2X: p.y2X, y2Y: p.y2Y,
+		sHX: p.sHX, sHY: p.sHY, sYX: p.sYX, sYY: p.sYY,
+	}
+
+	// T1 = g^{ra}
+	proof.t1X, proof.t1Y = curve.ScalarBaseMult(ra.Bytes())
+	// T2 = ((g^k)^{ra})(g^{rx})
+	proof.t2X, proof.t2Y = linearComb(p.gkX, p.gkY, ra, Gx, Gy, rx)
+	// T3 = (h2^{rx})(g^{rt})
+	proof.t3X, proof.t3Y = linearComb(p.h2X, p.h2Y, rx, Gx, Gy, rt)
+	// T4 = (y2^{rx})((g^k)^{rt})
+	proof.t4X, proof.t4Y = linearComb(p.y2X, p.y2Y, rx, p.gkX, p.gkY, rt)
+
+	c := proof.challenge()
+
+	// z = r + c*w
+	response := func(r, w *big.Int) *big.Int {
+		z := new(big.Int).Mul(c, w)
+		z = z.Add(z, r)
+		return z.Mod(z, N)
+	}
+	proof.zx = response(rx, p.x)
+	proof.za = response(ra, p.a1)
+	proof.zt = response(rt, p.t)
+
+	return proof, nil
+}
+
+// c = hash(ctx, data, g^k, E1, E2, S, T1, T2, T3, T4)
+func (p *ProductProof) challenge() *big.Int {
+	c := sha256.Sum256(common.ConcatBytes(
+		challengeContext(productTag, p.ctx),
+		p.data.Bytes(),
+		p.gkX.Bytes(), p.gkY.Bytes(),
+		p.h1X.Bytes(), p.h1Y.Bytes(), p.y1X.Bytes(), p.y1Y.Bytes(),
+		p.h2X.Bytes(), p.h2Y.Bytes(), p.y2X.Bytes(), p.y2Y.Bytes(),
+		p.sHX.Bytes(), p.sHY.Bytes(), p.sYX.Bytes(), p.sYY.Bytes(),
+		p.t1X.Bytes(), p.t1Y.Bytes(), p.t2X.Bytes(), p.t2Y.Bytes(),
+		p.t3X.Bytes(), p.t3Y.Bytes(), p.t4X.Bytes(), p.t4Y.Bytes(),
+	))
+
+	return new(big.Int).SetBytes(c[:])
+}
+
+// Verify verifies the zk proof
+func (p *ProductProof) Verify() (bool, error) {
+	points := [][2]*big.Int{
+		{p.gkX, p.gkY},
+		{p.h1X, p.h1Y}, {p.y1X, p.y1Y},
+		{p.h2X, p.h2Y}, {p.y2X, p.y2Y},
+		{p.sHX, p.sHY}, {p.sYX, p.sYY},
+		{p.t1X, p.t1Y}, {p.t2X, p.t2Y}, {p.t3X, p.t3Y}, {p.t4X, p.t4Y},
+	}
+	for _, pt := range points {
+		if !isOnCurve(pt[0], pt[1]) {
+			return false, ErrNotOnCurve
+		}
+	}
+
+	for _, z := range []*big.Int{p.zx, p.za, p.zt} {
+		if z.Sign() < 0 || z.Cmp(N) >= 0 {
+			return false, ErrOutOfRange
+		}
+	}
+
+	c := p.challenge()
+
+	check := func(X, Y, tX, tY, uX, uY *big.Int) bool {
+		// X = T * U^c
+		X1, Y1 := curve.ScalarMult(uX, uY, c.Bytes())
+		X1, Y1 = curve.Add(X1, Y1, tX, tY)
+		return X.Cmp(X1) == 0 && Y.Cmp(Y1) == 0
+	}
+
+	// g^{za} = T1 * h1^c
+	X, Y := curve.ScalarBaseMult(p.za.Bytes())
+	if !check(X, Y, p.t1X, p.t1Y, p.h1X, p.h1Y) {
+		return false, nil
+	}
+
+	// ((g^k)^{za})(g^{zx}) = T2 * y1^c
+	X, Y = linearComb(p.gkX, p.gkY, p.za, Gx, Gy, p.zx)
+	if !check(X, Y, p.t2X, p.t2Y, p.y1X, p.y1Y) {
+		return false, nil
+	}
+
+	// (h2^{zx})(g^{zt}) = T3 * S_h^c
+	X, Y = linearComb(p.h2X, p.h2Y, p.zx, Gx, Gy, p.zt)
+	if !check(X, Y, p.t3X, p.t3Y, p.sHX, p.sHY) {
+		return false, nil
+	}
+
+	// (y2^{zx})((g^k)^{zt}) = T4 * S_y^c
+	X, Y = linearComb(p.y2X, p.y2Y, p.zx, p.gkX, p.gkY, p.zt)
+	if !check(X, Y, p.t4X, p.t4Y, p.sYX, p.sYY) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// linearComb computes (P1^s1)(P2^s2)
+func linearComb(p1X, p1Y, s1, p2X, p2Y, s2 *big.Int) (*big.Int, *big.Int) {
+	X1, Y1 := curve.ScalarMult(p1X, p1Y, s1.Bytes())
+	X2, Y2 := curve.ScalarMult(p2X, p2Y, s2.Bytes())
+	return curve.Add(X1, Y1, X2, Y2)
+}
+
+// Factors returns E1 and E2
+func (p *ProductProof) Factors() (h1X, h1Y, y1X, y1Y, h2X, h2Y, y2X, y2Y *big.Int) {
+	return new(big.Int).Set(p.h1X), new(big.Int).Set(p.h1Y),
+		new(big.Int).Set(p.y1X), new(big.Int).Set(p.y1Y),
+		new(big.Int).Set(p.h2X), new(big.Int).Set(p.h2Y),
+		new(big.Int).Set(p.y2X), new(big.Int).Set(p.y2Y)
+}
+
+// Product returns S
+func (p *ProductProof) Product() (sHX, sHY, sYX, sYY *big.Int) {
+	return new(big.Int).Set(p.sHX), new(big.Int).Set(p.sHY),
+		new(big.Int).Set(p.sYX), new(big.Int).Set(p.sYY)
+}
+
+// PublicKey returns the authority public key the proof is bound to
+func (p *ProductProof) PublicKey() (*big.Int, *big.Int) {
+	return new(big.Int).Set(p.gkX), new(big.Int).Set(p.gkY)
+}
+
+// Data returns the data the proof is bound to
+func (p *ProductProof) Data() *big.Int {
+	return new(big.Int).Set(p.data)
+}
+
+// Context returns the context bound to the proof, nil if none
+func (p *ProductProof) Context() []byte {
+	return append([]byte(nil), p.ctx...)
+}
+
+func (p *ProductProof) String() string {
+	return fmt.Sprintf("S = (%x, %x, %x, %x); (zx, za, zt) = (%x, %x, %x)",
+		p.sHX, p.sHY, p.sYX, p.sYY, p.zx, p.za, p.zt)
+}
+
+// BuildJSONProductProof builds JSON object
+func (p *ProductProof) BuildJSONProductProof() *JSONProductProof {
+	return &JSONProductProof{
+		Data: common.BigIntToHexStr(p.data),
+		Ctx:  ctxToHexStr(p.ctx),
+		GKX:  common.BigIntToHexStr(p.gkX),
+		GKY:  common.BigIntToHexStr(p.gkY),
+		H1X:  common.BigIntToHexStr(p.h1X),
+		H1Y:  common.BigIntToHexStr(p.h1Y),
+		Y1X:  common.BigIntToHexStr(p.y1X),
+		Y1Y:  common.BigIntToHexStr(p.y1Y),
+		H2X:  common.BigIntToHexStr(p.h2X),
+		H2Y:  common.BigIntToHexStr(p.h2Y),
+		Y2X:  common.BigIntToHexStr(p.y2X),
+		Y2Y:  common.BigIntToHexStr(p.y2Y),
+		SHX:  common.BigIntToHexStr(p.sHX),
+		SHY:  common.BigIntToHexStr(p.sHY),
+		SYX:  common.BigIntToHexStr(p.sYX),
+		SYY:  common.BigIntToHexStr(p.sYY),
+		T1X:  common.BigIntToHexStr(p.t1X),
+		T1Y:  common.BigIntToHexStr(p.t1Y),
+		T2X:  common.BigIntToHexStr(p.t2X),
+		T2Y:  common.BigIntToHexStr(p.t2Y),
+		T3X:  common.BigIntToHexStr(p.t3X),
+		T3Y:  common.BigIntToHexStr(p.t3Y),
+		T4X:  common.BigIntToHexStr(p.t4X),
+		T4Y:  common.BigIntToHexStr(p.t4Y),
+		ZX:   common.BigIntToHexStr(p.zx),
+		ZA:   common.BigIntToHexStr(p.za),
+		ZT:   common.BigIntToHexStr(p.zt),
+	}
+}
+
+// FromJSONProductProof reconstructs from JSONProductProof
+func (p *ProductProof) FromJSONProductProof(obj *JSONProductProof) error {
+	if obj == nil {
+		return errors.New("Empty product proof")
+	}
+
+	var err error
+
+	fields := []struct {
+		dst **big.Int
+		src string
+	}{
+		{&p.data, obj.Data},
+		{&p.gkX, obj.GKX}, {&p.gkY, obj.GKY},
+		{&p.h1X, obj.H1X}, {&p.h1Y, obj.H1Y}, {&p.y1X, obj.Y1X}, {&p.y1Y, obj.Y1Y},
+		{&p.h2X, obj.H2X}, {&p.h2Y, obj.H2Y}, {&p.y2X, obj.Y2X}, {&p.y2Y, obj.Y2Y},
+		{&p.sHX, obj.SHX}, {&p.sHY, obj.SHY}, {&p.sYX, obj.SYX}, {&p.sYY, obj.SYY},
+		{&p.t1X, obj.T1X}, {&p.t1Y, obj.T1Y}, {&p.t2X, obj.T2X}, {&p.t2Y, obj.T2Y},
+		{&p.t3X, obj.T3X}, {&p.t3Y, obj.T3Y}, {&p.t4X, obj.T4X}, {&p.t4Y, obj.T4Y},
+		{&p.zx, obj.ZX}, {&p.za, obj.ZA}, {&p.zt, obj.ZT},
+	}
+	for _, f := range fields {
+		if *f.dst, err = common.HexStrToBigInt(f.src); err != nil {
+			return err
+		}
+	}
+	if p.ctx, err = hexStrToCtx(obj.Ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json marshal
+func (p *ProductProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONProductProof())
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *ProductProof) UnmarshalJSON(data []byte) error {
+	var obj JSONProductProof
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONProductProof(&obj)
+}
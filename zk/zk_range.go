@@ -0,0 +1,283 @@
+// Prove v\in[0, 2^l) given g^a, ((g^k)^a)(g^v)
+// 	a 		- a known secret key
+//	g^k 	- a known public key
+//
+// v is decomposed into bits v = sum_j 2^j*b_j. Each bit is encrypted as
+// (g^{a_j}, g^{k*a_j}*g^{b_j}) with a = sum_j 2^j*a_j and comes with a
+// BinaryProof. The verifier recombines the bit ciphertexts and checks that
+// they match the ciphertext of v.
+
+package zk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+)
+
+// RangeProver - structure
+type RangeProver struct {
+	v        *big.Int   // value
+	l        int        // number of bits
+	as       []*big.Int // secret of each bit
+	gkX, gkY *big.Int   // public key shared by authority
+}
+
+// RangeProof - structure
+type RangeProof struct {
+	gaX, gaY *big.Int // g^a
+	yX, yY   *big.Int // y = g^{ka} * g^v
+	bits     []*BinaryProof
+}
+
+// NewRangeProver - new prover
+//
+// a is the secret used to encrypt v. A random one is generated if a is nil.
+func NewRangeProver(v *big.Int, l int, a, gkX, gkY *big.Int) (*RangeProver, error) {
+	if gkX == nil || gkY == nil {
+		return nil, errors.New("gk cannot be nil")
+	}
+
+	if !isOnCurve(gkX, gkY) {
+		return nil, ErrNotOnCurve
+	}
+
+	if l <= 0 || l >= N.BitLen() {
+		return nil, errors.New("Invalid number of bits")
+	}
+
+	if v == nil || v.Sign() < 0 || v.BitLen() > l {
+		return nil, ErrOutOfRange
+	}
+
+	var err error
+	if a == nil {
+		if a, err = ecrand(); err != nil {
+			return nil, err
+		}
+	} else if !isInRange(a) {
+		return nil, ErrOutOfRange
+	}
+
+	// Split a into a = sum_j 2^j*a_j
+	as := make([]*big.Int, l)
+	for {
+		a0 := new(big.Int).Set(a)
+		for j := 1; j < l; j++ {
+			if as[j], err = ecrand(); err != nil {
+				return nil, err
+			}
+			t := new(big.Int).Lsh(as[j], uint(j))
+			a0 = a0.Sub(a0, t)
+		}
+		a0 = a0.Mod(a0, N)
+
+		if isInRange(a0) {
+			as[0] = a0
+			break
+		}
+	}
+
+	return &RangeProver{new(big.Int).Set(v), l, as, gkX, gkY}, nil
+}
+
+// A returns the secret used to encrypt v
+func (p *RangeProver) A() *big.Int {
+	a := big.NewInt(0)
+	for j, aj := range p.as {
+		a = a.Add(a, new(big.Int).Lsh(aj, uint(j)))
+	}
+	return a.Mod(a, N)
+}
+
+// Prove generates the zk proof of the range of v
+//
+// data - used to identify the prover, e.g., his/her account address
+func (p *RangeProver) Prove(data *big.Int) (*RangeProof, error) {
+	return p.ProveInContext(nil, data)
+}
+
+// ProveInContext generates the zk proof of the range of v with the proof of
+// each bit bound to ctx, e.g., the hash of an election manifest
+func (p *RangeProver) ProveInContext(ctx []byte, data *big.Int) (*RangeProof, error) {
+	a := p.A()
+
+	// g^a
+	gaX, gaY := curve.ScalarBaseMult(a.Bytes())
+
+	// y = g^{ka} * g^v
+	yX, yY := curve.ScalarMult(p.gkX, p.gkY, a.Bytes())
+	if p.v.Sign() > 0 {
+		X, Y := curve.ScalarBaseMult(p.v.Bytes())
+		yX, yY = curve.Add(yX, yY, X, Y)
+	}
+
+	bits := make([]*BinaryProof, p.l)
+	for j, aj := range p.as {
+		ajX, ajY := curve.ScalarBaseMult(aj.Bytes())
+
+		prover, err := NewBinaryProver(p.v.Bit(j) == 1, aj, ajX, ajY, p.gkX, p.gkY)
+		if err != nil {
+			return nil, err
+		}
+
+		if bits[j], err = prover.ProveInContext(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &RangeProof{gaX, gaY, yX, yY, bits}, nil
+}
+
+// Verify checks the validity of the range proof
+func (p *RangeProof) Verify() (bool, error) {
+	if len(p.bits) == 0 || len(p.bits) >= N.BitLen() {
+		return false, ErrOutOfRange
+	}
+
+	if !isOnCurve(p.gaX, p.gaY) || !isOnCurve(p.yX, p.yY) {
+		return false, ErrNotOnCurve
+	}
+
+	first := p.bits[0]
+
+	HX, HY := new(big.Int), new(big.Int)
+	YX, YY := new(big.Int), new(big.Int)
+	for j, b := range p.bits {
+		if !isOnCurve(b.gaX, b.gaY) || !isOnCurve(b.yX, b.yY) || !isOnCurve(b.gkX, b.gkY) {
+			return false, ErrNotOnCurve
+		}
+
+		// all bits must be bound to the same public key, data and context
+		if b.gkX.Cmp(first.gkX) != 0 || b.gkY.Cmp(first.gkY) != 0 || b.data.Cmp(first.data) != 0 ||
+			!bytes.Equal(b.ctx, first.ctx) {
+			return false, nil
+		}
+
+		if res, err := b.Verify(); err != nil || !res {
+			return false, err
+		}
+
+		// H = H * (g^{a_j})^{2^j}, Y = Y * y_j^{2^j}
+		s := new(big.Int).Lsh(big.NewInt(1), uint(j)).Bytes()
+		X, Y := curve.ScalarMult(b.gaX, b.gaY, s)
+		HX, HY = curve.Add(HX, HY, X, Y)
+		X, Y = curve.ScalarMult(b.yX, b.yY, s)
+		YX, YY = curve.Add(YX, YY, X, Y)
+	}
+
+	if HX.Cmp(p.gaX) != 0 || HY.Cmp(p.gaY) != 0 || YX.Cmp(p.yX) != 0 || YY.Cmp(p.yY) != 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Bits returns the number of bits covered by the proof
+func (p *RangeProof) Bits() int {
+	return len(p.bits)
+}
+
+// Ciphertext returns g^a and y = g^{ka} * g^v
+func (p *RangeProof) Ciphertext() (gaX, gaY, yX, yY *big.Int) {
+	return new(big.Int).Set(p.gaX), new(big.Int).Set(p.gaY),
+		new(big.Int).Set(p.yX), new(big.Int).Set(p.yY)
+}
+
+// PublicKey returns the authority public key the proof is bound to
+func (p *RangeProof) PublicKey() (*big.Int, *big.Int) {
+	if len(p.bits) == 0 {
+		return nil, nil
+	}
+	return new(big.Int).Set(p.bits[0].gkX), new(big.Int).Set(p.bits[0].gkY)
+}
+
+// Data returns the data the proof is bound to
+func (p *RangeProof) Data() *big.Int {
+	if len(p.bits) == 0 {
+		return nil
+	}
+	return new(big.Int).Set(p.bits[0].data)
+}
+
+// Context returns the context bound to the proof, nil if none
+func (p *RangeProof) Context() []byte {
+	if len(p.bits) == 0 {
+		return nil
+	}
+	return p.bits[0].Context()
+}
+
+func (p *RangeProof) String() string {
+	return fmt.Sprintf("g^a = (%x, %x); y = (%x, %x); bits = %d", p.gaX, p.gaY, p.yX, p.yY, len(p.bits))
+}
+
+// BuildJSONRangeProof builds JSON object
+func (p *RangeProof) BuildJSONRangeProof() *JSONRangeProof {
+	bits := make([]*JSONBinaryProof, len(p.bits))
+	for j, b := range p.bits {
+		bits[j] = b.BuildJSONBinaryProof()
+	}
+
+	return &JSONRangeProof{
+		GAX:  common.BigIntToHexStr(p.gaX),
+		GAY:  common.BigIntToHexStr(p.gaY),
+		YX:   common.BigIntToHexStr(p.yX),
+		YY:   common.BigIntToHexStr(p.yY),
+		Bits: bits,
+	}
+}
+
+// FromJSONRangeProof reconstructs from JSONRangeProof
+func (p *RangeProof) FromJSONRangeProof(obj *JSONRangeProof) error {
+	if obj == nil {
+		return errors.New("Empty range proof")
+	}
+
+	var err error
+
+	if p.gaX, err = common.HexStrToBigInt(obj.GAX); err != nil {
+		return err
+	}
+	if p.gaY, err = common.HexStrToBigInt(obj.GAY); err != nil {
+		return err
+	}
+
+	if p.yX, err = common.HexStrToBigInt(obj.YX); err != nil {
+		return err
+	}
+	if p.yY, err = common.HexStrToBigInt(obj.YY); err != nil {
+		return err
+	}
+
+	p.bits = make([]*BinaryProof, len(obj.Bits))
+	for j, b := range obj.Bits {
+		if b == nil {
+			return errors.New("Empty bit proof")
+		}
+		p.bits[j] = new(BinaryProof)
+		if err = p.bits[j].FromJSONBinaryProof(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json marshal
+func (p *RangeProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONRangeProof())
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *RangeProof) UnmarshalJSON(data []byte) error {
+	var obj JSONRangeProof
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONRangeProof(&obj)
+}
@@ -0,0 +1,534 @@
+// Prove that a list of ElGamal ciphertexts is a permutation of
+// re-encryptions of another list (Terelius-Wikström proof of shuffle)
+
+package zk
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/zzGHzz/zkVote/common"
+)
+
+// Ciphertext - ElGamal ciphertext (h, y) = (g^r, pk^r * m)
+type Ciphertext struct {
+	HX, HY *big.Int
+	YX, YY *big.Int
+}
+
+// ReEncrypt returns the ciphertext multiplied by an encryption of zero
+// with randomness r, i.e., (h * g^r, y * pk^r)
+func (e *Ciphertext) ReEncrypt(pkX, pkY, r *big.Int) *Ciphertext {
+	gX, gY := curve.ScalarBaseMult(r.Bytes())
+	kX, kY := curve.ScalarMult(pkX, pkY, r.Bytes())
+
+	e1 := new(Ciphertext)
+	e1.HX, e1.HY = curve.Add(e.HX, e.HY, gX, gY)
+	e1.YX, e1.YY = curve.Add(e.YX, e.YY, kX, kY)
+	return e1
+}
+
+// ShuffleProver - prover structure
+//
+// out[i] is the re-encryption of in[perm[i]] with randomness r[i].
+type ShuffleProver struct {
+	pkX, pkY *big.Int
+	in, out  []*Ciphertext
+	perm     []int
+	r        []*big.Int
+}
+
+// ShuffleProof - proof structure
+type ShuffleProof struct {
+	pkX, pkY *big.Int
+
+	cX, cY   []*big.Int // commitments to the permutation
+	chX, chY []*big.Int // commitment chain to the permuted challenges
+
+	t1X, t1Y   *big.Int
+	t2X, t2Y   *big.Int
+	t3X, t3Y   *big.Int
+	t4hX, t4hY *big.Int
+	t4yX, t4yY *big.Int
+	thX, thY   []*big.Int
+
+	s1, s2, s3, s4 *big.Int
+	sh, sp         []*big.Int
+
+	ctx []byte // context the proof is bound to
+}
+
+// NewShuffleProver news a prover
+func NewShuffleProver(pkX, pkY *big.Int, in, out []*Ciphertext, perm []int, r []*big.Int) (*ShuffleProver, error) {
+	n := len(in)
+	if n == 0 || len(out) != n || len(perm) != n || len(r) != n {
+		return nil, errors.New("Invalid shuffle size")
+	}
+	if !isOnCurve(pkX, pkY) {
+		return nil, ErrNotOnCurve
+	}
+
+	used := make([]bool, n)
+	for i, j := range perm {
+		if j < 0 || j >= n || used[j] {
+			return nil, errors.New("Invalid permutation")
+		}
+		used[j] = true
+
+		e := in[j].ReEncrypt(pkX, pkY, r[i])
+		if e.HX.Cmp(out[i].HX) != 0 || e.HY.Cmp(out[i].HY) != 0 ||
+			e.YX.Cmp(out[i].YX) != 0 || e.YY.Cmp(out[i].YY) != 0 {
+			return nil, errors.New("Output not a re-encryption of input")
+		}
+	}
+
+	return &ShuffleProver{
+		new(big.Int).Set(pkX), new(big.Int).Set(pkY),
+		in, out, append([]int(nil), perm...), r,
+	}, nil
+}
+
+// ProveInContext generates ShuffleProof bound to ctx
+func (p *ShuffleProver) ProveInContext(ctx []byte) (*ShuffleProof, error) {
+	n := len(p.in)
+	gX, gY := shuffleGenerators(n)
+
+	rnd := func(k int) ([]*big.Int, error) {
+		s := make([]*big.Int, k)
+		for i := range s {
+			var err error
+			if s[i], err = ecrand(); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	}
+
+	// c_perm[i] = g^r_perm[i] * h_i
+	rc, err := rnd(n)
+	if err != nil {
+		return nil, err
+	}
+	pf := &ShuffleProof{
+		pkX: p.pkX, pkY: p.pkY,
+		cX: make([]*big.Int, n), cY: make([]*big.Int, n),
+		chX: make([]*big.Int, n), chY: make([]*big.Int, n),
+		thX: make([]*big.Int, n), thY: make([]*big.Int, n),
+		sh: make([]*big.Int, n), sp: make([]*big.Int, n),
+		ctx: append([]byte(nil), ctx...),
+	}
+	for i, j := range p.perm {
+		X, Y := curve.ScalarBaseMult(rc[j].Bytes())
+		pf.cX[j], pf.cY[j] = curve.Add(X, Y, gX[i+1], gY[i+1])
+	}
+
+	u := shuffleChallenges(p.pkX, p.pkY, p.in, p.out, pf.cX, pf.cY, ctx)
+	up := make([]*big.Int, n)
+	for i, j := range p.perm {
+		up[i] = u[j]
+	}
+
+	// ch_i = g^rh_i * ch_{i-1}^up_i, ch_0 = h_0
+	rh, err := rnd(n)
+	if err != nil {
+		return nil, err
+	}
+	prevX, prevY := gX[0], gY[0]
+	for i := 0; i < n; i++ {
+		X, Y := scalarMult(nil, nil, rh[i])
+		pf.chX[i], pf.chY[i] = mulAdd(X, Y, prevX, prevY, up[i])
+		prevX, prevY = pf.chX[i], pf.chY[i]
+	}
+
+	w, err := rnd(4)
+	if err != nil {
+		return nil, err
+	}
+	wh, err := rnd(n)
+	if err != nil {
+		return nil, err
+	}
+	wp, err := rnd(n)
+	if err != nil {
+		return nil, err
+	}
+
+	pf.t1X, pf.t1Y = curve.ScalarBaseMult(w[0].Bytes())
+	pf.t2X, pf.t2Y = curve.ScalarBaseMult(w[1].Bytes())
+	pf.t3X, pf.t3Y = curve.ScalarBaseMult(w[2].Bytes())
+	pf.t4hX, pf.t4hY = scalarMult(nil, nil, neg(w[3]))
+	pf.t4yX, pf.t4yY = scalarMult(p.pkX, p.pkY, neg(w[3]))
+	prevX, prevY = gX[0], gY[0]
+	for i := 0; i < n; i++ {
+		pf.t3X, pf.t3Y = mulAdd(pf.t3X, pf.t3Y, gX[i+1], gY[i+1], wp[i])
+		pf.t4hX, pf.t4hY = mulAdd(pf.t4hX, pf.t4hY, p.out[i].HX, p.out[i].HY, wp[i])
+		pf.t4yX, pf.t4yY = mulAdd(pf.t4yX, pf.t4yY, p.out[i].YX, p.out[i].YY, wp[i])
+		X, Y := scalarMult(nil, nil, wh[i])
+		pf.thX[i], pf.thY[i] = mulAdd(X, Y, prevX, prevY, wp[i])
+		prevX, prevY = pf.chX[i], pf.chY[i]
+	}
+
+	c := pf.challenge(p.in, p.out)
+
+	// rBar = sum r_i, rHat = sum rh_i * prod_{k>i} up_k, rTilde = sum r_i * u_i,
+	// rPrime = sum r'_i * up_i
+	rBar, rHat, rTilde, rPrime := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	v := big.NewInt(1)
+	for i := n - 1; i >= 0; i-- {
+		rHat = rHat.Add(rHat, new(big.Int).Mul(rh[i], v))
+		v = v.Mul(v, up[i])
+		v = v.Mod(v, N)
+	}
+	for i := 0; i < n; i++ {
+		rBar = rBar.Add(rBar, rc[i])
+		rTilde = rTilde.Add(rTilde, new(big.Int).Mul(rc[i], u[i]))
+		rPrime = rPrime.Add(rPrime, new(big.Int).Mul(p.r[i], up[i]))
+	}
+
+	resp := func(w, x *big.Int) *big.Int {
+		s := new(big.Int).Mul(c, x)
+		s = s.Add(w, s)
+		return s.Mod(s, N)
+	}
+	pf.s1 = resp(w[0], rBar)
+	pf.s2 = resp(w[1], rHat)
+	pf.s3 = resp(w[2], rTilde)
+	pf.s4 = resp(w[3], rPrime)
+	for i := 0; i < n; i++ {
+		pf.sh[i] = resp(wh[i], rh[i])
+		pf.sp[i] = resp(wp[i], up[i])
+	}
+
+	return pf, nil
+}
+
+// Verify verifies that out is a permutation of re-encryptions of in
+func (p *ShuffleProof) Verify(in, out []*Ciphertext) (bool, error) {
+	n := len(in)
+	if n == 0 || len(out) != n || len(p.cX) != n || len(p.chX) != n ||
+		len(p.thX) != n || len(p.sh) != n || len(p.sp) != n {
+		return false, errors.New("Invalid shuffle size")
+	}
+
+	points := [][2]*big.Int{
+		{p.pkX, p.pkY}, {p.t1X, p.t1Y}, {p.t2X, p.t2Y}, {p.t3X, p.t3Y},
+		{p.t4hX, p.t4hY}, {p.t4yX, p.t4yY},
+	}
+	for i := 0; i < n; i++ {
+		points = append(points,
+			[2]*big.Int{in[i].HX, in[i].HY}, [2]*big.Int{in[i].YX, in[i].YY},
+			[2]*big.Int{out[i].HX, out[i].HY}, [2]*big.Int{out[i].YX, out[i].YY},
+			[2]*big.Int{p.cX[i], p.cY[i]}, [2]*big.Int{p.chX[i], p.chY[i]},
+			[2]*big.Int{p.thX[i], p.thY[i]},
+		)
+	}
+	for _, pt := range points {
+		if !isOnCurve(pt[0], pt[1]) {
+			return false, ErrNotOnCurve
+		}
+	}
+	for _, s := range append(append([]*big.Int{p.s1, p.s2, p.s3, p.s4}, p.sh...), p.sp...) {
+		if s.Sign() < 0 || s.Cmp(N) >= 0 {
+			return false, ErrOutOfRange
+		}
+	}
+
+	gX, gY := shuffleGenerators(n)
+	u := shuffleChallenges(p.pkX, p.pkY, in, out, p.cX, p.cY, p.ctx)
+	c := p.challenge(in, out)
+	nc := neg(c)
+
+	// cBar = prod c_i / prod h_i, cHat = ch_n / h_0^(prod u_i),
+	// cTilde = prod c_i^u_i, (h', y') = prod (h_i, y_i)^u_i
+	cBarX, cBarY := new(big.Int), new(big.Int)
+	cTildeX, cTildeY := new(big.Int), new(big.Int)
+	hX, hY, yX, yY := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	prod := big.NewInt(1)
+	for i := 0; i < n; i++ {
+		cBarX, cBarY = curve.Add(cBarX, cBarY, p.cX[i], p.cY[i])
+		cBarX, cBarY = mulAdd(cBarX, cBarY, gX[i+1], gY[i+1], big.NewInt(-1))
+		cTildeX, cTildeY = mulAdd(cTildeX, cTildeY, p.cX[i], p.cY[i], u[i])
+		hX, hY = mulAdd(hX, hY, in[i].HX, in[i].HY, u[i])
+		yX, yY = mulAdd(yX, yY, in[i].YX, in[i].YY, u[i])
+		prod = prod.Mul(prod, u[i])
+		prod = prod.Mod(prod, N)
+	}
+	cHatX, cHatY := mulAdd(p.chX[n-1], p.chY[n-1], gX[0], gY[0], neg(prod))
+
+	check := func(tX, tY *big.Int, terms ...[3]*big.Int) bool {
+		X, Y := new(big.Int), new(big.Int)
+		for _, t := range terms {
+			X, Y = mulAdd(X, Y, t[0], t[1], t[2])
+		}
+		return X.Cmp(tX) == 0 && Y.Cmp(tY) == 0
+	}
+
+	if !check(p.t1X, p.t1Y, [3]*big.Int{cBarX, cBarY, nc}, [3]*big.Int{nil, nil, p.s1}) ||
+		!check(p.t2X, p.t2Y, [3]*big.Int{cHatX, cHatY, nc}, [3]*big.Int{nil, nil, p.s2}) {
+		return false, nil
+	}
+
+	t3 := [][3]*big.Int{{cTildeX, cTildeY, nc}, {nil, nil, p.s3}}
+	t4h := [][3]*big.Int{{hX, hY, nc}, {nil, nil, neg(p.s4)}}
+	t4y := [][3]*big.Int{{yX, yY, nc}, {p.pkX, p.pkY, neg(p.s4)}}
+	for i := 0; i < n; i++ {
+		t3 = append(t3, [3]*big.Int{gX[i+1], gY[i+1], p.sp[i]})
+		t4h = append(t4h, [3]*big.Int{out[i].HX, out[i].HY, p.sp[i]})
+		t4y = append(t4y, [3]*big.Int{out[i].YX, out[i].YY, p.sp[i]})
+	}
+	if !check(p.t3X, p.t3Y, t3...) || !check(p.t4hX, p.t4hY, t4h...) || !check(p.t4yX, p.t4yY, t4y...) {
+		return false, nil
+	}
+
+	prevX, prevY := gX[0], gY[0]
+	for i := 0; i < n; i++ {
+		if !check(p.thX[i], p.thY[i],
+			[3]*big.Int{p.chX[i], p.chY[i], nc},
+			[3]*big.Int{nil, nil, p.sh[i]},
+			[3]*big.Int{prevX, prevY, p.sp[i]},
+		) {
+			return false, nil
+		}
+		prevX, prevY = p.chX[i], p.chY[i]
+	}
+
+	return true, nil
+}
+
+// PublicKey returns the public key of the ciphertexts
+func (p *ShuffleProof) PublicKey() (*big.Int, *big.Int) {
+	return new(big.Int).Set(p.pkX), new(big.Int).Set(p.pkY)
+}
+
+// Context returns the context bound into the proof, nil if none
+func (p *ShuffleProof) Context() []byte {
+	return append([]byte(nil), p.ctx...)
+}
+
+// scalarMult returns k * (X, Y), or k * g if X is nil, with k reduced mod N
+func scalarMult(X, Y, k *big.Int) (*big.Int, *big.Int) {
+	k = new(big.Int).Mod(k, N)
+	if X == nil {
+		return curve.ScalarBaseMult(k.Bytes())
+	}
+	return curve.ScalarMult(X, Y, k.Bytes())
+}
+
+// mulAdd returns (X, Y) + k * (PX, PY), or (X, Y) + k * g if PX is nil
+func mulAdd(X, Y, PX, PY, k *big.Int) (*big.Int, *big.Int) {
+	mX, mY := scalarMult(PX, PY, k)
+	return curve.Add(X, Y, mX, mY)
+}
+
+func neg(x *big.Int) *big.Int {
+	y := new(big.Int).Neg(x)
+	return y.Mod(y, N)
+}
+
+// shuffleGenerators returns n+1 generators whose discrete logs are unknown,
+// derived by hashing to the curve
+func shuffleGenerators(n int) ([]*big.Int, []*big.Int) {
+	P := curve.Params().P
+	three := big.NewInt(3)
+
+	xs := make([]*big.Int, n+1)
+	ys := make([]*big.Int, n+1)
+	for i := 0; i <= n; i++ {
+		for ctr := uint32(0); ; ctr++ {
+			var buf [12]byte
+			binary.BigEndian.PutUint64(buf[:8], uint64(i))
+			binary.BigEndian.PutUint32(buf[8:], ctr)
+			d := sha256.Sum256(append([]byte("zkVote shuffle generator"), buf[:]...))
+
+			// y^2 = x^3 - 3x + b
+			x := new(big.Int).SetBytes(d[:])
+			x = x.Mod(x, P)
+			rhs := new(big.Int).Exp(x, three, P)
+			rhs = rhs.Sub(rhs, new(big.Int).Mul(three, x))
+			rhs = rhs.Add(rhs, curve.Params().B)
+			rhs = rhs.Mod(rhs, P)
+			y := new(big.Int).ModSqrt(rhs, P)
+			if y != nil && curve.IsOnCurve(x, y) {
+				xs[i], ys[i] = x, y
+				break
+			}
+		}
+	}
+	return xs, ys
+}
+
+// shuffleSeed hashes the statement and the permutation commitments
+func shuffleSeed(pkX, pkY *big.Int, in, out []*Ciphertext, cX, cY []*big.Int, ctx []byte) []byte {
+	bs := [][]byte{challengeContext(shuffleTag, ctx), elliptic.Marshal(curve, pkX, pkY)}
+	for _, l := range [][]*Ciphertext{in, out} {
+		for _, e := range l {
+			bs = append(bs, elliptic.Marshal(curve, e.HX, e.HY), elliptic.Marshal(curve, e.YX, e.YY))
+		}
+	}
+	for i := range cX {
+		bs = append(bs, elliptic.Marshal(curve, cX[i], cY[i]))
+	}
+	h := sha256.Sum256(common.ConcatBytes(bs...))
+	return h[:]
+}
+
+// shuffleChallenges returns the challenges u_i = H(seed, i)
+func shuffleChallenges(pkX, pkY *big.Int, in, out []*Ciphertext, cX, cY []*big.Int, ctx []byte) []*big.Int {
+	seed := shuffleSeed(pkX, pkY, in, out, cX, cY, ctx)
+
+	u := make([]*big.Int, len(in))
+	for i := range u {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		h := sha256.Sum256(append(append([]byte(nil), seed...), buf[:]...))
+		u[i] = new(big.Int).SetBytes(h[:])
+		u[i] = u[i].Mod(u[i], N)
+	}
+	return u
+}
+
+// challenge returns c = H(seed, ch, t)
+func (p *ShuffleProof) challenge(in, out []*Ciphertext) *big.Int {
+	bs := [][]byte{shuffleSeed(p.pkX, p.pkY, in, out, p.cX, p.cY, p.ctx)}
+	for i := range p.chX {
+		bs = append(bs, elliptic.Marshal(curve, p.chX[i], p.chY[i]))
+	}
+	for _, t := range [][2]*big.Int{
+		{p.t1X, p.t1Y}, {p.t2X, p.t2Y}, {p.t3X, p.t3Y}, {p.t4hX, p.t4hY}, {p.t4yX, p.t4yY},
+	} {
+		bs = append(bs, elliptic.Marshal(curve, t[0], t[1]))
+	}
+	for i := range p.thX {
+		bs = append(bs, elliptic.Marshal(curve, p.thX[i], p.thY[i]))
+	}
+	h := sha256.Sum256(common.ConcatBytes(bs...))
+
+	c := new(big.Int).SetBytes(h[:])
+	return c.Mod(c, N)
+}
+
+// BuildJSONShuffleProof builds json object
+func (p *ShuffleProof) BuildJSONShuffleProof() *JSONShuffleProof {
+	points := func(X, Y []*big.Int) []*JSONPoint {
+		obj := make([]*JSONPoint, len(X))
+		for i := range X {
+			obj[i] = newJSONPoint(X[i], Y[i])
+		}
+		return obj
+	}
+	scalars := func(s []*big.Int) []string {
+		obj := make([]string, len(s))
+		for i := range s {
+			obj[i] = common.BigIntToHexStr(s[i])
+		}
+		return obj
+	}
+
+	return &JSONShuffleProof{
+		PK:  newJSONPoint(p.pkX, p.pkY),
+		C:   points(p.cX, p.cY),
+		CH:  points(p.chX, p.chY),
+		T1:  newJSONPoint(p.t1X, p.t1Y),
+		T2:  newJSONPoint(p.t2X, p.t2Y),
+		T3:  newJSONPoint(p.t3X, p.t3Y),
+		T4H: newJSONPoint(p.t4hX, p.t4hY),
+		T4Y: newJSONPoint(p.t4yX, p.t4yY),
+		TH:  points(p.thX, p.thY),
+		S1:  common.BigIntToHexStr(p.s1),
+		S2:  common.BigIntToHexStr(p.s2),
+		S3:  common.BigIntToHexStr(p.s3),
+		S4:  common.BigIntToHexStr(p.s4),
+		SH:  scalars(p.sh),
+		SP:  scalars(p.sp),
+		Ctx: ctxToHexStr(p.ctx),
+	}
+}
+
+// MarshalJSON implements json marshal
+func (p *ShuffleProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BuildJSONShuffleProof())
+}
+
+// FromJSONShuffleProof reconstructs proof from json object
+func (p *ShuffleProof) FromJSONShuffleProof(obj *JSONShuffleProof) error {
+	if obj == nil {
+		return errors.New("Empty shuffle proof")
+	}
+
+	var err error
+	p1 := new(ShuffleProof)
+
+	points := []struct {
+		dstX, dstY **big.Int
+		src        *JSONPoint
+	}{
+		{&p1.pkX, &p1.pkY, obj.PK},
+		{&p1.t1X, &p1.t1Y, obj.T1}, {&p1.t2X, &p1.t2Y, obj.T2}, {&p1.t3X, &p1.t3Y, obj.T3},
+		{&p1.t4hX, &p1.t4hY, obj.T4H}, {&p1.t4yX, &p1.t4yY, obj.T4Y},
+	}
+	for _, pt := range points {
+		if *pt.dstX, *pt.dstY, err = pt.src.toPoint(); err != nil {
+			return err
+		}
+	}
+
+	lists := []struct {
+		dstX, dstY *[]*big.Int
+		src        []*JSONPoint
+	}{
+		{&p1.cX, &p1.cY, obj.C}, {&p1.chX, &p1.chY, obj.CH}, {&p1.thX, &p1.thY, obj.TH},
+	}
+	for _, l := range lists {
+		*l.dstX = make([]*big.Int, len(l.src))
+		*l.dstY = make([]*big.Int, len(l.src))
+		for i, pt := range l.src {
+			if (*l.dstX)[i], (*l.dstY)[i], err = pt.toPoint(); err != nil {
+				return err
+			}
+		}
+	}
+
+	scalars := []struct {
+		dst **big.Int
+		src string
+	}{
+		{&p1.s1, obj.S1}, {&p1.s2, obj.S2}, {&p1.s3, obj.S3}, {&p1.s4, obj.S4},
+	}
+	for _, s := range scalars {
+		if *s.dst, err = common.HexStrToBigInt(s.src); err != nil {
+			return err
+		}
+	}
+	for _, l := range []struct {
+		dst *[]*big.Int
+		src []string
+	}{{&p1.sh, obj.SH}, {&p1.sp, obj.SP}} {
+		*l.dst = make([]*big.Int, len(l.src))
+		for i, s := range l.src {
+			if (*l.dst)[i], err = common.HexStrToBigInt(s); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p1.ctx, err = hexStrToCtx(obj.Ctx); err != nil {
+		return err
+	}
+
+	*p = *p1
+
+	return nil
+}
+
+// UnmarshalJSON implements json unmarshal
+func (p *ShuffleProof) UnmarshalJSON(data []byte) error {
+	var obj JSONShuffleProof
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return p.FromJSONShuffleProof(&obj)
+}
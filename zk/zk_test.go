@@ -152,3 +152,245 @@ func TestECFSProofJSON(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, *proof, reconstruct)
 }
+
+func TestDLEQ(t *testing.T) {
+	x, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	h, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	data := sha256.Sum256(common.ConcatBytes(x.PublicKey.X.Bytes(), x.PublicKey.Y.Bytes()))
+
+	prover, err := NewDLEQProver(x.D, Gx, Gy, h.PublicKey.X, h.PublicKey.Y)
+	assert.Nil(t, err)
+	proof, err := prover.Prove(new(big.Int).SetBytes(data[:]))
+	assert.Nil(t, err)
+
+	res, err := proof.Verify()
+	assert.Nil(t, err)
+	assert.True(t, res)
+
+	// json round trip
+	b, err := json.Marshal(proof)
+	assert.Nil(t, err)
+	var reconstruct DLEQProof
+	assert.Nil(t, json.Unmarshal(b, &reconstruct))
+	assert.Equal(t, *proof, reconstruct)
+
+	// tampered statement
+	proof.y2X, proof.y2Y = curve.Add(proof.y2X, proof.y2Y, Gx, Gy)
+	res, _ = proof.Verify()
+	assert.False(t, res)
+}
+
+func TestRangeProof(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	data := big.NewInt(1234)
+
+	for _, v := range []int64{0, 1, 5, 7} {
+		prover, err := NewRangeProver(big.NewInt(v), 3, nil, k.PublicKey.X, k.PublicKey.Y)
+		assert.Nil(t, err)
+		proof, err := prover.Prove(data)
+		assert.Nil(t, err)
+
+		res, err := proof.Verify()
+		assert.Nil(t, err)
+		assert.True(t, res)
+		assert.Equal(t, 3, proof.Bits())
+
+		// ciphertext decrypts to g^v
+		X, Y := curve.ScalarMult(proof.gaX, proof.gaY, k.D.Bytes())
+		X, Y = curve.Add(proof.yX, proof.yY, X, new(big.Int).Sub(curve.Params().P, Y))
+		gvX, gvY := curve.ScalarBaseMult(big.NewInt(v).Bytes())
+		assert.Equal(t, 0, X.Cmp(gvX))
+		assert.Equal(t, 0, Y.Cmp(gvY))
+
+		// json round trip
+		b, err := json.Marshal(proof)
+		assert.Nil(t, err)
+		var reconstruct RangeProof
+		assert.Nil(t, json.Unmarshal(b, &reconstruct))
+		res, err = reconstruct.Verify()
+		assert.Nil(t, err)
+		assert.True(t, res)
+	}
+
+	// out of range
+	_, err := NewRangeProver(big.NewInt(8), 3, nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.Equal(t, ErrOutOfRange, err)
+}
+
+func TestProductProof(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	a, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	data := big.NewInt(5678)
+
+	// E = (g^a, g^{ka} g^{-3})
+	x := big.NewInt(-3)
+	gxX, gxY := curve.ScalarBaseMult(new(big.Int).Mod(x, N).Bytes())
+	yX, yY := curve.ScalarMult(k.PublicKey.X, k.PublicKey.Y, a.D.Bytes())
+	yX, yY = curve.Add(yX, yY, gxX, gxY)
+
+	prover, err := NewProductProver(x, a.D, k.PublicKey.X, k.PublicKey.Y, a.PublicKey.X, a.PublicKey.Y, yX, yY)
+	assert.Nil(t, err)
+	proof, err := prover.Prove(data)
+	assert.Nil(t, err)
+
+	res, err := proof.Verify()
+	assert.Nil(t, err)
+	assert.True(t, res)
+
+	// S decrypts to g^9
+	sHX, sHY, sYX, sYY := proof.Product()
+	X, Y := curve.ScalarMult(sHX, sHY, k.D.Bytes())
+	X, Y = curve.Add(sYX, sYY, X, new(big.Int).Sub(curve.Params().P, Y))
+	g9X, g9Y := curve.ScalarBaseMult(big.NewInt(9).Bytes())
+	assert.Equal(t, 0, X.Cmp(g9X))
+	assert.Equal(t, 0, Y.Cmp(g9Y))
+
+	// json round trip
+	b, err := json.Marshal(proof)
+	assert.Nil(t, err)
+	var reconstruct ProductProof
+	assert.Nil(t, json.Unmarshal(b, &reconstruct))
+	assert.Equal(t, *proof, reconstruct)
+
+	// S encrypting a different value is rejected
+	proof.sYX, proof.sYY = curve.Add(proof.sYX, proof.sYY, Gx, Gy)
+	res, _ = proof.Verify()
+	assert.False(t, res)
+}
+
+func TestProofContext(t *testing.T) {
+	a, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	data := big.NewInt(1234)
+	ctx := sha256.Sum256([]byte("election"))
+
+	bp, err := NewBinaryProver(true, a.D, a.PublicKey.X, a.PublicKey.Y, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	bproof, err := bp.ProveInContext(ctx[:], data)
+	assert.Nil(t, err)
+
+	ep, err := NewECFSProver(k.D, a.PublicKey.X, a.PublicKey.Y)
+	assert.Nil(t, err)
+	eproof, err := ep.ProveInContext(ctx[:], data)
+	assert.Nil(t, err)
+
+	dp, err := NewDLEQProver(k.D, Gx, Gy, a.PublicKey.X, a.PublicKey.Y)
+	assert.Nil(t, err)
+	dproof, err := dp.ProveInContext(ctx[:], data)
+	assert.Nil(t, err)
+
+	rp, err := NewRangeProver(big.NewInt(5), 3, nil, k.PublicKey.X, k.PublicKey.Y)
+	assert.Nil(t, err)
+	rproof, err := rp.ProveInContext(ctx[:], data)
+	assert.Nil(t, err)
+
+	pp, err := NewProductProver(big.NewInt(3), a.D, k.PublicKey.X, k.PublicKey.Y, a.PublicKey.X, a.PublicKey.Y, Gx, Gy)
+	assert.Nil(t, err)
+	pproof, err := pp.ProveInContext(ctx[:], data)
+	assert.Nil(t, err)
+
+	proofs := []interface {
+		Verify() (bool, error)
+		Context() []byte
+	}{bproof, eproof, dproof, rproof, pproof}
+
+	for _, p := range proofs {
+		assert.Equal(t, ctx[:], p.Context())
+		res, err := p.Verify()
+		assert.Nil(t, err)
+		assert.True(t, res)
+	}
+
+	// json round trip keeps the context
+	b, err := json.Marshal(eproof)
+	assert.Nil(t, err)
+	var reconstruct ECFSProof
+	assert.Nil(t, json.Unmarshal(b, &reconstruct))
+	assert.Equal(t, *eproof, reconstruct)
+	b, err = json.Marshal(pproof)
+	assert.Nil(t, err)
+	var product ProductProof
+	assert.Nil(t, json.Unmarshal(b, &product))
+	assert.Equal(t, *pproof, product)
+
+	// proofs are invalid in another context or without one
+	bproof.ctx[0] ^= 1
+	eproof.ctx = nil
+	dproof.ctx = ctx[:16]
+	rproof.bits[1].ctx = nil
+	pproof.ctx[0] ^= 1
+	for _, p := range proofs {
+		res, _ := p.Verify()
+		assert.False(t, res)
+	}
+
+	// nor as unbound proofs of the context followed by the data
+	eproof, err = ep.ProveInContext(ctx[:], data)
+	assert.Nil(t, err)
+	eproof.ctx = nil
+	eproof.data = new(big.Int).SetBytes(append(ctx[:], data.Bytes()...))
+	res, _ := eproof.Verify()
+	assert.False(t, res)
+}
+
+func TestShuffleProof(t *testing.T) {
+	k, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	pkX, pkY := k.PublicKey.X, k.PublicKey.Y
+
+	for n := 1; n <= 5; n++ {
+		in := make([]*Ciphertext, n)
+		for i := range in {
+			a, _ := ecrand()
+			e := &Ciphertext{HX: new(big.Int), HY: new(big.Int), YX: Gx, YY: Gy}
+			in[i] = e.ReEncrypt(pkX, pkY, a)
+		}
+
+		// out[i] = in[perm[i]] re-encrypted, perm rotates by one
+		perm := make([]int, n)
+		r := make([]*big.Int, n)
+		out := make([]*Ciphertext, n)
+		for i := range perm {
+			perm[i] = (i + 1) % n
+			r[i], _ = ecrand()
+			out[i] = in[perm[i]].ReEncrypt(pkX, pkY, r[i])
+		}
+
+		prover, err := NewShuffleProver(pkX, pkY, in, out, perm, r)
+		assert.Nil(t, err)
+		proof, err := prover.ProveInContext([]byte("election"))
+		assert.Nil(t, err)
+
+		res, err := proof.Verify(in, out)
+		assert.Nil(t, err)
+		assert.True(t, res)
+
+		b, err := json.Marshal(proof)
+		assert.Nil(t, err)
+		var reconstruct ShuffleProof
+		assert.Nil(t, json.Unmarshal(b, &reconstruct))
+		res, err = reconstruct.Verify(in, out)
+		assert.Nil(t, err)
+		assert.True(t, res)
+
+		// tampered output
+		out1 := append([]*Ciphertext(nil), out...)
+		out1[0] = out[0].ReEncrypt(pkX, pkY, big.NewInt(1))
+		res, _ = proof.Verify(in, out1)
+		assert.False(t, res)
+		out1[0] = &Ciphertext{HX: out[0].HX, HY: out[0].HY}
+		out1[0].YX, out1[0].YY = curve.Add(out[0].YX, out[0].YY, Gx, Gy)
+		res, _ = proof.Verify(in, out1)
+		assert.False(t, res)
+
+		if n > 1 {
+			out1[0], out1[1] = out[1], out[0]
+			res, _ = proof.Verify(in, out1)
+			assert.False(t, res)
+		}
+	}
+
+	// output not a shuffle of input
+	in := []*Ciphertext{{Gx, Gy, Gx, Gy}}
+	_, err := NewShuffleProver(pkX, pkY, in, in, []int{0}, []*big.Int{big.NewInt(1)})
+	assert.NotNil(t, err)
+}